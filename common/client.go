@@ -0,0 +1,303 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DEFAULT_API_BASE_URL is the base URL of the public GitHub REST API
+	DEFAULT_API_BASE_URL = "https://api.github.com"
+	// AUTH_METHOD_TOKEN uses the configured token, letting a per-request Authorization header override it
+	AUTH_METHOD_TOKEN = "token"
+	// AUTH_METHOD_HEADER only uses the Authorization header of each incoming request
+	AUTH_METHOD_HEADER = "header"
+	// MAX_RATE_LIMIT_WAIT bounds how long a rate limited request waits before it is retried.
+	// When GitHub asks for a longer wait the rate limit error is returned instead.
+	MAX_RATE_LIMIT_WAIT = time.Minute
+)
+
+// RetryOptions controls how failed requests are retried
+type RetryOptions struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// CacheOptions controls the in-memory cache of GET responses
+type CacheOptions struct {
+	Enabled    bool
+	TTL        time.Duration
+	MaxEntries int
+}
+
+// PolicyOptions restricts what the server is allowed to do against the GitHub API
+type PolicyOptions struct {
+	// ReadOnly rejects every request that is not a GET or HEAD
+	ReadOnly bool
+	// AllowedRepositories limits repository-scoped requests to matching "owner/repo" patterns
+	AllowedRepositories []string
+}
+
+// ClientOptions configures how requests are sent to the GitHub API
+type ClientOptions struct {
+	BaseURL    string
+	AuthMethod string
+	Token      string
+	Timeout    time.Duration
+	Retry      RetryOptions
+	Cache      CacheOptions
+	Policy     PolicyOptions
+	// Signer signs commits created through the Git database API. Commits are unsigned when nil.
+	Signer *CommitSigner
+}
+
+// DefaultClientOptions returns the options used when Configure has not been called
+func DefaultClientOptions() ClientOptions {
+	return ClientOptions{
+		BaseURL:    DEFAULT_API_BASE_URL,
+		AuthMethod: AUTH_METHOD_TOKEN,
+		Timeout:    30 * time.Second,
+		Retry: RetryOptions{
+			MaxAttempts:    1,
+			InitialBackoff: 500 * time.Millisecond,
+			MaxBackoff:     10 * time.Second,
+		},
+	}
+}
+
+var (
+	clientMu      sync.RWMutex
+	clientOptions = DefaultClientOptions()
+	responseCache = newCache(clientOptions.Cache)
+)
+
+// Configure replaces the client options used by every subsequent request
+func Configure(options ClientOptions) {
+	if options.BaseURL == "" {
+		options.BaseURL = DEFAULT_API_BASE_URL
+	}
+	options.BaseURL = strings.TrimRight(options.BaseURL, "/")
+	if options.AuthMethod == "" {
+		options.AuthMethod = AUTH_METHOD_TOKEN
+	}
+	if options.Retry.MaxAttempts < 1 {
+		options.Retry.MaxAttempts = 1
+	}
+
+	clientMu.Lock()
+	defer clientMu.Unlock()
+	clientOptions = options
+	responseCache = newCache(options.Cache)
+}
+
+// CurrentClientOptions returns the client options currently in effect
+func CurrentClientOptions() ClientOptions {
+	clientMu.RLock()
+	defer clientMu.RUnlock()
+	return clientOptions
+}
+
+// APIURL builds an absolute GitHub API URL from a path relative to the configured base URL
+func APIURL(format string, args ...interface{}) string {
+	return CurrentClientOptions().BaseURL + fmt.Sprintf(format, args...)
+}
+
+// checkPolicy verifies that a request is allowed by the configured policy
+func checkPolicy(policy PolicyOptions, write bool, urlStr string) error {
+	if policy.ReadOnly && write {
+		return &PolicyError{Reason: "write requests are not allowed in read-only mode"}
+	}
+
+	if len(policy.AllowedRepositories) == 0 {
+		return nil
+	}
+
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return err
+	}
+
+	// Repository-scoped endpoints look like .../repos/{owner}/{repo}/...
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i+2 < len(segments); i++ {
+		if segments[i] != "repos" {
+			continue
+		}
+		fullName := segments[i+1] + "/" + segments[i+2]
+		if !RepositoryAllowed(policy.AllowedRepositories, fullName) {
+			return &PolicyError{Reason: fmt.Sprintf("repository %s is not in the allowed repositories list", fullName)}
+		}
+		return nil
+	}
+
+	return nil
+}
+
+// RepositoryAllowed reports whether an "owner/repo" name matches one of the given glob patterns
+func RepositoryAllowed(patterns []string, fullName string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(fullName)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns how long to wait before the given retry attempt
+func (r RetryOptions) backoff(attempt int) time.Duration {
+	wait := r.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		wait *= 2
+		if r.MaxBackoff > 0 && wait >= r.MaxBackoff {
+			return r.MaxBackoff
+		}
+	}
+	return wait
+}
+
+// retryWait decides whether a response is retried and how long to wait first. Writes are only
+// retried when a rate limit rejected them, as GitHub may have applied a write that failed otherwise.
+func (r RetryOptions) retryWait(write bool, status int, header http.Header, attempt int) (time.Duration, bool) {
+	if isRateLimited(status, header) {
+		wait, ok := rateLimitWait(header)
+		if !ok {
+			return r.backoff(attempt), true
+		}
+		return wait, wait <= MAX_RATE_LIMIT_WAIT
+	}
+	return r.backoff(attempt), !write && (status == 502 || status == 503 || status == 504)
+}
+
+// isRateLimited reports whether a response was rejected by a rate limit. GitHub reports
+// exhausted and secondary rate limits as 403 with rate limit headers.
+func isRateLimited(status int, header http.Header) bool {
+	return status == 429 ||
+		(status == 403 && (header.Get("X-RateLimit-Remaining") == "0" || header.Get("Retry-After") != ""))
+}
+
+// rateLimitWait returns how long a rate limited response asks the client to wait, from
+// Retry-After or else X-RateLimit-Reset. It returns false when neither header is set.
+func rateLimitWait(header http.Header) (time.Duration, bool) {
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		if wait := time.Until(time.Unix(reset, 0)); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+type cacheEntry struct {
+	value     *rawResponse
+	expiresAt time.Time
+}
+
+// responseCacheStore is a small TTL cache for GET responses
+type responseCacheStore struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]cacheEntry
+}
+
+func newCache(options CacheOptions) *responseCacheStore {
+	if !options.Enabled || options.TTL <= 0 {
+		return nil
+	}
+	return &responseCacheStore{
+		ttl:        options.TTL,
+		maxEntries: options.MaxEntries,
+		entries:    make(map[string]cacheEntry),
+	}
+}
+
+// cacheKey scopes cached responses to the token that fetched them
+func cacheKey(urlStr string, token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8]) + " " + urlStr
+}
+
+// lookup returns the cached response for key and whether it is still fresh.
+// Expired responses with an ETag are kept so they can be revalidated.
+func (c *responseCacheStore) lookup(key string) (*rawResponse, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		if entry.value.header.Get("ETag") != "" {
+			return entry.value, false
+		}
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *responseCacheStore) set(key string, value *rawResponse) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		// Drop expired entries first, then the one closest to expiry
+		oldestKey := ""
+		var oldest time.Time
+		for k, e := range c.entries {
+			if now.After(e.expiresAt) {
+				delete(c.entries, k)
+				continue
+			}
+			if oldestKey == "" || e.expiresAt.Before(oldest) {
+				oldestKey, oldest = k, e.expiresAt
+			}
+		}
+		if len(c.entries) >= c.maxEntries && oldestKey != "" {
+			delete(c.entries, oldestKey)
+		}
+	}
+	c.entries[key] = cacheEntry{value: value, expiresAt: now.Add(c.ttl)}
+}
+
+// invalidate drops every cached response, used after write requests
+func (c *responseCacheStore) invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]cacheEntry)
+}
+
+// logRequest logs a completed API request at debug level
+func logRequest(method string, urlStr string, status int, attempt int, elapsed time.Duration) {
+	slog.Debug("github api request",
+		"method", method,
+		"url", urlStr,
+		"status", status,
+		"attempt", attempt,
+		"elapsed", elapsed,
+	)
+}
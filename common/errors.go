@@ -1,10 +1,24 @@
 package common
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// APIError is implemented by every GitHub API error type. Wrapped errors can be
+// recovered with errors.As, or classified with the Is* helpers below.
+type APIError interface {
+	error
+	StatusCode() int
+	APIMessage() string
+	APIResponse() interface{}
+}
+
 // GitHubError is the base error type for GitHub API errors
 type GitHubError struct {
 	Message  string
@@ -16,6 +30,21 @@ func (e *GitHubError) Error() string {
 	return fmt.Sprintf("GitHub API Error: %s", e.Message)
 }
 
+// StatusCode returns the HTTP status of the failed request
+func (e *GitHubError) StatusCode() int {
+	return e.Status
+}
+
+// APIMessage returns the message GitHub sent with the error
+func (e *GitHubError) APIMessage() string {
+	return e.Message
+}
+
+// APIResponse returns the decoded error response body
+func (e *GitHubError) APIResponse() interface{} {
+	return e.Response
+}
+
 // GitHubValidationError represents validation errors from GitHub API
 type GitHubValidationError struct {
 	GitHubError
@@ -25,6 +54,11 @@ func (e *GitHubValidationError) Error() string {
 	return fmt.Sprintf("Validation Error: %s", e.Message)
 }
 
+// Unwrap exposes the base GitHubError to errors.As
+func (e *GitHubValidationError) Unwrap() error {
+	return &e.GitHubError
+}
+
 // GitHubResourceNotFoundError represents 404 not found errors
 type GitHubResourceNotFoundError struct {
 	GitHubError
@@ -34,6 +68,11 @@ func (e *GitHubResourceNotFoundError) Error() string {
 	return fmt.Sprintf("Not Found: %s", e.Message)
 }
 
+// Unwrap exposes the base GitHubError to errors.As
+func (e *GitHubResourceNotFoundError) Unwrap() error {
+	return &e.GitHubError
+}
+
 // GitHubAuthenticationError represents authentication failures
 type GitHubAuthenticationError struct {
 	GitHubError
@@ -43,6 +82,11 @@ func (e *GitHubAuthenticationError) Error() string {
 	return fmt.Sprintf("Authentication Failed: %s", e.Message)
 }
 
+// Unwrap exposes the base GitHubError to errors.As
+func (e *GitHubAuthenticationError) Unwrap() error {
+	return &e.GitHubError
+}
+
 // GitHubPermissionError represents permission/authorization errors
 type GitHubPermissionError struct {
 	GitHubError
@@ -52,16 +96,27 @@ func (e *GitHubPermissionError) Error() string {
 	return fmt.Sprintf("Permission Denied: %s", e.Message)
 }
 
+// Unwrap exposes the base GitHubError to errors.As
+func (e *GitHubPermissionError) Unwrap() error {
+	return &e.GitHubError
+}
+
 // GitHubRateLimitError represents rate limit exceeded errors
 type GitHubRateLimitError struct {
 	GitHubError
-	ResetAt time.Time
+	ResetAt    time.Time
+	RetryAfter time.Duration
 }
 
 func (e *GitHubRateLimitError) Error() string {
 	return fmt.Sprintf("Rate Limit Exceeded: %s\nResets at: %s", e.Message, e.ResetAt.Format(time.RFC3339))
 }
 
+// Unwrap exposes the base GitHubError to errors.As
+func (e *GitHubRateLimitError) Unwrap() error {
+	return &e.GitHubError
+}
+
 // GitHubConflictError represents conflict errors
 type GitHubConflictError struct {
 	GitHubError
@@ -71,40 +126,108 @@ func (e *GitHubConflictError) Error() string {
 	return fmt.Sprintf("Conflict: %s", e.Message)
 }
 
-// IsGitHubError checks if an error is a GitHub API error
-func IsGitHubError(err error) bool {
-	_, ok := err.(*GitHubError)
-	if ok {
-		return true
-	}
+// Unwrap exposes the base GitHubError to errors.As
+func (e *GitHubConflictError) Unwrap() error {
+	return &e.GitHubError
+}
 
-	_, ok = err.(*GitHubValidationError)
-	if ok {
-		return true
-	}
+// ConflictError reports that a branch or file changed after the caller read it, or no longer
+// matches what a change was made against, so the change could not be applied without
+// overwriting someone else's work
+type ConflictError struct {
+	Ref              string
+	Path             string
+	ExpectedSHA      string
+	ActualSHA        string
+	ConflictingPaths []string
+	Reason           string
+}
 
-	_, ok = err.(*GitHubResourceNotFoundError)
-	if ok {
-		return true
+func (e *ConflictError) Error() string {
+	subject := e.Ref
+	if e.Path != "" {
+		subject = e.Path
 	}
-
-	_, ok = err.(*GitHubAuthenticationError)
-	if ok {
-		return true
+	msg := fmt.Sprintf("Conflict: %s has changed", subject)
+	if e.ExpectedSHA != "" {
+		msg += fmt.Sprintf(", expected %s but found %s", e.ExpectedSHA, e.ActualSHA)
+	}
+	if e.Reason != "" {
+		msg += ": " + e.Reason
 	}
+	if len(e.ConflictingPaths) > 0 {
+		msg += fmt.Sprintf(" (conflicting paths: %s)", strings.Join(e.ConflictingPaths, ", "))
+	}
+	return msg
+}
 
-	_, ok = err.(*GitHubPermissionError)
-	if ok {
-		return true
+// PolicyError reports a request refused by the configured policy before it reached GitHub
+type PolicyError struct {
+	Reason string
+}
+
+func (e *PolicyError) Error() string {
+	return "policy violation: " + e.Reason
+}
+
+// IsGitHubError checks if an error is, or wraps, a GitHub API error
+func IsGitHubError(err error) bool {
+	var apiErr APIError
+	return errors.As(err, &apiErr)
+}
+
+// AsGitHubError returns the GitHub API error wrapped in err, if any
+func AsGitHubError(err error) (APIError, bool) {
+	var apiErr APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
 	}
+	return nil, false
+}
 
-	_, ok = err.(*GitHubRateLimitError)
-	if ok {
-		return true
+// ErrorStatus returns the HTTP status of a GitHub API error wrapped in err, or 0
+func ErrorStatus(err error) int {
+	if apiErr, ok := AsGitHubError(err); ok {
+		return apiErr.StatusCode()
 	}
+	return 0
+}
+
+// IsNotFound checks if err is, or wraps, a not found error
+func IsNotFound(err error) bool {
+	var target *GitHubResourceNotFoundError
+	return errors.As(err, &target)
+}
+
+// IsConflict checks if err is, or wraps, a conflict error reported by GitHub or detected locally
+func IsConflict(err error) bool {
+	var target *GitHubConflictError
+	var conflictErr *ConflictError
+	return errors.As(err, &target) || errors.As(err, &conflictErr)
+}
+
+// IsValidationError checks if err is, or wraps, a validation error
+func IsValidationError(err error) bool {
+	var target *GitHubValidationError
+	return errors.As(err, &target)
+}
+
+// IsRateLimited checks if err is, or wraps, a rate limit error
+func IsRateLimited(err error) bool {
+	var target *GitHubRateLimitError
+	return errors.As(err, &target)
+}
 
-	_, ok = err.(*GitHubConflictError)
-	return ok
+// IsAuthenticationError checks if err is, or wraps, an authentication error
+func IsAuthenticationError(err error) bool {
+	var target *GitHubAuthenticationError
+	return errors.As(err, &target)
+}
+
+// IsPermissionError checks if err is, or wraps, a permission error
+func IsPermissionError(err error) bool {
+	var target *GitHubPermissionError
+	return errors.As(err, &target)
 }
 
 // CreateGitHubError creates the appropriate GitHub error based on status code
@@ -188,28 +311,242 @@ func CreateGitHubError(status int, response interface{}) error {
 	}
 }
 
+// createGitHubErrorFromResponse creates a GitHub error, using the response headers to
+// recognise rate limits reported as 403 and to tell when the client may retry
+func createGitHubErrorFromResponse(status int, response interface{}, header http.Header) error {
+	if !isRateLimited(status, header) {
+		return CreateGitHubError(status, response)
+	}
+
+	err := CreateGitHubError(429, response).(*GitHubRateLimitError)
+	err.Status = status
+	if reset, parseErr := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); parseErr == nil {
+		err.ResetAt = time.Unix(reset, 0)
+	}
+	if seconds, parseErr := strconv.Atoi(header.Get("Retry-After")); parseErr == nil {
+		err.RetryAfter = time.Duration(seconds) * time.Second
+		err.ResetAt = time.Now().Add(err.RetryAfter)
+	}
+	return err
+}
+
 // FormatGitHubError formats a GitHub error for display
 func FormatGitHubError(err error) string {
-	switch e := err.(type) {
-	case *GitHubValidationError:
+	var (
+		validationErr *GitHubValidationError
+		notFoundErr   *GitHubResourceNotFoundError
+		authErr       *GitHubAuthenticationError
+		permissionErr *GitHubPermissionError
+		rateLimitErr  *GitHubRateLimitError
+		conflictErr   *GitHubConflictError
+		baseErr       *GitHubError
+	)
+
+	switch {
+	case errors.As(err, &validationErr):
 		details := ""
-		if e.Response != nil {
-			details = fmt.Sprintf("\nDetails: %v", e.Response)
-		}
-		return fmt.Sprintf("Validation Error: %s%s", e.Message, details)
-	case *GitHubResourceNotFoundError:
-		return fmt.Sprintf("Not Found: %s", e.Message)
-	case *GitHubAuthenticationError:
-		return fmt.Sprintf("Authentication Failed: %s", e.Message)
-	case *GitHubPermissionError:
-		return fmt.Sprintf("Permission Denied: %s", e.Message)
-	case *GitHubRateLimitError:
-		return fmt.Sprintf("Rate Limit Exceeded: %s\nResets at: %s", e.Message, e.ResetAt.Format(time.RFC3339))
-	case *GitHubConflictError:
-		return fmt.Sprintf("Conflict: %s", e.Message)
-	case *GitHubError:
-		return fmt.Sprintf("GitHub API Error: %s", e.Message)
+		if respMap, ok := validationErr.Response.(map[string]interface{}); ok {
+			for _, fe := range parseFieldErrors(respMap["errors"]) {
+				details += "\n- " + fe.String()
+			}
+		}
+		return fmt.Sprintf("Validation Error: %s%s", validationErr.Message, details)
+	case errors.As(err, &notFoundErr):
+		return fmt.Sprintf("Not Found: %s", notFoundErr.Message)
+	case errors.As(err, &authErr):
+		return fmt.Sprintf("Authentication Failed: %s", authErr.Message)
+	case errors.As(err, &permissionErr):
+		return fmt.Sprintf("Permission Denied: %s", permissionErr.Message)
+	case errors.As(err, &rateLimitErr):
+		return fmt.Sprintf("Rate Limit Exceeded: %s\nResets at: %s", rateLimitErr.Message, rateLimitErr.ResetAt.Format(time.RFC3339))
+	case errors.As(err, &conflictErr):
+		return fmt.Sprintf("Conflict: %s", conflictErr.Message)
+	case errors.As(err, &baseErr):
+		return fmt.Sprintf("GitHub API Error: %s", baseErr.Message)
 	default:
 		return err.Error()
 	}
 }
+
+// Error codes reported to MCP clients
+const (
+	ERROR_CODE_NOT_FOUND     = "not_found"
+	ERROR_CODE_AUTH          = "auth"
+	ERROR_CODE_PERMISSION    = "permission"
+	ERROR_CODE_RATE_LIMITED  = "rate_limited"
+	ERROR_CODE_VALIDATION    = "validation"
+	ERROR_CODE_CONFLICT      = "conflict"
+	ERROR_CODE_GITHUB        = "github_error"
+	ERROR_CODE_INVALID_INPUT = "invalid_input"
+	ERROR_CODE_POLICY        = "policy"
+	ERROR_CODE_NETWORK       = "network"
+	ERROR_CODE_INTERNAL      = "internal"
+)
+
+// FieldError is a single entry of the "errors" array GitHub returns with 422 responses
+type FieldError struct {
+	Resource string `json:"resource,omitempty"`
+	Field    string `json:"field,omitempty"`
+	Code     string `json:"code,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// String formats the field error for display
+func (e FieldError) String() string {
+	parts := make([]string, 0, 4)
+	for _, part := range []string{e.Resource, e.Field, e.Code, e.Message} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ": ")
+}
+
+// ErrorDetails is the stable, machine readable description of an error returned by a tool
+type ErrorDetails struct {
+	Code             string       `json:"code"`
+	Message          string       `json:"message"`
+	Context          string       `json:"context,omitempty"`
+	Status           int          `json:"status,omitempty"`
+	DocumentationURL string       `json:"documentation_url,omitempty"`
+	Errors           []FieldError `json:"errors,omitempty"`
+	RetryAfter       string       `json:"retry_after,omitempty"`
+	ResetAt          string       `json:"reset_at,omitempty"`
+	Hint             string       `json:"hint,omitempty"`
+	Conflict         *Conflict    `json:"conflict,omitempty"`
+}
+
+// Conflict describes what changed concurrently when an update was rejected
+type Conflict struct {
+	Ref              string   `json:"ref,omitempty"`
+	Path             string   `json:"path,omitempty"`
+	ExpectedSHA      string   `json:"expected_sha,omitempty"`
+	ActualSHA        string   `json:"actual_sha,omitempty"`
+	ConflictingPaths []string `json:"conflicting_paths,omitempty"`
+	Reason           string   `json:"reason,omitempty"`
+}
+
+// errorHints gives agents a short remediation hint for each error code
+var errorHints = map[string]string{
+	ERROR_CODE_NOT_FOUND:     "Check the owner, repository, path and ref. Private resources also return not found when the token has no access to them.",
+	ERROR_CODE_AUTH:          "The GitHub token is missing, invalid or expired.",
+	ERROR_CODE_PERMISSION:    "The token lacks the scopes or permissions required for this operation.",
+	ERROR_CODE_RATE_LIMITED:  "Wait until the rate limit resets before retrying.",
+	ERROR_CODE_VALIDATION:    "Fix the fields listed in errors and retry.",
+	ERROR_CODE_CONFLICT:      "The resource was changed concurrently. Fetch its latest state, such as the current SHA, and retry.",
+	ERROR_CODE_INVALID_INPUT: "Fix the tool arguments and retry.",
+	ERROR_CODE_POLICY:        "The server is configured to refuse this request. Do not retry it, ask the user to change the server policy if it is needed.",
+	ERROR_CODE_NETWORK:       "GitHub could not be reached or did not answer in time. Retry the same call later.",
+	ERROR_CODE_INTERNAL:      "The server could not complete the call. Retrying with the same arguments is unlikely to help.",
+}
+
+// GetErrorDetails classifies an error, which may wrap a GitHub API error, into ErrorDetails
+func GetErrorDetails(err error) ErrorDetails {
+	details := ErrorDetails{}
+
+	var conflictErr *ConflictError
+	if errors.As(err, &conflictErr) {
+		details.Code = ERROR_CODE_CONFLICT
+		details.Message = conflictErr.Error()
+		if full, inner := err.Error(), conflictErr.Error(); full != inner && strings.HasSuffix(full, inner) {
+			details.Context = strings.TrimSuffix(strings.TrimSuffix(full, inner), ": ")
+		}
+		details.Conflict = &Conflict{
+			Ref:              conflictErr.Ref,
+			Path:             conflictErr.Path,
+			ExpectedSHA:      conflictErr.ExpectedSHA,
+			ActualSHA:        conflictErr.ActualSHA,
+			ConflictingPaths: conflictErr.ConflictingPaths,
+			Reason:           conflictErr.Reason,
+		}
+		details.Hint = errorHints[details.Code]
+		return details
+	}
+
+	apiErr, ok := AsGitHubError(err)
+	if !ok {
+		var (
+			inputErr  *InvalidInputError
+			policyErr *PolicyError
+			netErr    net.Error
+		)
+		switch {
+		case errors.As(err, &inputErr):
+			details.Code = ERROR_CODE_INVALID_INPUT
+		case errors.As(err, &policyErr):
+			details.Code = ERROR_CODE_POLICY
+		case errors.As(err, &netErr):
+			details.Code = ERROR_CODE_NETWORK
+		default:
+			details.Code = ERROR_CODE_INTERNAL
+		}
+		details.Message = err.Error()
+		details.Hint = errorHints[details.Code]
+		return details
+	}
+
+	var rateLimitErr *GitHubRateLimitError
+	switch {
+	case IsValidationError(err):
+		details.Code = ERROR_CODE_VALIDATION
+	case IsNotFound(err):
+		details.Code = ERROR_CODE_NOT_FOUND
+	case IsAuthenticationError(err):
+		details.Code = ERROR_CODE_AUTH
+	case IsPermissionError(err):
+		details.Code = ERROR_CODE_PERMISSION
+	case errors.As(err, &rateLimitErr):
+		details.Code = ERROR_CODE_RATE_LIMITED
+		details.ResetAt = rateLimitErr.ResetAt.Format(time.RFC3339)
+		if rateLimitErr.RetryAfter > 0 {
+			details.RetryAfter = rateLimitErr.RetryAfter.String()
+		} else if wait := time.Until(rateLimitErr.ResetAt); wait > 0 {
+			details.RetryAfter = wait.Round(time.Second).String()
+		}
+	case IsConflict(err):
+		details.Code = ERROR_CODE_CONFLICT
+	default:
+		details.Code = ERROR_CODE_GITHUB
+	}
+
+	details.Message = apiErr.APIMessage()
+	details.Status = apiErr.StatusCode()
+	// Keep the context added by callers that wrapped the error
+	if full, inner := err.Error(), apiErr.Error(); full != inner && strings.HasSuffix(full, inner) {
+		details.Context = strings.TrimSuffix(strings.TrimSuffix(full, inner), ": ")
+	}
+	if respMap, ok := apiErr.APIResponse().(map[string]interface{}); ok {
+		if docURL, ok := respMap["documentation_url"].(string); ok {
+			details.DocumentationURL = docURL
+		}
+		details.Errors = parseFieldErrors(respMap["errors"])
+	}
+
+	details.Hint = errorHints[details.Code]
+	return details
+}
+
+// parseFieldErrors reads the "errors" array of an error response, whose
+// entries are either objects or plain strings
+func parseFieldErrors(raw interface{}) []FieldError {
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	fieldErrors := make([]FieldError, 0, len(items))
+	for _, item := range items {
+		switch v := item.(type) {
+		case string:
+			fieldErrors = append(fieldErrors, FieldError{Message: v})
+		case map[string]interface{}:
+			fe := FieldError{}
+			fe.Resource, _ = v["resource"].(string)
+			fe.Field, _ = v["field"].(string)
+			fe.Code, _ = v["code"].(string)
+			fe.Message, _ = v["message"].(string)
+			fieldErrors = append(fieldErrors, fe)
+		}
+	}
+	return fieldErrors
+}
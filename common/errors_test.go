@@ -0,0 +1,208 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestGetErrorDetails(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   string
+		wantStatus int
+		wantFields int
+		wantDocURL string
+	}{
+		{
+			name: "validation error with field errors",
+			err: CreateGitHubError(422, map[string]interface{}{
+				"message":           "Validation Failed",
+				"documentation_url": "https://docs.github.com/rest/issues/issues#create-an-issue",
+				"errors": []interface{}{
+					map[string]interface{}{"resource": "Issue", "field": "title", "code": "missing_field"},
+					"Reference already exists",
+				},
+			}),
+			wantCode:   ERROR_CODE_VALIDATION,
+			wantStatus: 422,
+			wantFields: 2,
+			wantDocURL: "https://docs.github.com/rest/issues/issues#create-an-issue",
+		},
+		{
+			name:       "not found",
+			err:        CreateGitHubError(404, map[string]interface{}{"message": "Not Found"}),
+			wantCode:   ERROR_CODE_NOT_FOUND,
+			wantStatus: 404,
+		},
+		{
+			name:       "conflict",
+			err:        CreateGitHubError(409, nil),
+			wantCode:   ERROR_CODE_CONFLICT,
+			wantStatus: 409,
+		},
+		{
+			name: "local conflict",
+			err: fmt.Errorf("error committing edits: %w", &ConflictError{
+				Ref:         "refs/heads/main",
+				ExpectedSHA: "abc",
+				ActualSHA:   "def",
+			}),
+			wantCode: ERROR_CODE_CONFLICT,
+		},
+		{
+			name:     "invalid arguments",
+			err:      InvalidInput(fmt.Errorf("path is required")),
+			wantCode: ERROR_CODE_INVALID_INPUT,
+		},
+		{
+			name:     "policy violation",
+			err:      fmt.Errorf("error creating issue: %w", &PolicyError{Reason: "write requests are not allowed in read-only mode"}),
+			wantCode: ERROR_CODE_POLICY,
+		},
+		{
+			name:     "network error",
+			err:      &url.Error{Op: "Get", URL: "https://api.github.com", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}},
+			wantCode: ERROR_CODE_NETWORK,
+		},
+		{
+			name:     "plain error",
+			err:      fmt.Errorf("error decoding response: unexpected non-JSON response"),
+			wantCode: ERROR_CODE_INTERNAL,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := GetErrorDetails(tt.err)
+			if details.Code != tt.wantCode {
+				t.Errorf("Code = %v, want %v", details.Code, tt.wantCode)
+			}
+			if details.Status != tt.wantStatus {
+				t.Errorf("Status = %v, want %v", details.Status, tt.wantStatus)
+			}
+			if len(details.Errors) != tt.wantFields {
+				t.Errorf("Errors = %v, want %d entries", details.Errors, tt.wantFields)
+			}
+			if details.DocumentationURL != tt.wantDocURL {
+				t.Errorf("DocumentationURL = %v, want %v", details.DocumentationURL, tt.wantDocURL)
+			}
+			if details.Hint == "" {
+				t.Errorf("Hint should not be empty")
+			}
+		})
+	}
+}
+
+func TestCreateGitHubErrorFromResponseRateLimit(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-RateLimit-Remaining", "0")
+	header.Set("Retry-After", "30")
+
+	err := createGitHubErrorFromResponse(403, map[string]interface{}{"message": "API rate limit exceeded"}, header)
+	var rateLimitErr *GitHubRateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("createGitHubErrorFromResponse() = %T, want *GitHubRateLimitError", err)
+	}
+	if rateLimitErr.RetryAfter != 30*time.Second {
+		t.Errorf("RetryAfter = %v, want 30s", rateLimitErr.RetryAfter)
+	}
+
+	details := GetErrorDetails(err)
+	if details.Code != ERROR_CODE_RATE_LIMITED || details.RetryAfter != "30s" || details.Status != 403 {
+		t.Errorf("GetErrorDetails() = %+v, want rate_limited with retry_after 30s and status 403", details)
+	}
+
+	if !IsPermissionError(createGitHubErrorFromResponse(403, nil, http.Header{})) {
+		t.Errorf("a 403 without rate limit headers should be a permission error")
+	}
+}
+
+func TestGetErrorDetailsConflict(t *testing.T) {
+	err := fmt.Errorf("error pushing files: %w", &ConflictError{
+		Ref:              "refs/heads/main",
+		ExpectedSHA:      "abc",
+		ActualSHA:        "def",
+		ConflictingPaths: []string{"README.md"},
+		Reason:           "the branch changed the same files",
+	})
+
+	details := GetErrorDetails(err)
+	if details.Conflict == nil {
+		t.Fatalf("GetErrorDetails() = %+v, want conflict details", details)
+	}
+	if details.Conflict.ExpectedSHA != "abc" || details.Conflict.ActualSHA != "def" || len(details.Conflict.ConflictingPaths) != 1 {
+		t.Errorf("Conflict = %+v, want abc..def with one conflicting path", details.Conflict)
+	}
+	if details.Context != "error pushing files" {
+		t.Errorf("Context = %q, want %q", details.Context, "error pushing files")
+	}
+	if !IsConflict(err) {
+		t.Errorf("IsConflict() should recognise a ConflictError")
+	}
+}
+
+func TestWrappedGitHubErrors(t *testing.T) {
+	notFound := CreateGitHubError(404, map[string]interface{}{"message": "Not Found"})
+	conflict := CreateGitHubError(409, map[string]interface{}{"message": "Reference update failed"})
+
+	tests := []struct {
+		name        string
+		err         error
+		check       func(error) bool
+		wantStatus  int
+		wantFormat  string
+		wantContext string
+	}{
+		{
+			name:        "wrapped not found",
+			err:         fmt.Errorf("error getting source branch: %w", notFound),
+			check:       IsNotFound,
+			wantStatus:  404,
+			wantFormat:  "Not Found: Not Found",
+			wantContext: "error getting source branch",
+		},
+		{
+			name:        "doubly wrapped conflict",
+			err:         fmt.Errorf("push failed: %w", fmt.Errorf("error updating reference: %w", conflict)),
+			check:       IsConflict,
+			wantStatus:  409,
+			wantFormat:  "Conflict: Reference update failed",
+			wantContext: "push failed: error updating reference",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !IsGitHubError(tt.err) {
+				t.Errorf("IsGitHubError() = false, want true")
+			}
+			if !tt.check(tt.err) {
+				t.Errorf("classification helper did not match the wrapped error")
+			}
+			if got := ErrorStatus(tt.err); got != tt.wantStatus {
+				t.Errorf("ErrorStatus() = %v, want %v", got, tt.wantStatus)
+			}
+			if got := FormatGitHubError(tt.err); got != tt.wantFormat {
+				t.Errorf("FormatGitHubError() = %q, want %q", got, tt.wantFormat)
+			}
+			if got := GetErrorDetails(tt.err).Context; got != tt.wantContext {
+				t.Errorf("GetErrorDetails().Context = %q, want %q", got, tt.wantContext)
+			}
+
+			var base *GitHubError
+			if !errors.As(tt.err, &base) || base.Status != tt.wantStatus {
+				t.Errorf("errors.As() should reach the base GitHubError")
+			}
+		})
+	}
+
+	if IsGitHubError(fmt.Errorf("plain error")) || ErrorStatus(fmt.Errorf("plain error")) != 0 {
+		t.Errorf("plain errors must not be classified as GitHub errors")
+	}
+}
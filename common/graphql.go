@@ -0,0 +1,294 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GRAPHQL_RATE_LIMIT_FIELDS can be added to a query to have the cost of the query reported
+const GRAPHQL_RATE_LIMIT_FIELDS = "rateLimit { cost limit remaining resetAt }"
+
+// GraphQLError is a single entry of the "errors" array of a GraphQL response
+type GraphQLError struct {
+	Type      string                 `json:"type"`
+	Message   string                 `json:"message"`
+	Path      []interface{}          `json:"path,omitempty"`
+	Locations []GraphQLErrorLocation `json:"locations,omitempty"`
+}
+
+// GraphQLErrorLocation is the position in the query an error refers to
+type GraphQLErrorLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// GraphQLRateLimit reports the rate limit state after a GraphQL request
+type GraphQLRateLimit struct {
+	// Cost is only known when the query selects GRAPHQL_RATE_LIMIT_FIELDS
+	Cost      int       `json:"cost,omitempty"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Used      int       `json:"used,omitempty"`
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+// GraphQLResponse is the result of a successful GraphQL request
+type GraphQLResponse struct {
+	Data      json.RawMessage   `json:"data"`
+	RateLimit *GraphQLRateLimit `json:"rate_limit,omitempty"`
+}
+
+// Decode unmarshals the response data into v
+func (r *GraphQLResponse) Decode(v interface{}) error {
+	if len(r.Data) == 0 || string(r.Data) == "null" {
+		return fmt.Errorf("GraphQL response has no data")
+	}
+	return json.Unmarshal(r.Data, v)
+}
+
+// PageInfo is the GraphQL connection pagination info
+type PageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// graphQLEnvelope is the top level shape of every GraphQL response
+type graphQLEnvelope struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []GraphQLError  `json:"errors"`
+}
+
+// GraphQLURL returns the GraphQL endpoint matching the configured REST base URL
+func GraphQLURL() string {
+	base := CurrentClientOptions().BaseURL
+	// GitHub Enterprise Server serves REST under /api/v3 and GraphQL under /api/graphql
+	if strings.HasSuffix(base, "/api/v3") {
+		return strings.TrimSuffix(base, "/v3") + "/graphql"
+	}
+	return base + "/graphql"
+}
+
+// isQueryDocument reports whether every operation a GraphQL document defines is a query,
+// so that whichever one operationName selects cannot change anything. Comments, strings and
+// fragments are skipped. Documents that cannot be read this far are not treated as queries.
+func isQueryDocument(document string) bool {
+	operations := 0
+	depth := 0
+	definitionStart := true
+	for i := 0; i < len(document); i++ {
+		c := document[i]
+		switch {
+		case c == '#':
+			for i < len(document) && document[i] != '\n' && document[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(document[i:], `"""`):
+			end := strings.Index(strings.ReplaceAll(document[i+3:], `\"""`, "xxxx"), `"""`)
+			if end < 0 {
+				return false
+			}
+			i += end + 5
+		case c == '"':
+			for i++; i < len(document) && document[i] != '"'; i++ {
+				if document[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(document) {
+				return false
+			}
+		case c == '{' || c == '(' || c == '[':
+			if definitionStart {
+				if c != '{' {
+					return false
+				}
+				// A selection set on its own is a shorthand query
+				operations++
+				definitionStart = false
+			}
+			depth++
+		case c == '}' || c == ')' || c == ']':
+			depth--
+			if depth < 0 {
+				return false
+			}
+			definitionStart = depth == 0 && c == '}'
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			start := i
+			for i+1 < len(document) && (document[i+1] == '_' || document[i+1] >= 'a' && document[i+1] <= 'z' || document[i+1] >= 'A' && document[i+1] <= 'Z' || document[i+1] >= '0' && document[i+1] <= '9') {
+				i++
+			}
+			if !definitionStart {
+				continue
+			}
+			switch document[start : i+1] {
+			case "query":
+				operations++
+			case "fragment":
+			default:
+				// mutation, subscription and anything that is not an executable definition
+				return false
+			}
+			definitionStart = false
+		}
+	}
+	return depth == 0 && operations > 0
+}
+
+// GraphQLRequest sends a query or mutation to the GitHub GraphQL API.
+// GraphQL errors are mapped to the same typed errors as REST errors.
+func GraphQLRequest(query string, variables map[string]interface{}, apiReqs *APIRequirements) (*GraphQLResponse, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+
+	// The allow list is enforced on REST paths, GraphQL requests have to name their repository
+	policy := CurrentClientOptions().Policy
+	if len(policy.AllowedRepositories) > 0 {
+		owner, _ := variables["owner"].(string)
+		name, _ := variables["name"].(string)
+		if name == "" {
+			name, _ = variables["repo"].(string)
+		}
+		if owner == "" || name == "" {
+			return nil, &PolicyError{Reason: "GraphQL requests must pass owner and name variables when allowed repositories are configured"}
+		}
+		if !RepositoryAllowed(policy.AllowedRepositories, owner+"/"+name) {
+			return nil, &PolicyError{Reason: fmt.Sprintf("repository %s/%s is not in the allowed repositories list", owner, name)}
+		}
+	}
+
+	body := map[string]interface{}{
+		"query": query,
+	}
+	if len(variables) > 0 {
+		body["variables"] = variables
+	}
+
+	// Every document counts as a write for the read-only policy unless it only defines queries
+	raw, resp, err := TypedGitHubRequest[graphQLEnvelope](GraphQLURL(), "POST", body, apiReqs, asWrite(!isQueryDocument(query)))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raw.Errors) > 0 {
+		return nil, CreateGraphQLError(raw.Errors)
+	}
+
+	result := &GraphQLResponse{
+		Data:      raw.Data,
+		RateLimit: parseRateLimitHeaders(resp.Header),
+	}
+
+	// Prefer the rateLimit object when the query selected it, since it also carries the cost
+	var withRateLimit struct {
+		RateLimit *struct {
+			Cost      int       `json:"cost"`
+			Limit     int       `json:"limit"`
+			Remaining int       `json:"remaining"`
+			ResetAt   time.Time `json:"resetAt"`
+		} `json:"rateLimit"`
+	}
+	if len(raw.Data) > 0 && json.Unmarshal(raw.Data, &withRateLimit) == nil && withRateLimit.RateLimit != nil {
+		if result.RateLimit == nil {
+			result.RateLimit = &GraphQLRateLimit{}
+		}
+		result.RateLimit.Cost = withRateLimit.RateLimit.Cost
+		result.RateLimit.Limit = withRateLimit.RateLimit.Limit
+		result.RateLimit.Remaining = withRateLimit.RateLimit.Remaining
+		result.RateLimit.ResetAt = withRateLimit.RateLimit.ResetAt
+	}
+
+	return result, nil
+}
+
+// GraphQLPaginate runs a query repeatedly, passing the previous end cursor as the
+// "cursor" variable, until there are no more pages or maxPages is reached (0 means no limit).
+// pageInfo is called with each response to collect its nodes and return the connection's page info.
+func GraphQLPaginate(query string, variables map[string]interface{}, apiReqs *APIRequirements, maxPages int, pageInfo func(resp *GraphQLResponse) (PageInfo, error)) error {
+	vars := make(map[string]interface{}, len(variables)+1)
+	for k, v := range variables {
+		vars[k] = v
+	}
+
+	for page := 1; maxPages <= 0 || page <= maxPages; page++ {
+		resp, err := GraphQLRequest(query, vars, apiReqs)
+		if err != nil {
+			return err
+		}
+
+		info, err := pageInfo(resp)
+		if err != nil {
+			return err
+		}
+		if !info.HasNextPage || info.EndCursor == "" {
+			return nil
+		}
+		vars["cursor"] = info.EndCursor
+	}
+	return nil
+}
+
+// CreateGraphQLError maps the errors array of a GraphQL response to a typed GitHub error
+func CreateGraphQLError(errs []GraphQLError) error {
+	messages := make([]string, 0, len(errs))
+	details := make([]interface{}, 0, len(errs))
+	for _, e := range errs {
+		messages = append(messages, e.Message)
+		details = append(details, map[string]interface{}{
+			"code":    e.Type,
+			"message": e.Message,
+		})
+	}
+
+	response := map[string]interface{}{
+		"message": strings.Join(messages, "; "),
+		"errors":  details,
+	}
+
+	// The first typed error decides the classification
+	status := 422
+	for _, e := range errs {
+		if s, ok := graphQLErrorStatus[e.Type]; ok {
+			status = s
+			break
+		}
+	}
+	return CreateGitHubError(status, response)
+}
+
+// graphQLErrorStatus maps GraphQL error types to the equivalent REST status codes
+var graphQLErrorStatus = map[string]int{
+	"UNAUTHORIZED":            401,
+	"FORBIDDEN":               403,
+	"INSUFFICIENT_SCOPES":     403,
+	"NOT_FOUND":               404,
+	"CONFLICT":                409,
+	"UNPROCESSABLE":           422,
+	"RATE_LIMITED":            429,
+	"MAX_NODE_LIMIT_EXCEEDED": 422,
+}
+
+// parseRateLimitHeaders reads the X-RateLimit-* headers of a response
+func parseRateLimitHeaders(header http.Header) *GraphQLRateLimit {
+	if header == nil || header.Get("X-RateLimit-Limit") == "" {
+		return nil
+	}
+
+	limit, _ := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	remaining, _ := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	used, _ := strconv.Atoi(header.Get("X-RateLimit-Used"))
+	rateLimit := &GraphQLRateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+		Used:      used,
+	}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rateLimit.ResetAt = time.Unix(reset, 0).UTC()
+	}
+	return rateLimit
+}
@@ -0,0 +1,188 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGraphQLURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		want    string
+	}{
+		{
+			name:    "github.com",
+			baseURL: "https://api.github.com",
+			want:    "https://api.github.com/graphql",
+		},
+		{
+			name:    "enterprise server",
+			baseURL: "https://github.example.com/api/v3",
+			want:    "https://github.example.com/api/graphql",
+		},
+	}
+
+	defer Configure(DefaultClientOptions())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Configure(ClientOptions{BaseURL: tt.baseURL})
+			if got := GraphQLURL(); got != tt.want {
+				t.Errorf("GraphQLURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsQueryDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     bool
+	}{
+		{name: "query", document: `query($owner: String!) { repository(owner: $owner, name: "x") { id } }`, want: true},
+		{name: "shorthand query", document: `{ viewer { login } }`, want: true},
+		{name: "queries and fragments", document: "query A { viewer { ...user } }\nfragment user on User { login }\nquery B { rateLimit { cost } }", want: true},
+		{name: "mention of mutation in a comment", document: "# mutation { deleteRef }\nquery { viewer { login } }", want: true},
+		{name: "braces in strings", document: `query { search(query: "} mutation {", type: REPOSITORY, first: 1) { repositoryCount } search2: search(query: """ } \""" mutation""", type: ISSUE, first: 1) { issueCount } }`, want: true},
+		{name: "mutation", document: `mutation { deleteRef(input: {refId: "x"}) { clientMutationId } }`},
+		{name: "mutation after a comment", document: "# Delete the branch\nmutation { deleteRef(input: {refId: \"x\"}) { clientMutationId } }"},
+		{name: "query and mutation", document: `query A { viewer { login } } mutation B { deleteRef(input: {refId: "x"}) { clientMutationId } }`},
+		{name: "subscription", document: `subscription { events { id } }`},
+		{name: "only fragments", document: `fragment user on User { login }`},
+		{name: "unbalanced", document: `query { viewer { login }`},
+		{name: "unterminated string", document: `query { search(query: "x) { issueCount } }`},
+		{name: "empty", document: "  # nothing here\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isQueryDocument(tt.document); got != tt.want {
+				t.Errorf("isQueryDocument() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraphQLRequestReadOnly(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"data":{"viewer":{"login":"octocat"}}}`))
+	}))
+	defer server.Close()
+
+	defer Configure(DefaultClientOptions())
+	Configure(ClientOptions{BaseURL: server.URL, Token: "test", Policy: PolicyOptions{ReadOnly: true}})
+
+	if _, err := GraphQLRequest("# Who am I\nquery { viewer { login } }", nil, nil); err != nil {
+		t.Errorf("GraphQLRequest() error = %v, want queries to be allowed", err)
+	}
+	for _, document := range []string{
+		"# Delete the branch\nmutation { deleteRef(input: {refId: \"x\"}) { clientMutationId } }",
+		`query A { viewer { login } } mutation B { deleteRef(input: {refId: "x"}) { clientMutationId } }`,
+	} {
+		if _, err := GraphQLRequest(document, nil, nil); err == nil || !strings.Contains(err.Error(), "read-only") {
+			t.Errorf("GraphQLRequest(%q) error = %v, want a read-only policy violation", document, err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("server received %d requests, want only the query", requests)
+	}
+}
+
+func TestCreateGraphQLError(t *testing.T) {
+	tests := []struct {
+		name   string
+		errs   []GraphQLError
+		status int
+	}{
+		{
+			name:   "not found",
+			errs:   []GraphQLError{{Type: "NOT_FOUND", Message: "Could not resolve to a Repository"}},
+			status: 404,
+		},
+		{
+			name:   "rate limited",
+			errs:   []GraphQLError{{Type: "RATE_LIMITED", Message: "API rate limit exceeded"}},
+			status: 429,
+		},
+		{
+			name:   "untyped error",
+			errs:   []GraphQLError{{Message: "Field 'foo' doesn't exist on type 'Query'"}},
+			status: 422,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CreateGraphQLError(tt.errs)
+			if !IsGitHubError(err) {
+				t.Fatalf("CreateGraphQLError() = %T, want a GitHub error", err)
+			}
+			if want := CreateGitHubError(tt.status, nil); fmt.Sprintf("%T", err) != fmt.Sprintf("%T", want) {
+				t.Errorf("CreateGraphQLError() = %T, want %T", err, want)
+			}
+		})
+	}
+}
+
+func TestGraphQLPaginate(t *testing.T) {
+	pages := map[string]string{
+		"":   `{"data":{"repository":{"issues":{"nodes":[{"number":1}],"pageInfo":{"hasNextPage":true,"endCursor":"c1"}}}}}`,
+		"c1": `{"data":{"repository":{"issues":{"nodes":[{"number":2}],"pageInfo":{"hasNextPage":false,"endCursor":"c2"}}}}}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		cursor, _ := body.Variables["cursor"].(string)
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		w.Write([]byte(pages[cursor]))
+	}))
+	defer server.Close()
+
+	defer Configure(DefaultClientOptions())
+	Configure(ClientOptions{BaseURL: server.URL, Token: "test"})
+
+	var numbers []int
+	err := GraphQLPaginate("query($owner: String!, $name: String!, $cursor: String) { ... }",
+		map[string]interface{}{"owner": "octo", "name": "repo"}, nil, 0,
+		func(resp *GraphQLResponse) (PageInfo, error) {
+			if resp.RateLimit == nil || resp.RateLimit.Remaining != 4999 {
+				t.Errorf("RateLimit = %+v, want it parsed from the headers", resp.RateLimit)
+			}
+			var data struct {
+				Repository struct {
+					Issues struct {
+						Nodes []struct {
+							Number int `json:"number"`
+						} `json:"nodes"`
+						PageInfo PageInfo `json:"pageInfo"`
+					} `json:"issues"`
+				} `json:"repository"`
+			}
+			if err := resp.Decode(&data); err != nil {
+				return PageInfo{}, err
+			}
+			for _, n := range data.Repository.Issues.Nodes {
+				numbers = append(numbers, n.Number)
+			}
+			return data.Repository.Issues.PageInfo, nil
+		})
+	if err != nil {
+		t.Fatalf("GraphQLPaginate() error = %v", err)
+	}
+	if len(numbers) != 2 || numbers[0] != 1 || numbers[1] != 2 {
+		t.Errorf("GraphQLPaginate() collected %v, want [1 2]", numbers)
+	}
+}
@@ -0,0 +1,304 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Response carries the status and headers of a GitHub API response
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	// FromCache is true when the body was served from the response cache
+	FromCache bool
+}
+
+var linkPattern = regexp.MustCompile(`<([^>]+)>;\s*rel="([^"]+)"`)
+
+// Links parses the Link header used by GitHub for pagination, keyed by rel
+func (r *Response) Links() map[string]string {
+	links := make(map[string]string)
+	if r == nil || r.Header == nil {
+		return links
+	}
+	for _, match := range linkPattern.FindAllStringSubmatch(r.Header.Get("Link"), -1) {
+		links[match[2]] = match[1]
+	}
+	return links
+}
+
+// NextPageURL returns the URL of the next page of results, or "" on the last page
+func (r *Response) NextPageURL() string {
+	return r.Links()["next"]
+}
+
+// RequestOption customises a single request
+type RequestOption func(*requestConfig)
+
+type requestConfig struct {
+	accept  string
+	headers map[string]string
+	write   *bool
+	noCache bool
+	maxSize int64
+	ctx     context.Context
+}
+
+// ErrResponseTooLarge is returned when a response body exceeds the size set with WithMaxSize
+var ErrResponseTooLarge = errors.New("response body is too large")
+
+// WithAccept overrides the Accept header, for example to request a raw or diff media type
+func WithAccept(mediaType string) RequestOption {
+	return func(c *requestConfig) {
+		c.accept = mediaType
+	}
+}
+
+// WithHeader sets an additional request header
+func WithHeader(key string, value string) RequestOption {
+	return func(c *requestConfig) {
+		c.headers[key] = value
+	}
+}
+
+// WithNoCache sends a GET request to GitHub even when a fresh response is cached, and
+// leaves the response out of the cache. Use it when polling for changes and for large downloads.
+func WithNoCache() RequestOption {
+	return func(c *requestConfig) {
+		c.noCache = true
+	}
+}
+
+// WithContext sends the request with ctx, so that it is abandoned when ctx is cancelled
+func WithContext(ctx context.Context) RequestOption {
+	return func(c *requestConfig) {
+		c.ctx = ctx
+	}
+}
+
+// WithMaxSize fails the request with ErrResponseTooLarge when the response body is over size
+// bytes, without reading more than that into memory
+func WithMaxSize(size int64) RequestOption {
+	return func(c *requestConfig) {
+		c.maxSize = size
+	}
+}
+
+// asWrite overrides whether the request counts as a write for policy and caching.
+// By default anything other than GET and HEAD is a write.
+func asWrite(write bool) RequestOption {
+	return func(c *requestConfig) {
+		c.write = &write
+	}
+}
+
+// rawResponse is an undecoded response as kept in the response cache
+type rawResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// TypedGitHubRequest sends a request to the GitHub API and decodes the JSON response
+// directly into T. If T is string or []byte the raw body is returned instead.
+func TypedGitHubRequest[T any](urlStr string, method string, body interface{}, apiReqs *APIRequirements, opts ...RequestOption) (T, *Response, error) {
+	var result T
+
+	raw, fromCache, err := sendRequest(urlStr, method, body, apiReqs, opts...)
+	if err != nil {
+		return result, nil, err
+	}
+
+	resp := &Response{
+		StatusCode: raw.status,
+		Header:     raw.header,
+		FromCache:  fromCache,
+	}
+	if err := decodeBody(raw, &result); err != nil {
+		return result, resp, fmt.Errorf("error decoding response from %s: %w", urlStr, err)
+	}
+	return result, resp, nil
+}
+
+// decodeBody decodes a response body into out, passing string and []byte through untouched
+func decodeBody(raw *rawResponse, out interface{}) error {
+	switch v := out.(type) {
+	case *[]byte:
+		*v = raw.body
+		return nil
+	case *string:
+		*v = string(raw.body)
+		return nil
+	}
+
+	if len(bytes.TrimSpace(raw.body)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw.body, out); err != nil {
+		var syntaxErr *json.SyntaxError
+		if contentType := raw.header.Get("Content-Type"); errors.As(err, &syntaxErr) && !strings.Contains(contentType, "json") {
+			return fmt.Errorf("unexpected non-JSON response with content type %q", contentType)
+		}
+		return err
+	}
+	return nil
+}
+
+// sendRequest applies policy, authentication, caching and retries, and returns the
+// undecoded response. Error statuses are converted to typed GitHub errors.
+func sendRequest(urlStr string, method string, body interface{}, apiReqs *APIRequirements, opts ...RequestOption) (*rawResponse, bool, error) {
+	config := &requestConfig{
+		accept:  "application/vnd.github.v3+json",
+		headers: make(map[string]string),
+		ctx:     context.Background(),
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	write := method != "GET" && method != "HEAD"
+	if config.write != nil {
+		write = *config.write
+	}
+
+	options := CurrentClientOptions()
+	if err := checkPolicy(options.Policy, write, urlStr); err != nil {
+		return nil, false, err
+	}
+
+	var bodyBytes []byte
+	if body != nil {
+		var err error
+		bodyBytes, err = json.Marshal(body)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	// Use token from provided APIRequirements if available, otherwise fall back to the configured token
+	var token string
+	if apiReqs != nil && apiReqs.Token != "" {
+		token = apiReqs.Token
+	} else if options.AuthMethod == AUTH_METHOD_HEADER {
+		return nil, false, fmt.Errorf("no Authorization header found on the request and auth method is %q", AUTH_METHOD_HEADER)
+	} else if options.Token != "" {
+		token = options.Token
+	} else {
+		token = os.Getenv(GITHUB_TOKEN_ENV_VAR)
+	}
+
+	key := cacheKey(config.accept+" "+urlStr, token)
+	var stale *rawResponse
+	if method == "GET" && !config.noCache {
+		cached, fresh := responseCache.lookup(key)
+		if fresh {
+			return cached, true, nil
+		}
+		stale = cached
+	}
+
+	client := &http.Client{Timeout: options.Timeout}
+
+	var raw *rawResponse
+	for attempt := 1; ; attempt++ {
+		var bodyReader io.Reader
+		if bodyBytes != nil {
+			bodyReader = bytes.NewReader(bodyBytes)
+		}
+
+		req, err := http.NewRequestWithContext(config.ctx, method, urlStr, bodyReader)
+		if err != nil {
+			return nil, false, err
+		}
+
+		req.Header.Set("Accept", config.accept)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", USER_AGENT)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		for k, v := range config.headers {
+			req.Header.Set(k, v)
+		}
+		// Revalidate an expired cache entry, a 304 does not count against the rate limit
+		if stale != nil {
+			req.Header.Set("If-None-Match", stale.header.Get("ETag"))
+		}
+
+		start := time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			// A write may have reached GitHub before the connection failed, so it is not sent again
+			if !write && attempt < options.Retry.MaxAttempts && sleep(config.ctx, options.Retry.backoff(attempt)) {
+				continue
+			}
+			return nil, false, err
+		}
+
+		var reader io.Reader = resp.Body
+		if config.maxSize > 0 {
+			reader = io.LimitReader(resp.Body, config.maxSize+1)
+		}
+		responseBody, err := io.ReadAll(reader)
+		resp.Body.Close()
+		if err != nil {
+			return nil, false, err
+		}
+		if config.maxSize > 0 && int64(len(responseBody)) > config.maxSize {
+			return nil, false, fmt.Errorf("%w: %s returned more than %d bytes", ErrResponseTooLarge, urlStr, config.maxSize)
+		}
+		logRequest(method, urlStr, resp.StatusCode, attempt, time.Since(start))
+
+		if attempt < options.Retry.MaxAttempts {
+			if wait, retry := options.Retry.retryWait(write, resp.StatusCode, resp.Header, attempt); retry && sleep(config.ctx, wait) {
+				continue
+			}
+		}
+
+		raw = &rawResponse{status: resp.StatusCode, header: resp.Header, body: responseBody}
+		break
+	}
+
+	if raw.status == http.StatusNotModified && stale != nil {
+		responseCache.set(key, stale)
+		return stale, true, nil
+	}
+
+	if raw.status >= 400 {
+		var errorBody interface{}
+		if len(raw.body) > 0 {
+			if err := json.Unmarshal(raw.body, &errorBody); err != nil {
+				errorBody = map[string]interface{}{"message": strings.TrimSpace(string(raw.body))}
+			}
+		}
+		return nil, false, createGitHubErrorFromResponse(raw.status, errorBody, raw.header)
+	}
+
+	if method == "GET" && !config.noCache {
+		responseCache.set(key, raw)
+	} else if write {
+		responseCache.invalidate()
+	}
+
+	return raw, false, nil
+}
+
+// sleep waits for d before a retry. It returns false without waiting out d when ctx is cancelled.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,311 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTypedGitHubRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octo/repo/branches/main":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Link", `<https://api.github.com/resource?page=2>; rel="next", <https://api.github.com/resource?page=5>; rel="last"`)
+			w.Write([]byte(`{"name":"main","commit":{"sha":"abc123"},"protected":true}`))
+		case "/raw":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("plain text body"))
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Not Found","documentation_url":"https://docs.github.com"}`))
+		}
+	}))
+	defer server.Close()
+
+	defer Configure(DefaultClientOptions())
+	Configure(ClientOptions{BaseURL: server.URL, Token: "test"})
+
+	branch, resp, err := TypedGitHubRequest[GitHubBranch](APIURL("/repos/octo/repo/branches/main"), "GET", nil, nil)
+	if err != nil {
+		t.Fatalf("TypedGitHubRequest() error = %v", err)
+	}
+	if branch.Name != "main" || branch.Commit.SHA != "abc123" || !branch.Protected {
+		t.Errorf("TypedGitHubRequest() decoded %+v", branch)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("StatusCode = %v, want 200", resp.StatusCode)
+	}
+	if got := resp.NextPageURL(); got != "https://api.github.com/resource?page=2" {
+		t.Errorf("NextPageURL() = %v", got)
+	}
+
+	text, _, err := TypedGitHubRequest[string](APIURL("/raw"), "GET", nil, nil)
+	if err != nil || text != "plain text body" {
+		t.Errorf("TypedGitHubRequest[string]() = %q, %v", text, err)
+	}
+
+	if _, _, err := TypedGitHubRequest[GitHubBranch](APIURL("/raw"), "GET", nil, nil); err == nil || !strings.Contains(err.Error(), "non-JSON") {
+		t.Errorf("decoding a non-JSON body into a struct should fail, got %v", err)
+	}
+
+	_, _, err = TypedGitHubRequest[GitHubBranch](APIURL("/missing"), "GET", nil, nil)
+	if !IsNotFound(err) {
+		t.Errorf("TypedGitHubRequest() error = %v, want a not found error", err)
+	}
+}
+
+func TestTypedGitHubRequestRevalidatesCache(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"login":"octocat"}`))
+	}))
+	defer server.Close()
+
+	defer Configure(DefaultClientOptions())
+	Configure(ClientOptions{
+		BaseURL: server.URL,
+		Token:   "test",
+		Cache:   CacheOptions{Enabled: true, TTL: time.Millisecond},
+	})
+
+	for i := 0; i < 2; i++ {
+		user, resp, err := TypedGitHubRequest[GitHubUser](APIURL("/users/octocat"), "GET", nil, nil)
+		if err != nil {
+			t.Fatalf("TypedGitHubRequest() error = %v", err)
+		}
+		if user.Login != "octocat" {
+			t.Errorf("Login = %q, want octocat", user.Login)
+		}
+		if i == 1 && !resp.FromCache {
+			t.Errorf("second response should be served from the revalidated cache")
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	if requests != 2 {
+		t.Errorf("server received %d requests, want 2", requests)
+	}
+}
+
+func TestTypedGitHubRequestWithNoCache(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprintf(w, `{"login":"octocat","id":%d}`, requests)
+	}))
+	defer server.Close()
+
+	defer Configure(DefaultClientOptions())
+	Configure(ClientOptions{
+		BaseURL: server.URL,
+		Token:   "test",
+		Cache:   CacheOptions{Enabled: true, TTL: time.Hour},
+	})
+
+	url := APIURL("/users/octocat")
+	if _, _, err := TypedGitHubRequest[GitHubUser](url, "GET", nil, nil); err != nil {
+		t.Fatalf("TypedGitHubRequest() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		user, resp, err := TypedGitHubRequest[GitHubUser](url, "GET", nil, nil, WithNoCache())
+		if err != nil {
+			t.Fatalf("TypedGitHubRequest() error = %v", err)
+		}
+		if resp.FromCache || user.ID != i+2 {
+			t.Errorf("request %d returned user %d (from cache %v), want a fresh response", i+2, user.ID, resp.FromCache)
+		}
+	}
+	// Responses fetched without the cache do not replace the cached one
+	user, resp, err := TypedGitHubRequest[GitHubUser](url, "GET", nil, nil)
+	if err != nil {
+		t.Fatalf("TypedGitHubRequest() error = %v", err)
+	}
+	if !resp.FromCache || user.ID != 1 {
+		t.Errorf("returned user %d (from cache %v), want the cached first response", user.ID, resp.FromCache)
+	}
+}
+
+func TestTypedGitHubRequestRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		status       int
+		header       map[string]string
+		wantRequests int
+	}{
+		{name: "read after a bad gateway", method: "GET", status: http.StatusBadGateway, wantRequests: 2},
+		{name: "write after a bad gateway", method: "POST", status: http.StatusBadGateway, wantRequests: 1},
+		{name: "write after a rate limit", method: "POST", status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "0"}, wantRequests: 2},
+		{name: "write after a secondary rate limit", method: "POST", status: http.StatusForbidden, header: map[string]string{"Retry-After": "0"}, wantRequests: 2},
+		{
+			name:         "rate limit that resets too late",
+			method:       "GET",
+			status:       http.StatusForbidden,
+			header:       map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": fmt.Sprint(time.Now().Add(time.Hour).Unix())},
+			wantRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests == 1 {
+					for k, v := range tt.header {
+						w.Header().Set(k, v)
+					}
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.Write([]byte(`{"number":1}`))
+			}))
+			defer server.Close()
+
+			defer Configure(DefaultClientOptions())
+			Configure(ClientOptions{
+				BaseURL: server.URL,
+				Token:   "test",
+				Retry:   RetryOptions{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+			})
+
+			_, _, err := TypedGitHubRequest[GitHubIssue](APIURL("/repos/octo/repo/issues"), tt.method, map[string]string{"title": "Bug"}, nil)
+			if requests != tt.wantRequests {
+				t.Errorf("server received %d requests, want %d", requests, tt.wantRequests)
+			}
+			if (err == nil) != (tt.wantRequests == 2) {
+				t.Errorf("TypedGitHubRequest() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestTypedGitHubRequestWithMaxSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer server.Close()
+
+	defer Configure(DefaultClientOptions())
+	Configure(ClientOptions{BaseURL: server.URL, Token: "test"})
+
+	if body, _, err := TypedGitHubRequest[[]byte](APIURL("/archive.zip"), "GET", nil, nil, WithMaxSize(100)); err != nil || len(body) != 100 {
+		t.Errorf("TypedGitHubRequest() = %d bytes, %v, want the whole body", len(body), err)
+	}
+	if _, _, err := TypedGitHubRequest[[]byte](APIURL("/archive.zip"), "GET", nil, nil, WithMaxSize(99)); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("TypedGitHubRequest() error = %v, want ErrResponseTooLarge", err)
+	}
+}
+
+// largeSearchResponse builds a code search response with 100 results
+func largeSearchResponse() []byte {
+	resp := GitHubSearchCodeResponse{TotalCount: 100}
+	for i := 0; i < 100; i++ {
+		resp.Items = append(resp.Items, CodeResult{
+			Name: fmt.Sprintf("file%d.go", i),
+			Path: fmt.Sprintf("pkg/sub/file%d.go", i),
+			SHA:  strings.Repeat("a", 40),
+			Repository: GitHubRepository{
+				ID:          i,
+				Name:        "repo",
+				FullName:    "octo/repo",
+				Description: strings.Repeat("description ", 20),
+				Owner:       GitHubUser{Login: "octo"},
+			},
+		})
+	}
+	data, _ := json.Marshal(resp)
+	return data
+}
+
+// largeTreeResponse builds a recursive tree response with 20000 entries
+func largeTreeResponse() []byte {
+	tree := GitHubTree{SHA: strings.Repeat("b", 40)}
+	for i := 0; i < 20000; i++ {
+		tree.Tree = append(tree.Tree, TreeEntry{
+			Path: fmt.Sprintf("src/module%d/file%d.go", i/100, i),
+			Mode: "100644",
+			Type: "blob",
+			SHA:  strings.Repeat("c", 40),
+			Size: i,
+		})
+	}
+	data, _ := json.Marshal(tree)
+	return data
+}
+
+func benchmarkServer(b *testing.B, body []byte) func() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	Configure(ClientOptions{BaseURL: server.URL, Token: "test"})
+	return func() {
+		server.Close()
+		Configure(DefaultClientOptions())
+	}
+}
+
+// roundTrip is how operations used to decode responses: generic decode, re-marshal, decode again
+func roundTrip(b *testing.B, out interface{}) {
+	resp, err := GitHubRequest(APIURL("/bench"), "GET", nil, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	jsonData, err := json.Marshal(resp)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := json.Unmarshal(jsonData, out); err != nil {
+		b.Fatal(err)
+	}
+}
+
+func BenchmarkSearchResponseRoundTrip(b *testing.B) {
+	defer benchmarkServer(b, largeSearchResponse())()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var result GitHubSearchCodeResponse
+		roundTrip(b, &result)
+	}
+}
+
+func BenchmarkSearchResponseTyped(b *testing.B) {
+	defer benchmarkServer(b, largeSearchResponse())()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := TypedGitHubRequest[GitHubSearchCodeResponse](APIURL("/bench"), "GET", nil, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTreeResponseRoundTrip(b *testing.B) {
+	defer benchmarkServer(b, largeTreeResponse())()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var result GitHubTree
+		roundTrip(b, &result)
+	}
+}
+
+func BenchmarkTreeResponseTyped(b *testing.B) {
+	defer benchmarkServer(b, largeTreeResponse())()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := TypedGitHubRequest[GitHubTree](APIURL("/bench"), "GET", nil, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
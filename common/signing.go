@@ -0,0 +1,185 @@
+package common
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// SIGNING_FORMAT_OPENPGP signs commits with an OpenPGP (GPG) key
+	SIGNING_FORMAT_OPENPGP = "openpgp"
+	// SIGNING_FORMAT_SSH signs commits with an SSH key, like git's gpg.format=ssh
+	SIGNING_FORMAT_SSH = "ssh"
+)
+
+// SigningOptions describes the key used to sign commits and the identity it belongs to
+type SigningOptions struct {
+	Format     string
+	Key        []byte
+	Passphrase string
+	// Name and Email are used as the committer of signed commits. GitHub only marks a
+	// commit as verified when the committer email is a verified email of the key's owner.
+	Name  string
+	Email string
+}
+
+// CommitSigner signs the canonical payload of commit objects
+type CommitSigner struct {
+	Name   string
+	Email  string
+	format string
+	sign   func(payload []byte) (string, error)
+}
+
+// Format returns the signature format, SIGNING_FORMAT_OPENPGP or SIGNING_FORMAT_SSH
+func (s *CommitSigner) Format() string {
+	return s.format
+}
+
+// Sign returns the armored signature of payload, as stored in the gpgsig header of a commit
+func (s *CommitSigner) Sign(payload []byte) (string, error) {
+	signature, err := s.sign(payload)
+	if err != nil {
+		return "", fmt.Errorf("error signing commit: %w", err)
+	}
+	return signature, nil
+}
+
+// NewCommitSigner parses and, if needed, decrypts the signing key
+func NewCommitSigner(options SigningOptions) (*CommitSigner, error) {
+	signer := &CommitSigner{
+		Name:   options.Name,
+		Email:  options.Email,
+		format: options.Format,
+	}
+
+	switch options.Format {
+	case SIGNING_FORMAT_OPENPGP:
+		entity, err := readOpenPGPKey(options.Key, options.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		signer.sign = func(payload []byte) (string, error) {
+			var signature bytes.Buffer
+			if err := openpgp.ArmoredDetachSign(&signature, entity, bytes.NewReader(payload), nil); err != nil {
+				return "", err
+			}
+			return signature.String(), nil
+		}
+	case SIGNING_FORMAT_SSH:
+		var (
+			sshSigner ssh.Signer
+			err       error
+		)
+		if options.Passphrase != "" {
+			sshSigner, err = ssh.ParsePrivateKeyWithPassphrase(options.Key, []byte(options.Passphrase))
+		} else {
+			sshSigner, err = ssh.ParsePrivateKey(options.Key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading SSH signing key: %w", err)
+		}
+		signer.sign = func(payload []byte) (string, error) {
+			return signSSH(sshSigner, payload)
+		}
+	default:
+		return nil, fmt.Errorf("signing format %q must be one of: %s, %s", options.Format, SIGNING_FORMAT_OPENPGP, SIGNING_FORMAT_SSH)
+	}
+	return signer, nil
+}
+
+// readOpenPGPKey reads the first private key of an armored or binary key ring and decrypts it
+func readOpenPGPKey(key []byte, passphrase string) (*openpgp.Entity, error) {
+	var (
+		entities openpgp.EntityList
+		err      error
+	)
+	if bytes.Contains(key, []byte("-----BEGIN PGP")) {
+		entities, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(key))
+	} else {
+		entities, err = openpgp.ReadKeyRing(bytes.NewReader(key))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading OpenPGP signing key: %w", err)
+	}
+
+	for _, entity := range entities {
+		if entity.PrivateKey == nil {
+			continue
+		}
+		if entity.PrivateKey.Encrypted {
+			if passphrase == "" {
+				return nil, fmt.Errorf("the OpenPGP signing key is encrypted and no passphrase is configured")
+			}
+			if err := entity.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+				return nil, fmt.Errorf("error decrypting OpenPGP signing key: %w", err)
+			}
+		}
+		for _, subkey := range entity.Subkeys {
+			if subkey.PrivateKey != nil && subkey.PrivateKey.Encrypted {
+				if err := subkey.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+					return nil, fmt.Errorf("error decrypting OpenPGP signing subkey: %w", err)
+				}
+			}
+		}
+		return entity, nil
+	}
+	return nil, fmt.Errorf("the OpenPGP key ring contains no private key")
+}
+
+// sshSignatureNamespace is the namespace git uses for SSH commit signatures
+const sshSignatureNamespace = "git"
+
+// signSSH creates an armored SSH signature in the format of ssh-keygen -Y sign, see
+// https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.sshsig
+func signSSH(signer ssh.Signer, payload []byte) (string, error) {
+	hash := sha512.Sum512(payload)
+	signedData := bytes.NewBufferString("SSHSIG")
+	for _, field := range [][]byte{[]byte(sshSignatureNamespace), nil, []byte("sha512"), hash[:]} {
+		writeSSHString(signedData, field)
+	}
+
+	var (
+		signature *ssh.Signature
+		err       error
+	)
+	// RSA keys must not sign with SHA-1, which ssh-rsa signatures use
+	if algorithmSigner, ok := signer.(ssh.AlgorithmSigner); ok && signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		signature, err = algorithmSigner.SignWithAlgorithm(rand.Reader, signedData.Bytes(), ssh.KeyAlgoRSASHA512)
+	} else {
+		signature, err = signer.Sign(rand.Reader, signedData.Bytes())
+	}
+	if err != nil {
+		return "", err
+	}
+
+	blob := bytes.NewBufferString("SSHSIG")
+	binary.Write(blob, binary.BigEndian, uint32(1))
+	for _, field := range [][]byte{signer.PublicKey().Marshal(), []byte(sshSignatureNamespace), nil, []byte("sha512"), ssh.Marshal(signature)} {
+		writeSSHString(blob, field)
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(blob.Bytes())
+	var armored strings.Builder
+	armored.WriteString("-----BEGIN SSH SIGNATURE-----\n")
+	for len(encoded) > 70 {
+		armored.WriteString(encoded[:70] + "\n")
+		encoded = encoded[70:]
+	}
+	armored.WriteString(encoded + "\n-----END SSH SIGNATURE-----\n")
+	return armored.String(), nil
+}
+
+// writeSSHString writes a length prefixed SSH wire format string
+func writeSSHString(buf *bytes.Buffer, data []byte) {
+	binary.Write(buf, binary.BigEndian, uint32(len(data)))
+	buf.Write(data)
+}
@@ -0,0 +1,130 @@
+package common
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestCommitSignerOpenPGP(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *packet.Config
+	}{
+		{name: "RSA"},
+		// The default key type of gpg since GnuPG 2.3
+		{name: "Ed25519", config: &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA, Curve: packet.Curve25519}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity, err := openpgp.NewEntity("Mona Lisa", "", "mona@example.com", tt.config)
+			if err != nil {
+				t.Fatalf("NewEntity() error = %v", err)
+			}
+			var key bytes.Buffer
+			w, err := armor.Encode(&key, openpgp.PrivateKeyType, nil)
+			if err != nil {
+				t.Fatalf("armor.Encode() error = %v", err)
+			}
+			if err := entity.SerializePrivate(w, nil); err != nil {
+				t.Fatalf("SerializePrivate() error = %v", err)
+			}
+			w.Close()
+
+			signer, err := NewCommitSigner(SigningOptions{Format: SIGNING_FORMAT_OPENPGP, Key: key.Bytes()})
+			if err != nil {
+				t.Fatalf("NewCommitSigner() error = %v", err)
+			}
+			payload := []byte("tree abc\nauthor Mona Lisa <mona@example.com> 1714557600 +0000\n\nSigned commit")
+			signature, err := signer.Sign(payload)
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+
+			if _, err := openpgp.CheckArmoredDetachedSignature(openpgp.EntityList{entity}, bytes.NewReader(payload), strings.NewReader(signature), nil); err != nil {
+				t.Errorf("signature does not verify: %v", err)
+			}
+		})
+	}
+}
+
+func TestCommitSignerSSH(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(privateKey, "", []byte("secret"))
+	if err != nil {
+		t.Fatalf("MarshalPrivateKeyWithPassphrase() error = %v", err)
+	}
+	key := pem.EncodeToMemory(block)
+
+	if _, err := NewCommitSigner(SigningOptions{Format: SIGNING_FORMAT_SSH, Key: key}); err == nil {
+		t.Errorf("NewCommitSigner() should fail for an encrypted key without a passphrase")
+	}
+	signer, err := NewCommitSigner(SigningOptions{Format: SIGNING_FORMAT_SSH, Key: key, Passphrase: "secret"})
+	if err != nil {
+		t.Fatalf("NewCommitSigner() error = %v", err)
+	}
+
+	payload := []byte("tree abc\n\nSigned commit")
+	armored, err := signer.Sign(payload)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if !strings.HasPrefix(armored, "-----BEGIN SSH SIGNATURE-----\n") || !strings.HasSuffix(armored, "-----END SSH SIGNATURE-----\n") {
+		t.Fatalf("Sign() = %q, want an armored SSH signature", armored)
+	}
+
+	// Decode the sshsig blob and check the signature against the public key
+	body := strings.TrimSuffix(strings.TrimPrefix(armored, "-----BEGIN SSH SIGNATURE-----\n"), "-----END SSH SIGNATURE-----\n")
+	blob, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(body, "\n", ""))
+	if err != nil {
+		t.Fatalf("signature is not base64: %v", err)
+	}
+	if string(blob[:6]) != "SSHSIG" || binary.BigEndian.Uint32(blob[6:10]) != 1 {
+		t.Fatalf("signature blob has no SSHSIG header")
+	}
+	var fields struct {
+		PublicKey     []byte
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Signature     []byte
+	}
+	if err := ssh.Unmarshal(blob[10:], &fields); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if fields.Namespace != "git" || fields.HashAlgorithm != "sha512" {
+		t.Errorf("namespace = %q, hash = %q, want git and sha512", fields.Namespace, fields.HashAlgorithm)
+	}
+	publicKey, err := ssh.ParsePublicKey(fields.PublicKey)
+	if err != nil {
+		t.Fatalf("ParsePublicKey() error = %v", err)
+	}
+	signature := new(ssh.Signature)
+	if err := ssh.Unmarshal(fields.Signature, signature); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	hash := sha512.Sum512(payload)
+	signedData := bytes.NewBufferString("SSHSIG")
+	for _, field := range [][]byte{[]byte("git"), nil, []byte("sha512"), hash[:]} {
+		writeSSHString(signedData, field)
+	}
+	if err := publicKey.Verify(signedData.Bytes(), signature); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+}
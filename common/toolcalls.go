@@ -0,0 +1,173 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/mcp-golang/transport"
+)
+
+type progressKey struct{}
+
+// progressReporter sends progress notifications for one tool call
+type progressReporter struct {
+	transport transport.Transport
+	token     json.RawMessage
+}
+
+// ToolCallTransport wraps the transport of the server to give tool handlers the progress token
+// and the cancellation of their call through the context. mcp-golang drops the params of every
+// notification it receives, so it never acts on notifications/cancelled by itself.
+type ToolCallTransport struct {
+	transport.Transport
+	progress bool
+
+	mu    sync.Mutex
+	calls map[transport.RequestId]context.CancelFunc
+}
+
+// NewToolCallTransport creates the wrapper before the transport it wraps, so that the input of
+// the transport can be passed through WatchCancellations. Progress notifications are only sent
+// when progress is true, as transports that answer each HTTP request with a single response
+// cannot carry them. Set the wrapped transport with Wrap.
+func NewToolCallTransport(progress bool) *ToolCallTransport {
+	return &ToolCallTransport{progress: progress, calls: make(map[transport.RequestId]context.CancelFunc)}
+}
+
+// Wrap sets the transport the messages are exchanged over
+func (t *ToolCallTransport) Wrap(inner transport.Transport) *ToolCallTransport {
+	t.Transport = inner
+	return t
+}
+
+// WatchCancellations returns a reader of the messages read from r that cancels the tool calls
+// named by the notifications/cancelled messages passing through
+func (t *ToolCallTransport) WatchCancellations(r io.Reader) io.Reader {
+	return &cancellationReader{reader: r, cancel: t.cancelCall}
+}
+
+// SetMessageHandler installs handler behind the progress token and cancellation of each tool call
+func (t *ToolCallTransport) SetMessageHandler(handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)) {
+	t.Transport.SetMessageHandler(func(ctx context.Context, message *transport.BaseJsonRpcMessage) {
+		if message.Type != transport.BaseMessageTypeJSONRPCRequestType || message.JsonRpcRequest.Method != "tools/call" {
+			handler(ctx, message)
+			return
+		}
+
+		// The call is cancelled when the client cancels it or the response is sent. mcp-golang
+		// runs the call after the handler returns, so the context must outlive this function.
+		ctx, cancel := context.WithCancel(ctx)
+		t.mu.Lock()
+		t.calls[message.JsonRpcRequest.Id] = cancel
+		t.mu.Unlock()
+		if c, ok := ctx.Value("ginContext").(*gin.Context); ok {
+			context.AfterFunc(c.Request.Context(), cancel)
+		}
+
+		var params struct {
+			Meta struct {
+				ProgressToken json.RawMessage `json:"progressToken"`
+			} `json:"_meta"`
+		}
+		if t.progress && json.Unmarshal(message.JsonRpcRequest.Params, &params) == nil && len(params.Meta.ProgressToken) > 0 {
+			ctx = context.WithValue(ctx, progressKey{}, &progressReporter{transport: t.Transport, token: params.Meta.ProgressToken})
+		}
+		handler(ctx, message)
+	})
+}
+
+// Send sends a message and ends the tool call it answers
+func (t *ToolCallTransport) Send(ctx context.Context, message *transport.BaseJsonRpcMessage) error {
+	switch {
+	case message.Type == transport.BaseMessageTypeJSONRPCResponseType:
+		defer t.cancelCall(message.JsonRpcResponse.Id)
+	case message.Type == transport.BaseMessageTypeJSONRPCErrorType:
+		defer t.cancelCall(message.JsonRpcError.Id)
+	}
+	return t.Transport.Send(ctx, message)
+}
+
+// cancelCall cancels the context of a tool call that is still running
+func (t *ToolCallTransport) cancelCall(id transport.RequestId) {
+	t.mu.Lock()
+	cancel, ok := t.calls[id]
+	delete(t.calls, id)
+	t.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// cancellationReader passes newline delimited JSON-RPC messages through unchanged and reports
+// the request ID of every notifications/cancelled message to cancel
+type cancellationReader struct {
+	reader io.Reader
+	cancel func(id transport.RequestId)
+	line   []byte
+}
+
+func (r *cancellationReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	data := p[:n]
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			r.line = append(r.line, data...)
+			break
+		}
+		r.line = append(r.line, data[:i]...)
+		r.checkLine()
+		data = data[i+1:]
+	}
+	return n, err
+}
+
+func (r *cancellationReader) checkLine() {
+	defer func() { r.line = r.line[:0] }()
+	if !bytes.Contains(r.line, []byte(`"notifications/cancelled"`)) {
+		return
+	}
+	var notification struct {
+		Method string `json:"method"`
+		Params struct {
+			RequestId *transport.RequestId `json:"requestId"`
+		} `json:"params"`
+	}
+	if json.Unmarshal(r.line, &notification) == nil && notification.Method == "notifications/cancelled" && notification.Params.RequestId != nil {
+		r.cancel(*notification.Params.RequestId)
+	}
+}
+
+// SendProgress sends a notifications/progress message for the tool call of ctx, if the client
+// passed a progress token. progress must increase from one call to the next, total is left out
+// when zero.
+func SendProgress(ctx context.Context, progress float64, total float64, message string) {
+	reporter, ok := ctx.Value(progressKey{}).(*progressReporter)
+	if !ok {
+		return
+	}
+
+	params := map[string]interface{}{
+		"progressToken": reporter.token,
+		"progress":      progress,
+	}
+	if total > 0 {
+		params["total"] = total
+	}
+	if message != "" {
+		params["message"] = message
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return
+	}
+	notification := &transport.BaseJSONRPCNotification{Jsonrpc: "2.0", Method: "notifications/progress", Params: data}
+	if err := reporter.transport.Send(ctx, transport.NewBaseMessageNotification(notification)); err != nil {
+		slog.Warn("error sending progress notification", "error", err)
+	}
+}
@@ -0,0 +1,159 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/mcp-golang/transport"
+)
+
+// recordingTransport keeps the message handler and every message sent
+type recordingTransport struct {
+	transport.Transport
+	handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)
+	sent    []*transport.BaseJsonRpcMessage
+}
+
+func (t *recordingTransport) SetMessageHandler(handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)) {
+	t.handler = handler
+}
+
+func (t *recordingTransport) Send(ctx context.Context, message *transport.BaseJsonRpcMessage) error {
+	t.sent = append(t.sent, message)
+	return nil
+}
+
+func toolCall(params string) *transport.BaseJsonRpcMessage {
+	return transport.NewBaseMessageRequest(&transport.BaseJSONRPCRequest{
+		Id:      1,
+		Jsonrpc: "2.0",
+		Method:  "tools/call",
+		Params:  json.RawMessage(params),
+	})
+}
+
+func TestToolCallTransportProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		progress bool
+		params   string
+		want     string
+	}{
+		{
+			name:     "progress token",
+			progress: true,
+			params:   `{"name":"wait_for_checks","arguments":{},"_meta":{"progressToken":"tok-1"}}`,
+			want:     `{"message":"1 of 2 checks completed","progress":5,"progressToken":"tok-1","total":600}`,
+		},
+		{
+			name:     "numeric progress token",
+			progress: true,
+			params:   `{"name":"wait_for_checks","arguments":{},"_meta":{"progressToken":7}}`,
+			want:     `{"message":"1 of 2 checks completed","progress":5,"progressToken":7,"total":600}`,
+		},
+		{name: "no progress token", progress: true, params: `{"name":"wait_for_checks","arguments":{}}`},
+		{name: "transport without notifications", params: `{"name":"wait_for_checks","arguments":{},"_meta":{"progressToken":"tok-1"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &recordingTransport{}
+			NewToolCallTransport(tt.progress).Wrap(inner).SetMessageHandler(func(ctx context.Context, message *transport.BaseJsonRpcMessage) {
+				SendProgress(ctx, 5, 600, "1 of 2 checks completed")
+			})
+			inner.handler(context.Background(), toolCall(tt.params))
+
+			if tt.want == "" {
+				if len(inner.sent) != 0 {
+					t.Errorf("sent %d messages, want none", len(inner.sent))
+				}
+				return
+			}
+			if len(inner.sent) != 1 {
+				t.Fatalf("sent %d messages, want one notification", len(inner.sent))
+			}
+			notification := inner.sent[0].JsonRpcNotification
+			if notification == nil || notification.Method != "notifications/progress" {
+				t.Fatalf("sent %+v, want a progress notification", inner.sent[0])
+			}
+			if string(notification.Params) != tt.want {
+				t.Errorf("params = %s, want %s", notification.Params, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolCallTransportCancelsHTTPCalls(t *testing.T) {
+	request, err := http.NewRequest("POST", "/mcp", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requestCtx, endRequest := context.WithCancel(context.Background())
+	ginContext := &gin.Context{Request: request.WithContext(requestCtx)}
+
+	inner := &recordingTransport{}
+	var callCtx context.Context
+	NewToolCallTransport(false).Wrap(inner).SetMessageHandler(func(ctx context.Context, message *transport.BaseJsonRpcMessage) {
+		callCtx = ctx
+	})
+	inner.handler(context.WithValue(context.Background(), "ginContext", ginContext), toolCall(`{"name":"wait_for_checks"}`))
+
+	if callCtx.Err() != nil {
+		t.Fatalf("call cancelled before the HTTP request ended: %v", callCtx.Err())
+	}
+	endRequest()
+	<-callCtx.Done()
+	if callCtx.Value("ginContext") != ginContext {
+		t.Errorf("call context lost the gin context")
+	}
+}
+
+func TestToolCallTransportCancellation(t *testing.T) {
+	inner := &recordingTransport{}
+	toolCalls := NewToolCallTransport(true).Wrap(inner)
+	calls := make(map[transport.RequestId]context.Context)
+	toolCalls.SetMessageHandler(func(ctx context.Context, message *transport.BaseJsonRpcMessage) {
+		calls[message.JsonRpcRequest.Id] = ctx
+	})
+	for _, id := range []transport.RequestId{1, 2, 3} {
+		message := toolCall(`{"name":"wait_for_checks"}`)
+		message.JsonRpcRequest.Id = id
+		inner.handler(context.Background(), message)
+	}
+
+	// The notification is split across reads, as it may be on a pipe
+	input := "{\"jsonrpc\":\"2.0\",\"id\":4,\"method\":\"ping\"}\n{\"jsonrpc\":\"2.0\",\"method\":\"notifications/cancelled\",\"params\":{\"requestId\":2,\"reason\":\"user\"}}\n"
+	reader := toolCalls.WatchCancellations(strings.NewReader(input))
+	var read bytes.Buffer
+	buf := make([]byte, 16)
+	for {
+		n, err := reader.Read(buf)
+		read.Write(buf[:n])
+		if err != nil {
+			break
+		}
+	}
+	if read.String() != input {
+		t.Errorf("read %q, want the input unchanged", read.String())
+	}
+
+	toolCalls.Send(context.Background(), transport.NewBaseMessageResponse(&transport.BaseJSONRPCResponse{Id: 3, Jsonrpc: "2.0"}))
+
+	if calls[1].Err() != nil {
+		t.Errorf("call 1 was cancelled, want it running")
+	}
+	if calls[2].Err() == nil {
+		t.Errorf("call 2 is running, want it cancelled by the notification")
+	}
+	if calls[3].Err() == nil {
+		t.Errorf("call 3 is running, want it ended by its response")
+	}
+	if len(inner.sent) != 1 {
+		t.Errorf("sent %d messages, want the response", len(inner.sent))
+	}
+}
@@ -145,6 +145,37 @@ type FileContent struct {
 	GitURL      string `json:"git_url"`
 	HTMLURL     string `json:"html_url"`
 	DownloadURL string `json:"download_url"`
+	// MimeType, LineCount and Range describe the decoded content, they are not part of the GitHub response
+	MimeType  string        `json:"mime_type,omitempty"`
+	LineCount int           `json:"line_count,omitempty"`
+	Range     *ContentRange `json:"range,omitempty"`
+}
+
+// ContentRange describes the part of a file returned when it is read in chunks
+type ContentRange struct {
+	Offset  int  `json:"offset"`
+	Length  int  `json:"length"`
+	HasMore bool `json:"has_more"`
+}
+
+// FileExcerpts holds selected, line-numbered parts of a file
+type FileExcerpts struct {
+	Path      string    `json:"path"`
+	SHA       string    `json:"sha"`
+	Size      int       `json:"size"`
+	LineCount int       `json:"line_count"`
+	Excerpts  []Excerpt `json:"excerpts"`
+	Matches   int       `json:"matches,omitempty"`
+	// Truncated is true when there were more matches than were returned
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Excerpt is a run of consecutive lines of a file. Each line of Content is prefixed
+// with its line number, followed by ":" for selected or matching lines and "-" for context lines.
+type Excerpt struct {
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Content   string `json:"content"`
 }
 
 // GitHubSearchResponse represents a search response from GitHub
@@ -294,15 +325,54 @@ type PRRef struct {
 
 // GitHubCommit represents a commit in a GitHub repository
 type GitHubCommit struct {
-	SHA         string      `json:"sha"`
-	NodeID      string      `json:"node_id"`
-	Commit      CommitData  `json:"commit"`
-	URL         string      `json:"url"`
-	HTMLURL     string      `json:"html_url"`
-	CommentsURL string      `json:"comments_url"`
-	Author      *GitHubUser `json:"author"`
-	Committer   *GitHubUser `json:"committer"`
-	Parents     []CommitRef `json:"parents"`
+	SHA         string       `json:"sha"`
+	NodeID      string       `json:"node_id"`
+	Commit      CommitData   `json:"commit"`
+	URL         string       `json:"url"`
+	HTMLURL     string       `json:"html_url"`
+	CommentsURL string       `json:"comments_url"`
+	Author      *GitHubUser  `json:"author"`
+	Committer   *GitHubUser  `json:"committer"`
+	Parents     []CommitRef  `json:"parents"`
+	Stats       *CommitStats `json:"stats,omitempty"`
+	Files       []CommitFile `json:"files,omitempty"`
+}
+
+// CommitStats represents the line changes of a commit
+type CommitStats struct {
+	Additions int `json:"additions"`
+	Deletions int `json:"deletions"`
+	Total     int `json:"total"`
+}
+
+// CommitDetails is a single commit with its changes, verification and pull requests
+type CommitDetails struct {
+	GitHubCommit
+	FilesTruncated bool                 `json:"files_truncated,omitempty"`
+	Warning        string               `json:"warning,omitempty"`
+	Verification   VerificationStatus   `json:"verification"`
+	PullRequests   []PullRequestSummary `json:"pull_requests"`
+}
+
+// VerificationStatus summarises the signature verification of a commit
+type VerificationStatus struct {
+	Verified bool   `json:"verified"`
+	Reason   string `json:"reason"`
+	// SignatureType is gpg, ssh or x509 for signed commits and empty otherwise
+	SignatureType string `json:"signature_type,omitempty"`
+}
+
+// PullRequestSummary identifies a pull request without its full details
+type PullRequestSummary struct {
+	Number   int        `json:"number"`
+	Title    string     `json:"title"`
+	State    string     `json:"state"`
+	Draft    bool       `json:"draft"`
+	HTMLURL  string     `json:"html_url"`
+	User     string     `json:"user"`
+	Base     string     `json:"base"`
+	Head     string     `json:"head"`
+	MergedAt *time.Time `json:"merged_at,omitempty"`
 }
 
 // CommitData represents commit data
@@ -331,6 +401,56 @@ type Verification struct {
 	Payload   string `json:"payload"`
 }
 
+// CommitComparison represents the comparison of two commits
+type CommitComparison struct {
+	URL             string         `json:"url"`
+	HTMLURL         string         `json:"html_url"`
+	Status          string         `json:"status"`
+	AheadBy         int            `json:"ahead_by"`
+	BehindBy        int            `json:"behind_by"`
+	TotalCommits    int            `json:"total_commits"`
+	BaseCommit      GitHubCommit   `json:"base_commit"`
+	MergeBaseCommit GitHubCommit   `json:"merge_base_commit"`
+	Commits         []GitHubCommit `json:"commits"`
+	Files           []CommitFile   `json:"files"`
+}
+
+// CommitFile represents a file changed by a commit or between two commits
+type CommitFile struct {
+	SHA              string `json:"sha"`
+	Filename         string `json:"filename"`
+	Status           string `json:"status"`
+	Additions        int    `json:"additions"`
+	Deletions        int    `json:"deletions"`
+	Changes          int    `json:"changes"`
+	BlobURL          string `json:"blob_url"`
+	RawURL           string `json:"raw_url"`
+	ContentsURL      string `json:"contents_url"`
+	Patch            string `json:"patch,omitempty"`
+	PatchTruncated   bool   `json:"patch_truncated,omitempty"`
+	PreviousFilename string `json:"previous_filename,omitempty"`
+}
+
+// RefComparison is the summary of the changes between two refs returned by compare_refs
+type RefComparison struct {
+	Base             string         `json:"base"`
+	Head             string         `json:"head"`
+	Status           string         `json:"status"`
+	AheadBy          int            `json:"ahead_by"`
+	BehindBy         int            `json:"behind_by"`
+	MergeBaseSHA     string         `json:"merge_base_sha"`
+	HTMLURL          string         `json:"html_url"`
+	TotalCommits     int            `json:"total_commits"`
+	Commits          []GitHubCommit `json:"commits"`
+	CommitsTruncated bool           `json:"commits_truncated,omitempty"`
+	ChangedFiles     int            `json:"changed_files"`
+	Additions        int            `json:"additions"`
+	Deletions        int            `json:"deletions"`
+	Files            []CommitFile   `json:"files"`
+	FilesTruncated   bool           `json:"files_truncated,omitempty"`
+	Warning          string         `json:"warning,omitempty"`
+}
+
 // GitHubSearchCodeResponse represents a code search response from GitHub
 type GitHubSearchCodeResponse struct {
 	TotalCount        int          `json:"total_count"`
@@ -377,3 +497,522 @@ type RefObject struct {
 	Type string `json:"type"`
 	URL  string `json:"url"`
 }
+
+// IssueComment represents a comment on an issue or pull request
+type IssueComment struct {
+	ID                int        `json:"id"`
+	NodeID            string     `json:"node_id"`
+	URL               string     `json:"url"`
+	HTMLURL           string     `json:"html_url"`
+	IssueURL          string     `json:"issue_url"`
+	Body              string     `json:"body"`
+	User              GitHubUser `json:"user"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	AuthorAssociation string     `json:"author_association"`
+}
+
+// GitHubTree represents a Git tree object
+type GitHubTree struct {
+	SHA       string      `json:"sha"`
+	URL       string      `json:"url"`
+	Tree      []TreeEntry `json:"tree"`
+	Truncated bool        `json:"truncated"`
+}
+
+// TreeEntry represents a single entry of a Git tree
+type TreeEntry struct {
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+	Type string `json:"type"`
+	SHA  string `json:"sha"`
+	Size int    `json:"size,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+// RepositoryTree is a filtered recursive listing of a repository tree
+type RepositoryTree struct {
+	SHA          string      `json:"sha"`
+	Truncated    bool        `json:"truncated"`
+	Warning      string      `json:"warning,omitempty"`
+	TotalEntries int         `json:"total_entries"`
+	Entries      []TreeEntry `json:"entries,omitempty"`
+	// Text is the indented rendering of the entries when the text format is requested
+	Text string `json:"text,omitempty"`
+}
+
+// GitCommit represents a commit object from the Git database API
+type GitCommit struct {
+	SHA          string       `json:"sha"`
+	NodeID       string       `json:"node_id"`
+	URL          string       `json:"url"`
+	HTMLURL      string       `json:"html_url"`
+	Author       CommitAuthor `json:"author"`
+	Committer    CommitAuthor `json:"committer"`
+	Message      string       `json:"message"`
+	Tree         CommitRef    `json:"tree"`
+	Parents      []CommitRef  `json:"parents"`
+	Verification Verification `json:"verification"`
+}
+
+// FileCommitResponse is returned when a file is created, updated or deleted through the contents API
+type FileCommitResponse struct {
+	Content *FileContent `json:"content"`
+	Commit  GitCommit    `json:"commit"`
+}
+
+// GitBlob represents a Git blob object
+type GitBlob struct {
+	SHA      string `json:"sha"`
+	NodeID   string `json:"node_id"`
+	URL      string `json:"url"`
+	Size     int    `json:"size"`
+	Content  string `json:"content,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// EditFileResult is the commit created by an edit and what changed in each file
+type EditFileResult struct {
+	Commit GitCommit    `json:"commit"`
+	Files  []EditedFile `json:"files"`
+}
+
+// EditedFile describes the changes made to one file
+type EditedFile struct {
+	Path         string `json:"path"`
+	PreviousSHA  string `json:"previous_sha"`
+	Replacements int    `json:"replacements"`
+}
+
+// Status of a file changed by a patch
+const (
+	PATCH_STATUS_ADDED    = "added"
+	PATCH_STATUS_MODIFIED = "modified"
+	PATCH_STATUS_DELETED  = "deleted"
+	PATCH_STATUS_RENAMED  = "renamed"
+)
+
+// ApplyPatchResult is the commit created from a patch and the files it changed
+type ApplyPatchResult struct {
+	Commit GitCommit     `json:"commit"`
+	Files  []PatchedFile `json:"files"`
+}
+
+// PatchedFile describes how a patch changed one file
+type PatchedFile struct {
+	Path         string `json:"path"`
+	PreviousPath string `json:"previous_path,omitempty"`
+	Status       string `json:"status"`
+	Hunks        int    `json:"hunks"`
+	// Notes lists hunks that only applied at an offset or with fuzz
+	Notes []string `json:"notes,omitempty"`
+}
+
+// AppliedCommitResult is the commit created by cherry-picking or reverting another commit
+type AppliedCommitResult struct {
+	Commit GitCommit `json:"commit"`
+	// SourceSHA is the commit that was cherry-picked or reverted
+	SourceSHA string        `json:"source_sha"`
+	Files     []AppliedFile `json:"files"`
+	// Unchanged lists files that already had the resulting content on the branch
+	Unchanged []string `json:"unchanged,omitempty"`
+}
+
+// AppliedFile describes how a cherry-pick or revert changed one file
+type AppliedFile struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+}
+
+// SquashBranchResult is the single commit that replaced the commits of a branch
+type SquashBranchResult struct {
+	Commit GitCommit `json:"commit"`
+	// PreviousHeadSHA is the commit the branch pointed to before it was rewritten
+	PreviousHeadSHA string `json:"previous_head_sha"`
+	MergeBaseSHA    string `json:"merge_base_sha"`
+	SquashedCommits int    `json:"squashed_commits"`
+}
+
+// RejectedHunk is a hunk of a patch that does not apply. Hunk is 0 when the whole file was rejected.
+type RejectedHunk struct {
+	Path   string `json:"path"`
+	Hunk   int    `json:"hunk,omitempty"`
+	Header string `json:"header,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// Overall state of the statuses and check runs of a commit
+const (
+	CHECK_STATE_SUCCESS = "success"
+	CHECK_STATE_FAILURE = "failure"
+	CHECK_STATE_PENDING = "pending"
+	// CHECK_STATE_NONE means no status or check run was reported for the commit
+	CHECK_STATE_NONE = "none"
+)
+
+// CombinedStatus is the combined result of the commit statuses of a ref
+type CombinedStatus struct {
+	State      string         `json:"state"`
+	SHA        string         `json:"sha"`
+	TotalCount int            `json:"total_count"`
+	Statuses   []CommitStatus `json:"statuses"`
+}
+
+// CommitStatus is a status reported through the commit statuses API
+type CommitStatus struct {
+	State       string    `json:"state"`
+	Context     string    `json:"context"`
+	Description string    `json:"description"`
+	TargetURL   string    `json:"target_url"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CheckRunList is a page of check runs
+type CheckRunList struct {
+	TotalCount int        `json:"total_count"`
+	CheckRuns  []CheckRun `json:"check_runs"`
+}
+
+// CheckRun represents a GitHub check run
+type CheckRun struct {
+	ID          int64          `json:"id"`
+	Name        string         `json:"name"`
+	HeadSHA     string         `json:"head_sha"`
+	Status      string         `json:"status"`
+	Conclusion  string         `json:"conclusion"`
+	StartedAt   *time.Time     `json:"started_at"`
+	CompletedAt *time.Time     `json:"completed_at"`
+	DetailsURL  string         `json:"details_url"`
+	HTMLURL     string         `json:"html_url"`
+	ExternalID  string         `json:"external_id,omitempty"`
+	Output      CheckRunOutput `json:"output"`
+	CheckSuite  *CheckSuiteRef `json:"check_suite,omitempty"`
+	App         *CheckApp      `json:"app,omitempty"`
+	// Annotations is only filled in on request, see Output.AnnotationsCount for their number
+	Annotations []CheckAnnotation `json:"annotations,omitempty"`
+}
+
+// CheckRunOutput is the title, summary and details reported by a check run
+type CheckRunOutput struct {
+	Title            string `json:"title"`
+	Summary          string `json:"summary"`
+	Text             string `json:"text,omitempty"`
+	AnnotationsCount int    `json:"annotations_count"`
+}
+
+// CheckAnnotation is a message attached to lines of a file by a check run
+type CheckAnnotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	StartColumn     *int   `json:"start_column,omitempty"`
+	EndColumn       *int   `json:"end_column,omitempty"`
+	AnnotationLevel string `json:"annotation_level"`
+	Title           string `json:"title,omitempty"`
+	Message         string `json:"message"`
+	RawDetails      string `json:"raw_details,omitempty"`
+	BlobHref        string `json:"blob_href,omitempty"`
+}
+
+// CheckSuiteList is a page of check suites
+type CheckSuiteList struct {
+	TotalCount  int          `json:"total_count"`
+	CheckSuites []CheckSuite `json:"check_suites"`
+}
+
+// CheckSuite groups the check runs created by one app for a commit
+type CheckSuite struct {
+	ID                   int64     `json:"id"`
+	HeadBranch           string    `json:"head_branch"`
+	HeadSHA              string    `json:"head_sha"`
+	Status               string    `json:"status"`
+	Conclusion           string    `json:"conclusion"`
+	App                  *CheckApp `json:"app,omitempty"`
+	LatestCheckRunsCount int       `json:"latest_check_runs_count"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// CheckSuiteRef identifies the check suite of a check run
+type CheckSuiteRef struct {
+	ID int64 `json:"id"`
+}
+
+// CheckApp is the GitHub App that reported a check
+type CheckApp struct {
+	ID   int64  `json:"id"`
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+// RefStatus is everything CI reported for a commit
+type RefStatus struct {
+	Ref string `json:"ref"`
+	SHA string `json:"sha"`
+	// State combines the commit statuses and check runs, see CHECK_STATE_*
+	State       string         `json:"state"`
+	Statuses    []CommitStatus `json:"statuses"`
+	CheckRuns   []CheckRun     `json:"check_runs"`
+	CheckSuites []CheckSuite   `json:"check_suites"`
+}
+
+// CheckWaitResult is the outcome of waiting for the checks of a commit
+type CheckWaitResult struct {
+	SHA string `json:"sha"`
+	// State is success, failure, pending or none, see CHECK_STATE_*
+	State    string         `json:"state"`
+	TimedOut bool           `json:"timed_out"`
+	Elapsed  string         `json:"elapsed"`
+	Polls    int            `json:"polls"`
+	Checks   []CheckSummary `json:"checks"`
+	// Missing lists required checks that were never reported
+	Missing []string `json:"missing,omitempty"`
+}
+
+// CheckSummary is the result of one check run or commit status
+type CheckSummary struct {
+	Name string `json:"name"`
+	// Kind is check_run or status
+	Kind       string `json:"kind"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion,omitempty"`
+	DetailsURL string `json:"details_url,omitempty"`
+	Duration   string `json:"duration,omitempty"`
+	// Excerpt is the end of the output of a failed check
+	Excerpt     string   `json:"excerpt,omitempty"`
+	Annotations []string `json:"annotations,omitempty"`
+}
+
+// CheckWaitProgress is reported after each poll while waiting for checks
+type CheckWaitProgress struct {
+	SHA       string
+	Poll      int
+	Elapsed   time.Duration
+	State     string
+	Completed int
+	Total     int
+}
+
+// WorkflowList is a page of GitHub Actions workflows
+type WorkflowList struct {
+	TotalCount int        `json:"total_count"`
+	Workflows  []Workflow `json:"workflows"`
+}
+
+// Workflow is a GitHub Actions workflow file
+type Workflow struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	State     string    `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	HTMLURL   string    `json:"html_url"`
+	BadgeURL  string    `json:"badge_url"`
+}
+
+// WorkflowRunList is a page of workflow runs
+type WorkflowRunList struct {
+	TotalCount   int           `json:"total_count"`
+	WorkflowRuns []WorkflowRun `json:"workflow_runs"`
+}
+
+// WorkflowRun is a single run of a workflow
+type WorkflowRun struct {
+	ID           int64       `json:"id"`
+	Name         string      `json:"name"`
+	DisplayTitle string      `json:"display_title"`
+	RunNumber    int         `json:"run_number"`
+	RunAttempt   int         `json:"run_attempt"`
+	Event        string      `json:"event"`
+	Status       string      `json:"status"`
+	Conclusion   string      `json:"conclusion"`
+	WorkflowID   int64       `json:"workflow_id"`
+	Path         string      `json:"path"`
+	HeadBranch   string      `json:"head_branch"`
+	HeadSHA      string      `json:"head_sha"`
+	Actor        *GitHubUser `json:"actor"`
+	HTMLURL      string      `json:"html_url"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+	RunStartedAt *time.Time  `json:"run_started_at"`
+}
+
+// WorkflowJobList is a page of the jobs of a workflow run
+type WorkflowJobList struct {
+	TotalCount int           `json:"total_count"`
+	Jobs       []WorkflowJob `json:"jobs"`
+}
+
+// WorkflowJob is a job of a workflow run
+type WorkflowJob struct {
+	ID          int64          `json:"id"`
+	RunID       int64          `json:"run_id"`
+	RunAttempt  int            `json:"run_attempt"`
+	Name        string         `json:"name"`
+	Status      string         `json:"status"`
+	Conclusion  string         `json:"conclusion"`
+	StartedAt   *time.Time     `json:"started_at"`
+	CompletedAt *time.Time     `json:"completed_at"`
+	HTMLURL     string         `json:"html_url"`
+	RunnerName  string         `json:"runner_name"`
+	Labels      []string       `json:"labels"`
+	Steps       []WorkflowStep `json:"steps"`
+}
+
+// WorkflowStep is a step of a workflow job
+type WorkflowStep struct {
+	Number      int        `json:"number"`
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	Conclusion  string     `json:"conclusion"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+// WorkflowRunDetails is a workflow run with its jobs and their steps
+type WorkflowRunDetails struct {
+	WorkflowRun
+	Jobs []WorkflowJob `json:"jobs"`
+}
+
+// WorkflowDispatchResult describes a triggered workflow_dispatch event
+type WorkflowDispatchResult struct {
+	Workflow string            `json:"workflow"`
+	Ref      string            `json:"ref"`
+	Inputs   map[string]string `json:"inputs"`
+	// Defaults lists the declared inputs that were omitted and take their default value
+	Defaults []string `json:"defaults,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// JobLog is the distilled log of a workflow job
+type JobLog struct {
+	JobID      int64  `json:"job_id"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	HTMLURL    string `json:"html_url"`
+	// LineCount is the number of lines of the cleaned log, which the line numbers refer to
+	LineCount int          `json:"line_count"`
+	Steps     []JobLogStep `json:"steps"`
+	// Step is the step the excerpts are taken from, the failing step unless one was requested
+	Step     *JobLogStep `json:"step,omitempty"`
+	Excerpts []Excerpt   `json:"excerpts,omitempty"`
+	// Log is the whole cleaned log when it was requested
+	Log    string        `json:"log,omitempty"`
+	Errors []JobLogError `json:"errors"`
+	// ErrorsTruncated is true when the job has more error lines than are listed
+	ErrorsTruncated bool `json:"errors_truncated,omitempty"`
+	// LogTruncated is true when excerpts were dropped or the whole log was cut to the size limit
+	LogTruncated bool `json:"log_truncated,omitempty"`
+}
+
+// JobLogStep is a step of a job and the lines of the log it wrote
+type JobLogStep struct {
+	Number     int    `json:"number"`
+	Name       string `json:"name"`
+	Conclusion string `json:"conclusion"`
+	StartLine  int    `json:"start_line,omitempty"`
+	EndLine    int    `json:"end_line,omitempty"`
+}
+
+// JobLogError is a line of a job log that looks like an error
+type JobLogError struct {
+	Line int    `json:"line"`
+	Step int    `json:"step,omitempty"`
+	Text string `json:"text"`
+}
+
+// ArtifactList is a page of the artifacts of a workflow run
+type ArtifactList struct {
+	TotalCount int        `json:"total_count"`
+	Artifacts  []Artifact `json:"artifacts"`
+}
+
+// Artifact is a file archive uploaded by a workflow run
+type Artifact struct {
+	ID          int64                `json:"id"`
+	Name        string               `json:"name"`
+	SizeInBytes int64                `json:"size_in_bytes"`
+	Expired     bool                 `json:"expired"`
+	Digest      string               `json:"digest,omitempty"`
+	CreatedAt   *time.Time           `json:"created_at"`
+	ExpiresAt   *time.Time           `json:"expires_at"`
+	WorkflowRun *ArtifactWorkflowRun `json:"workflow_run,omitempty"`
+}
+
+// ArtifactWorkflowRun is the workflow run an artifact was uploaded by
+type ArtifactWorkflowRun struct {
+	ID         int64  `json:"id"`
+	HeadBranch string `json:"head_branch"`
+	HeadSHA    string `json:"head_sha"`
+}
+
+// ArtifactContents is the extracted content of an artifact
+type ArtifactContents struct {
+	Artifact Artifact `json:"artifact"`
+	// Files lists every file of the archive
+	Files    []ArtifactFile        `json:"files"`
+	Contents []ArtifactFileContent `json:"contents"`
+	// TestResults summarizes the JUnit XML reports found in the archive
+	TestResults *TestReport `json:"test_results,omitempty"`
+	// Truncated is true when files were left out or cut to the size limits
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// ArtifactFile is a file in an artifact archive
+type ArtifactFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// ArtifactFileContent is the text of a file in an artifact archive
+type ArtifactFileContent struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+	// Truncated is true when only the start of the file is returned
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// TestReport is the combined pass/fail summary of JUnit XML reports
+type TestReport struct {
+	Files    []string      `json:"files"`
+	Tests    int           `json:"tests"`
+	Passed   int           `json:"passed"`
+	Failed   int           `json:"failed"`
+	Errors   int           `json:"errors"`
+	Skipped  int           `json:"skipped"`
+	Suites   []TestSuite   `json:"suites"`
+	Failures []TestFailure `json:"failures"`
+	// Truncated is true when there were more failures than are listed
+	Truncated bool `json:"truncated,omitempty"`
+	// UnparsedFiles are XML files that were not read for JUnit results, as the parsing budget was spent
+	UnparsedFiles []string `json:"unparsed_files,omitempty"`
+}
+
+// TestSuite is the result of a JUnit test suite
+type TestSuite struct {
+	Name    string  `json:"name"`
+	File    string  `json:"file"`
+	Tests   int     `json:"tests"`
+	Failed  int     `json:"failed"`
+	Errors  int     `json:"errors"`
+	Skipped int     `json:"skipped"`
+	Time    float64 `json:"time,omitempty"`
+}
+
+// TestFailure is a failed test case of a JUnit report
+type TestFailure struct {
+	Suite     string `json:"suite"`
+	ClassName string `json:"classname,omitempty"`
+	Name      string `json:"name"`
+	File      string `json:"file,omitempty"`
+	// Kind is failure for a failed assertion and error for an unexpected error
+	Kind    string `json:"kind"`
+	Type    string `json:"type,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Details is the end of the failure output
+	Details string `json:"details,omitempty"`
+}
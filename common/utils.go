@@ -1,14 +1,11 @@
 package common
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
-	"os"
 	"regexp"
 	"strings"
 
@@ -29,6 +26,29 @@ type APIRequirements struct {
 	Token string
 }
 
+// InvalidInputError marks an error caused by invalid tool arguments, as reported by the
+// Validate method of an options struct
+type InvalidInputError struct {
+	Err error
+}
+
+func (e *InvalidInputError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap exposes the validation error to errors.As
+func (e *InvalidInputError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidInput marks err as caused by invalid tool arguments. It returns nil for a nil error.
+func InvalidInput(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &InvalidInputError{Err: err}
+}
+
 // GetGitHubAPIRequirementsFromContext extracts GitHub authentication information from the request context
 func GetGitHubAPIRequirementsFromContext(ctx context.Context) *APIRequirements {
 	// Try to get the gin context from the context
@@ -99,62 +119,11 @@ func BuildURL(baseURL string, params map[string]string) (string, error) {
 	return u.String(), nil
 }
 
-// GitHubRequest sends an HTTP request to the GitHub API
+// GitHubRequest sends an HTTP request to the GitHub API and returns the decoded JSON as
+// generic maps and slices. Prefer TypedGitHubRequest, which decodes straight into a struct.
 func GitHubRequest(urlStr string, method string, body interface{}, apiReqs *APIRequirements) (interface{}, error) {
-	var bodyReader io.Reader
-	if body != nil {
-		bodyBytes, err := json.Marshal(body)
-		if err != nil {
-			return nil, err
-		}
-		bodyReader = bytes.NewReader(bodyBytes)
-	}
-
-	req, err := http.NewRequest(method, urlStr, bodyReader)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", USER_AGENT)
-
-	// Use token from provided APIRequirements if available, otherwise fall back to environment variable
-	var token string
-	if apiReqs != nil && apiReqs.Token != "" {
-		token = apiReqs.Token
-	} else {
-		token = os.Getenv(GITHUB_TOKEN_ENV_VAR)
-	}
-
-	if token != "" {
-		req.Header.Set("Authorization", "Bearer "+token)
-	}
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	responseBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var result interface{}
-	if len(responseBody) > 0 {
-		if err := json.Unmarshal(responseBody, &result); err != nil {
-			return string(responseBody), nil
-		}
-	}
-
-	if resp.StatusCode >= 400 {
-		return nil, CreateGitHubError(resp.StatusCode, result)
-	}
-
-	return result, nil
+	result, _, err := TypedGitHubRequest[interface{}](urlStr, method, body, apiReqs)
+	return result, err
 }
 
 // ValidateBranchName validates a branch name according to Git rules
@@ -223,10 +192,10 @@ func ValidateOwnerName(owner string) (string, error) {
 
 // CheckBranchExists checks if a branch exists in a repository
 func CheckBranchExists(owner, repo, branch string) (bool, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/branches/%s", owner, repo, branch)
-	_, err := GitHubRequest(url, "GET", nil, nil)
+	url := APIURL("/repos/%s/%s/branches/%s", owner, repo, branch)
+	_, _, err := TypedGitHubRequest[json.RawMessage](url, "GET", nil, nil)
 	if err != nil {
-		if _, ok := err.(*GitHubResourceNotFoundError); ok {
+		if IsNotFound(err) {
 			return false, nil
 		}
 		return false, err
@@ -236,10 +205,10 @@ func CheckBranchExists(owner, repo, branch string) (bool, error) {
 
 // CheckUserExists checks if a GitHub user exists
 func CheckUserExists(username string) (bool, error) {
-	url := fmt.Sprintf("https://api.github.com/users/%s", username)
-	_, err := GitHubRequest(url, "GET", nil, nil)
+	url := APIURL("/users/%s", username)
+	_, _, err := TypedGitHubRequest[json.RawMessage](url, "GET", nil, nil)
 	if err != nil {
-		if _, ok := err.(*GitHubResourceNotFoundError); ok {
+		if IsNotFound(err) {
 			return false, nil
 		}
 		return false, err
@@ -0,0 +1,539 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metoro-io/github-mcp-server-go/common"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// APP_NAME is the directory name used under the XDG config directory
+	APP_NAME = "github-mcp-server"
+	// CONFIG_PATH_ENV_VAR is the environment variable that can point at a config file
+	CONFIG_PATH_ENV_VAR = "GITHUB_MCP_CONFIG"
+	// MASKED_SECRET replaces secrets when the config is dumped
+	MASKED_SECRET = "********"
+)
+
+// Config is the effective server configuration
+type Config struct {
+	API       APIConfig       `yaml:"api" toml:"api"`
+	Auth      AuthConfig      `yaml:"auth" toml:"auth"`
+	Transport TransportConfig `yaml:"transport" toml:"transport"`
+	Toolsets  []string        `yaml:"toolsets" toml:"toolsets"`
+	Policies  PoliciesConfig  `yaml:"policies" toml:"policies"`
+	Cache     CacheConfig     `yaml:"cache" toml:"cache"`
+	Retry     RetryConfig     `yaml:"retry" toml:"retry"`
+	Logging   LoggingConfig   `yaml:"logging" toml:"logging"`
+	Signing   SigningConfig   `yaml:"signing" toml:"signing"`
+}
+
+// APIConfig configures the GitHub API endpoint
+type APIConfig struct {
+	BaseURL string   `yaml:"base_url" toml:"base_url"`
+	Timeout Duration `yaml:"timeout" toml:"timeout"`
+}
+
+// AuthConfig configures how requests are authenticated
+type AuthConfig struct {
+	// Method is either "token" or "header"
+	Method string `yaml:"method" toml:"method"`
+	Token  string `yaml:"token" toml:"token"`
+}
+
+// TransportConfig configures how MCP clients connect to the server
+type TransportConfig struct {
+	// Type is either "stdio" or "http"
+	Type    string `yaml:"type" toml:"type"`
+	Address string `yaml:"address" toml:"address"`
+	Path    string `yaml:"path" toml:"path"`
+}
+
+// PoliciesConfig restricts what the server may do
+type PoliciesConfig struct {
+	ReadOnly            bool     `yaml:"read_only" toml:"read_only"`
+	AllowedRepositories []string `yaml:"allowed_repositories" toml:"allowed_repositories"`
+}
+
+// CacheConfig configures the in-memory response cache
+type CacheConfig struct {
+	Enabled    bool     `yaml:"enabled" toml:"enabled"`
+	TTL        Duration `yaml:"ttl" toml:"ttl"`
+	MaxEntries int      `yaml:"max_entries" toml:"max_entries"`
+}
+
+// RetryConfig configures retries of failed API requests
+type RetryConfig struct {
+	MaxAttempts    int      `yaml:"max_attempts" toml:"max_attempts"`
+	InitialBackoff Duration `yaml:"initial_backoff" toml:"initial_backoff"`
+	MaxBackoff     Duration `yaml:"max_backoff" toml:"max_backoff"`
+}
+
+// LoggingConfig configures the server logs, which are always written to stderr
+type LoggingConfig struct {
+	// Level is one of debug, info, warn, error
+	Level string `yaml:"level" toml:"level"`
+	// Format is either "text" or "json"
+	Format string `yaml:"format" toml:"format"`
+}
+
+// SigningConfig configures the key used to sign the commits the server creates
+type SigningConfig struct {
+	// Format is "openpgp" or "ssh". Commits are not signed when it is empty
+	Format     string `yaml:"format" toml:"format"`
+	KeyFile    string `yaml:"key_file" toml:"key_file"`
+	Passphrase string `yaml:"passphrase" toml:"passphrase"`
+	// Name and Email are the committer of signed commits, Email must be verified on the key owner's account
+	Name  string `yaml:"name" toml:"name"`
+	Email string `yaml:"email" toml:"email"`
+}
+
+// Duration is a time.Duration that reads and writes as a string such as "30s"
+type Duration time.Duration
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", string(text), err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Default returns the configuration used when nothing is overridden
+func Default() *Config {
+	return &Config{
+		API: APIConfig{
+			BaseURL: "https://api.github.com",
+			Timeout: Duration(30 * time.Second),
+		},
+		Auth: AuthConfig{
+			Method: "token",
+		},
+		Transport: TransportConfig{
+			Type:    "stdio",
+			Address: ":8080",
+			Path:    "/mcp",
+		},
+		Toolsets: []string{"all"},
+		Cache: CacheConfig{
+			Enabled:    false,
+			TTL:        Duration(time.Minute),
+			MaxEntries: 1000,
+		},
+		Retry: RetryConfig{
+			MaxAttempts:    3,
+			InitialBackoff: Duration(500 * time.Millisecond),
+			MaxBackoff:     Duration(10 * time.Second),
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "text",
+		},
+	}
+}
+
+// CommandLine holds the parsed command line flags
+type CommandLine struct {
+	ConfigPath  string
+	PrintConfig bool
+
+	// set records the flags explicitly passed so that only those override the config
+	set    map[string]bool
+	values commandLineValues
+}
+
+type commandLineValues struct {
+	apiURL    string
+	transport string
+	address   string
+	toolsets  string
+	readOnly  bool
+	logLevel  string
+	logFormat string
+}
+
+// ParseCommandLine parses the server flags
+func ParseCommandLine(args []string) (*CommandLine, error) {
+	cl := &CommandLine{set: make(map[string]bool)}
+
+	fs := flag.NewFlagSet(APP_NAME, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&cl.ConfigPath, "config", "", "path to a YAML or TOML config file")
+	fs.BoolVar(&cl.PrintConfig, "print-config", false, "print the effective config with secrets masked and exit")
+	fs.StringVar(&cl.values.apiURL, "api-url", "", "GitHub API base URL")
+	fs.StringVar(&cl.values.transport, "transport", "", "transport type: stdio or http")
+	fs.StringVar(&cl.values.address, "address", "", "listen address for the http transport")
+	fs.StringVar(&cl.values.toolsets, "toolsets", "", "comma separated list of toolsets to enable")
+	fs.BoolVar(&cl.values.readOnly, "read-only", false, "only register tools that do not modify GitHub")
+	fs.StringVar(&cl.values.logLevel, "log-level", "", "log level: debug, info, warn or error")
+	fs.StringVar(&cl.values.logFormat, "log-format", "", "log format: text or json")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("invalid command line: %w", err)
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("invalid command line: unexpected argument %q", fs.Arg(0))
+	}
+	fs.Visit(func(f *flag.Flag) {
+		cl.set[f.Name] = true
+	})
+	return cl, nil
+}
+
+// Load builds the effective configuration from defaults, the config file,
+// environment variables and command line flags, in increasing order of precedence
+func Load(cl *CommandLine) (*Config, error) {
+	cfg := Default()
+
+	path, err := findConfigFile(cl.ConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	cfg.applyFlags(cl)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// findConfigFile returns the explicit path, the path in GITHUB_MCP_CONFIG or the
+// first config file found in the XDG config directory, or "" if there is none
+func findConfigFile(explicit string) (string, error) {
+	if explicit == "" {
+		explicit = os.Getenv(CONFIG_PATH_ENV_VAR)
+	}
+	if explicit != "" {
+		if _, err := os.Stat(explicit); err != nil {
+			return "", fmt.Errorf("config file %s: %w", explicit, err)
+		}
+		return explicit, nil
+	}
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", nil
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	for _, name := range []string{"config.yaml", "config.yml", "config.toml"} {
+		candidate := filepath.Join(dir, APP_NAME, name)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", nil
+}
+
+// loadFile merges a YAML or TOML file, chosen by extension, into the config
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file %s: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(c); err != nil && err != io.EOF {
+			return fmt.Errorf("parsing config file %s: %w", path, err)
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(c); err != nil {
+			return fmt.Errorf("parsing config file %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("config file %s: unsupported extension, use .yaml, .yml or .toml", path)
+	}
+	return nil
+}
+
+// applyEnv overrides the config with the supported environment variables
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	if v, ok := lookup("GITHUB_PERSONAL_ACCESS_TOKEN"); ok && v != "" {
+		c.Auth.Token = v
+	}
+	if v, ok := lookup("GITHUB_API_URL"); ok && v != "" {
+		c.API.BaseURL = v
+	}
+	if v, ok := lookup("GITHUB_MCP_AUTH_METHOD"); ok && v != "" {
+		c.Auth.Method = v
+	}
+	if v, ok := lookup("GITHUB_MCP_TRANSPORT"); ok && v != "" {
+		c.Transport.Type = v
+	}
+	if v, ok := lookup("GITHUB_MCP_ADDRESS"); ok && v != "" {
+		c.Transport.Address = v
+	}
+	if v, ok := lookup("GITHUB_MCP_TOOLSETS"); ok && v != "" {
+		c.Toolsets = splitList(v)
+	}
+	if v, ok := lookup("GITHUB_MCP_READ_ONLY"); ok && v != "" {
+		readOnly, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("GITHUB_MCP_READ_ONLY: %q is not a boolean", v)
+		}
+		c.Policies.ReadOnly = readOnly
+	}
+	if v, ok := lookup("GITHUB_MCP_LOG_LEVEL"); ok && v != "" {
+		c.Logging.Level = v
+	}
+	if v, ok := lookup("GITHUB_MCP_SIGNING_KEY_FILE"); ok && v != "" {
+		c.Signing.KeyFile = v
+	}
+	if v, ok := lookup("GITHUB_MCP_SIGNING_PASSPHRASE"); ok && v != "" {
+		c.Signing.Passphrase = v
+	}
+	return nil
+}
+
+// applyFlags overrides the config with the flags explicitly set on the command line
+func (c *Config) applyFlags(cl *CommandLine) {
+	if cl == nil {
+		return
+	}
+	if cl.set["api-url"] {
+		c.API.BaseURL = cl.values.apiURL
+	}
+	if cl.set["transport"] {
+		c.Transport.Type = cl.values.transport
+	}
+	if cl.set["address"] {
+		c.Transport.Address = cl.values.address
+	}
+	if cl.set["toolsets"] {
+		c.Toolsets = splitList(cl.values.toolsets)
+	}
+	if cl.set["read-only"] {
+		c.Policies.ReadOnly = cl.values.readOnly
+	}
+	if cl.set["log-level"] {
+		c.Logging.Level = cl.values.logLevel
+	}
+	if cl.set["log-format"] {
+		c.Logging.Format = cl.values.logFormat
+	}
+}
+
+// Validate checks the config for invalid or inconsistent values
+func (c *Config) Validate() error {
+	u, err := url.Parse(c.API.BaseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("api.base_url: %q must be an absolute http or https URL", c.API.BaseURL)
+	}
+	if c.API.Timeout < 0 {
+		return fmt.Errorf("api.timeout: must not be negative")
+	}
+
+	switch c.Auth.Method {
+	case "token":
+		if c.Auth.Token == "" {
+			return fmt.Errorf("auth.token: a token is required with auth method \"token\", set it in the config file or with GITHUB_PERSONAL_ACCESS_TOKEN")
+		}
+	case "header":
+		if c.Transport.Type != "http" {
+			return fmt.Errorf("auth.method: \"header\" requires the http transport")
+		}
+	default:
+		return fmt.Errorf("auth.method: %q must be one of: token, header", c.Auth.Method)
+	}
+
+	switch c.Transport.Type {
+	case "stdio":
+	case "http":
+		if c.Transport.Address == "" {
+			return fmt.Errorf("transport.address: required for the http transport")
+		}
+		if !strings.HasPrefix(c.Transport.Path, "/") {
+			return fmt.Errorf("transport.path: %q must start with '/'", c.Transport.Path)
+		}
+	default:
+		return fmt.Errorf("transport.type: %q must be one of: stdio, http", c.Transport.Type)
+	}
+
+	if len(c.Toolsets) == 0 {
+		return fmt.Errorf("toolsets: at least one toolset must be enabled")
+	}
+
+	for _, pattern := range c.Policies.AllowedRepositories {
+		if _, err := path.Match(pattern, ""); err != nil || strings.Count(pattern, "/") != 1 {
+			return fmt.Errorf("policies.allowed_repositories: %q must be an owner/repo pattern", pattern)
+		}
+	}
+
+	if c.Cache.Enabled && c.Cache.TTL <= 0 {
+		return fmt.Errorf("cache.ttl: must be positive when the cache is enabled")
+	}
+	if c.Cache.MaxEntries < 0 {
+		return fmt.Errorf("cache.max_entries: must not be negative")
+	}
+
+	if c.Retry.MaxAttempts < 1 {
+		return fmt.Errorf("retry.max_attempts: must be at least 1")
+	}
+	if c.Retry.InitialBackoff < 0 || c.Retry.MaxBackoff < 0 {
+		return fmt.Errorf("retry: backoff durations must not be negative")
+	}
+
+	switch c.Logging.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("logging.level: %q must be one of: debug, info, warn, error", c.Logging.Level)
+	}
+	switch c.Logging.Format {
+	case "text", "json":
+	default:
+		return fmt.Errorf("logging.format: %q must be one of: text, json", c.Logging.Format)
+	}
+
+	switch c.Signing.Format {
+	case "":
+	case common.SIGNING_FORMAT_OPENPGP, common.SIGNING_FORMAT_SSH:
+		if c.Signing.KeyFile == "" {
+			return fmt.Errorf("signing.key_file: required to sign commits")
+		}
+		if c.Signing.Name == "" || c.Signing.Email == "" {
+			return fmt.Errorf("signing: name and email of the committer are required to sign commits")
+		}
+	default:
+		return fmt.Errorf("signing.format: %q must be one of: %s, %s", c.Signing.Format, common.SIGNING_FORMAT_OPENPGP, common.SIGNING_FORMAT_SSH)
+	}
+
+	return nil
+}
+
+// ClientOptions converts the config into the options used by the GitHub API client
+func (c *Config) ClientOptions() common.ClientOptions {
+	return common.ClientOptions{
+		BaseURL:    c.API.BaseURL,
+		AuthMethod: c.Auth.Method,
+		Token:      c.Auth.Token,
+		Timeout:    time.Duration(c.API.Timeout),
+		Retry: common.RetryOptions{
+			MaxAttempts:    c.Retry.MaxAttempts,
+			InitialBackoff: time.Duration(c.Retry.InitialBackoff),
+			MaxBackoff:     time.Duration(c.Retry.MaxBackoff),
+		},
+		Cache: common.CacheOptions{
+			Enabled:    c.Cache.Enabled,
+			TTL:        time.Duration(c.Cache.TTL),
+			MaxEntries: c.Cache.MaxEntries,
+		},
+		Policy: common.PolicyOptions{
+			ReadOnly:            c.Policies.ReadOnly,
+			AllowedRepositories: c.Policies.AllowedRepositories,
+		},
+	}
+}
+
+// CommitSigner reads the configured signing key, returning nil when commits are not signed
+func (c *Config) CommitSigner() (*common.CommitSigner, error) {
+	if c.Signing.Format == "" {
+		return nil, nil
+	}
+	key, err := os.ReadFile(c.Signing.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("signing.key_file: %w", err)
+	}
+	signer, err := common.NewCommitSigner(common.SigningOptions{
+		Format:     c.Signing.Format,
+		Key:        key,
+		Passphrase: c.Signing.Passphrase,
+		Name:       c.Signing.Name,
+		Email:      c.Signing.Email,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("signing: %w", err)
+	}
+	return signer, nil
+}
+
+// NewLogger builds a logger with the configured level and format
+func (c *Config) NewLogger(w io.Writer) *slog.Logger {
+	var level slog.Level
+	switch c.Logging.Level {
+	case "debug":
+		level = slog.LevelDebug
+	case "warn":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	default:
+		level = slog.LevelInfo
+	}
+
+	handlerOptions := &slog.HandlerOptions{Level: level}
+	if c.Logging.Format == "json" {
+		return slog.New(slog.NewJSONHandler(w, handlerOptions))
+	}
+	return slog.New(slog.NewTextHandler(w, handlerOptions))
+}
+
+// Masked returns a copy of the config with secrets replaced
+func (c *Config) Masked() *Config {
+	masked := *c
+	masked.Toolsets = append([]string(nil), c.Toolsets...)
+	masked.Policies.AllowedRepositories = append([]string(nil), c.Policies.AllowedRepositories...)
+	if masked.Auth.Token != "" {
+		masked.Auth.Token = MASKED_SECRET
+	}
+	if masked.Signing.Passphrase != "" {
+		masked.Signing.Passphrase = MASKED_SECRET
+	}
+	return &masked
+}
+
+// Dump writes the config as YAML with secrets masked
+func (c *Config) Dump(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c.Masked()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
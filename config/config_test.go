@@ -0,0 +1,235 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		modify        func(c *Config)
+		wantErr       bool
+		errorContains string
+	}{
+		{
+			name:    "valid config",
+			modify:  func(c *Config) {},
+			wantErr: false,
+		},
+		{
+			name:          "relative base url",
+			modify:        func(c *Config) { c.API.BaseURL = "api.github.com" },
+			wantErr:       true,
+			errorContains: "api.base_url",
+		},
+		{
+			name:          "missing token",
+			modify:        func(c *Config) { c.Auth.Token = "" },
+			wantErr:       true,
+			errorContains: "auth.token",
+		},
+		{
+			name:          "header auth over stdio",
+			modify:        func(c *Config) { c.Auth.Method = "header" },
+			wantErr:       true,
+			errorContains: "requires the http transport",
+		},
+		{
+			name: "header auth over http",
+			modify: func(c *Config) {
+				c.Auth.Method = "header"
+				c.Auth.Token = ""
+				c.Transport.Type = "http"
+			},
+			wantErr: false,
+		},
+		{
+			name:          "unknown transport",
+			modify:        func(c *Config) { c.Transport.Type = "sse" },
+			wantErr:       true,
+			errorContains: "transport.type",
+		},
+		{
+			name:          "invalid repository pattern",
+			modify:        func(c *Config) { c.Policies.AllowedRepositories = []string{"metoro-io"} },
+			wantErr:       true,
+			errorContains: "policies.allowed_repositories",
+		},
+		{
+			name:          "zero retry attempts",
+			modify:        func(c *Config) { c.Retry.MaxAttempts = 0 },
+			wantErr:       true,
+			errorContains: "retry.max_attempts",
+		},
+		{
+			name:          "unknown log level",
+			modify:        func(c *Config) { c.Logging.Level = "verbose" },
+			wantErr:       true,
+			errorContains: "logging.level",
+		},
+		{
+			name:          "unknown signing format",
+			modify:        func(c *Config) { c.Signing.Format = "x509" },
+			wantErr:       true,
+			errorContains: "signing.format",
+		},
+		{
+			name: "signing without committer",
+			modify: func(c *Config) {
+				c.Signing.Format = "ssh"
+				c.Signing.KeyFile = "/keys/id_ed25519"
+			},
+			wantErr:       true,
+			errorContains: "name and email",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Auth.Token = "test-token"
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil && tt.errorContains != "" {
+				if !strings.Contains(err.Error(), tt.errorContains) {
+					t.Errorf("Validate() error = %v, should contain %v", err, tt.errorContains)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadLayering(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		contents string
+	}{
+		{
+			name: "yaml",
+			file: "config.yaml",
+			contents: `
+api:
+  base_url: https://github.example.com/api/v3
+auth:
+  token: file-token
+transport:
+  type: http
+retry:
+  max_attempts: 5
+  initial_backoff: 2s
+logging:
+  level: warn
+`,
+		},
+		{
+			name: "toml",
+			file: "config.toml",
+			contents: `
+[api]
+base_url = "https://github.example.com/api/v3"
+
+[auth]
+token = "file-token"
+
+[transport]
+type = "http"
+
+[retry]
+max_attempts = 5
+initial_backoff = "2s"
+
+[logging]
+level = "warn"
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.contents), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			t.Setenv("GITHUB_PERSONAL_ACCESS_TOKEN", "env-token")
+			t.Setenv("GITHUB_MCP_LOG_LEVEL", "error")
+
+			cl, err := ParseCommandLine([]string{"--config", path, "--log-level", "debug"})
+			if err != nil {
+				t.Fatalf("ParseCommandLine() error = %v", err)
+			}
+
+			cfg, err := Load(cl)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if cfg.API.BaseURL != "https://github.example.com/api/v3" {
+				t.Errorf("API.BaseURL = %q, want value from file", cfg.API.BaseURL)
+			}
+			if cfg.Transport.Type != "http" {
+				t.Errorf("Transport.Type = %q, want value from file", cfg.Transport.Type)
+			}
+			if cfg.Retry.MaxAttempts != 5 || time.Duration(cfg.Retry.InitialBackoff) != 2*time.Second {
+				t.Errorf("Retry = %+v, want values from file", cfg.Retry)
+			}
+			if cfg.Auth.Token != "env-token" {
+				t.Errorf("Auth.Token = %q, environment should override the file", cfg.Auth.Token)
+			}
+			if cfg.Logging.Level != "debug" {
+				t.Errorf("Logging.Level = %q, flag should override the environment", cfg.Logging.Level)
+			}
+			if time.Duration(cfg.API.Timeout) != 30*time.Second {
+				t.Errorf("API.Timeout = %v, want the default", cfg.API.Timeout)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsUnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("api:\n  base_uri: https://example.com\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_PERSONAL_ACCESS_TOKEN", "env-token")
+
+	_, err := Load(&CommandLine{ConfigPath: path})
+	if err == nil || !strings.Contains(err.Error(), "base_uri") {
+		t.Errorf("Load() error = %v, should mention the unknown field", err)
+	}
+}
+
+func TestDumpMasksSecrets(t *testing.T) {
+	cfg := Default()
+	cfg.Auth.Token = "ghp_supersecret"
+	cfg.Signing.Passphrase = "key-passphrase"
+
+	var buf bytes.Buffer
+	if err := cfg.Dump(&buf); err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+
+	if strings.Contains(buf.String(), "ghp_supersecret") {
+		t.Errorf("Dump() leaked the token:\n%s", buf.String())
+	}
+	if strings.Contains(buf.String(), "key-passphrase") {
+		t.Errorf("Dump() leaked the signing key passphrase:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), MASKED_SECRET) {
+		t.Errorf("Dump() should contain the masked token:\n%s", buf.String())
+	}
+	if cfg.Auth.Token != "ghp_supersecret" {
+		t.Errorf("Dump() must not modify the config")
+	}
+}
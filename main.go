@@ -2,42 +2,94 @@ package main
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/github-mcp-server-go/common"
+	"github.com/metoro-io/github-mcp-server-go/config"
 	"github.com/metoro-io/github-mcp-server-go/tools"
 	mcpgolang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/mcp-golang/transport"
+	mcphttp "github.com/metoro-io/mcp-golang/transport/http"
 	"github.com/metoro-io/mcp-golang/transport/stdio"
 )
 
 func main() {
-	// Check if the appropriate environment variables are set
-	if err := checkEnvVars(); err != nil {
-		panic(err)
+	commandLine, err := config.ParseCommandLine(os.Args[1:])
+	if err != nil {
+		exitWithError(err)
+	}
+
+	cfg, err := config.Load(commandLine)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	if commandLine.PrintConfig {
+		if err := cfg.Dump(os.Stdout); err != nil {
+			exitWithError(err)
+		}
+		return
+	}
+
+	// Logs always go to stderr since stdout carries the stdio transport
+	slog.SetDefault(cfg.NewLogger(os.Stderr))
+	clientOptions := cfg.ClientOptions()
+	clientOptions.Signer, err = cfg.CommitSigner()
+	if err != nil {
+		exitWithError(err)
+	}
+	common.Configure(clientOptions)
+
+	enabledTools, err := tools.FilterTools(cfg.Toolsets, cfg.Policies.ReadOnly)
+	if err != nil {
+		exitWithError(fmt.Errorf("toolsets: %w", err))
 	}
 
 	done := make(chan struct{})
 
-	mcpServer := mcpgolang.NewServer(stdio.NewStdioServerTransport())
+	// The stateless HTTP transport answers each request with a single response, which leaves no room for progress notifications
+	toolCalls := common.NewToolCallTransport(cfg.Transport.Type != "http")
+	var serverTransport transport.Transport
+	var ginTransport *mcphttp.GinTransport
+	switch cfg.Transport.Type {
+	case "http":
+		ginTransport = mcphttp.NewGinTransport()
+		serverTransport = ginTransport
+	default:
+		serverTransport = stdio.NewStdioServerTransportWithIO(toolCalls.WatchCancellations(os.Stdin), os.Stdout)
+	}
+
+	mcpServer := mcpgolang.NewServer(toolCalls.Wrap(serverTransport), mcpgolang.WithName(config.APP_NAME), mcpgolang.WithVersion(common.VERSION))
 
 	// Add tools
-	for _, tool := range tools.GitHubToolsList {
+	for _, tool := range enabledTools {
 		err := mcpServer.RegisterTool(tool.Name, tool.Description, tool.Handler)
 		if err != nil {
 			panic(err)
 		}
 	}
+	slog.Info("starting server", "transport", cfg.Transport.Type, "tools", len(enabledTools))
 
-	err := mcpServer.Serve()
+	err = mcpServer.Serve()
 	if err != nil {
 		panic(err)
 	}
 
+	if ginTransport != nil {
+		gin.SetMode(gin.ReleaseMode)
+		router := gin.New()
+		router.POST(cfg.Transport.Path, ginTransport.Handler())
+		if err := router.Run(cfg.Transport.Address); err != nil {
+			panic(err)
+		}
+	}
+
 	<-done
 }
 
-func checkEnvVars() error {
-	if os.Getenv("GITHUB_PERSONAL_ACCESS_TOKEN") == "" {
-		return fmt.Errorf("GITHUB_PERSONAL_ACCESS_TOKEN environment variable not set")
-	}
-	return nil
+func exitWithError(err error) {
+	fmt.Fprintf(os.Stderr, "error: %v\n", err)
+	os.Exit(1)
 }
@@ -0,0 +1,462 @@
+package operations
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/metoro-io/github-mcp-server-go/common"
+	"gopkg.in/yaml.v3"
+)
+
+// workflowIDPattern matches a workflow ID or the file name of a workflow
+var workflowIDPattern = regexp.MustCompile(`^([0-9]+|[A-Za-z0-9._-]+\.ya?ml)$`)
+
+func validateWorkflowID(workflow string) error {
+	if !workflowIDPattern.MatchString(workflow) {
+		return fmt.Errorf("workflow %q must be a workflow ID or a file name such as ci.yml", workflow)
+	}
+	return nil
+}
+
+// ListWorkflowsOptions defines options for listing the workflows of a repository
+type ListWorkflowsOptions struct {
+	Owner   string `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo    string `json:"repo" jsonschema:"description=The name of the repository"`
+	Page    int    `json:"page,omitempty" jsonschema:"description=Page number of the results to fetch. Default: 1"`
+	PerPage int    `json:"per_page,omitempty" jsonschema:"description=Number of results per page. Default: 30. Maximum: 100"`
+}
+
+// Validate validates the ListWorkflowsOptions
+func (o *ListWorkflowsOptions) Validate() error {
+	if _, err := common.ValidateOwnerName(o.Owner); err != nil {
+		return err
+	}
+	if _, err := common.ValidateRepositoryName(o.Repo); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListWorkflows lists the GitHub Actions workflows of a repository
+func ListWorkflows(options *ListWorkflowsOptions, apiReqs *common.APIRequirements) (*common.WorkflowList, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	url := common.APIURL("/repos/%s/%s/actions/workflows", options.Owner, options.Repo)
+	params := make(map[string]string)
+	if options.Page > 0 {
+		params["page"] = strconv.Itoa(options.Page)
+	}
+	if options.PerPage > 0 {
+		params["per_page"] = strconv.Itoa(options.PerPage)
+	}
+	url, err := common.BuildURL(url, params)
+	if err != nil {
+		return nil, err
+	}
+
+	workflows, _, err := common.TypedGitHubRequest[common.WorkflowList](url, "GET", nil, apiReqs)
+	if err != nil {
+		return nil, fmt.Errorf("error listing workflows: %w", err)
+	}
+	return &workflows, nil
+}
+
+// ListWorkflowRunsOptions defines options for listing workflow runs
+type ListWorkflowRunsOptions struct {
+	Owner    string `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo     string `json:"repo" jsonschema:"description=The name of the repository"`
+	Workflow string `json:"workflow,omitempty" jsonschema:"description=Only list runs of this workflow given by ID or file name such as ci.yml. Default: all workflows"`
+	Branch   string `json:"branch,omitempty" jsonschema:"description=Only list runs for this branch"`
+	Event    string `json:"event,omitempty" jsonschema:"description=Only list runs triggered by this event such as push pull_request or workflow_dispatch"`
+	Status   string `json:"status,omitempty" jsonschema:"description=Only list runs with this status or conclusion. Can be one of: queued in_progress requested waiting pending completed success failure cancelled skipped timed_out action_required neutral stale"`
+	Actor    string `json:"actor,omitempty" jsonschema:"description=Only list runs triggered by this user login"`
+	HeadSHA  string `json:"head_sha,omitempty" jsonschema:"description=Only list runs for this commit SHA"`
+	Page     int    `json:"page,omitempty" jsonschema:"description=Page number of the results to fetch. Default: 1"`
+	PerPage  int    `json:"per_page,omitempty" jsonschema:"description=Number of results per page. Default: 30. Maximum: 100"`
+}
+
+// workflowRunStatuses are the values the status filter of workflow runs accepts
+var workflowRunStatuses = []string{
+	"queued", "in_progress", "requested", "waiting", "pending", "completed",
+	"success", "failure", "cancelled", "skipped", "timed_out", "action_required", "neutral", "stale",
+}
+
+// Validate validates the ListWorkflowRunsOptions
+func (o *ListWorkflowRunsOptions) Validate() error {
+	if _, err := common.ValidateOwnerName(o.Owner); err != nil {
+		return err
+	}
+	if _, err := common.ValidateRepositoryName(o.Repo); err != nil {
+		return err
+	}
+	if o.Workflow != "" {
+		if err := validateWorkflowID(o.Workflow); err != nil {
+			return err
+		}
+	}
+	if o.Branch != "" {
+		if _, err := common.ValidateBranchName(o.Branch); err != nil {
+			return err
+		}
+	}
+	if o.Status != "" && !containsString(workflowRunStatuses, o.Status) {
+		return fmt.Errorf("status must be one of: %s", strings.Join(workflowRunStatuses, ", "))
+	}
+	return nil
+}
+
+// ListWorkflowRuns lists the runs of one or all workflows of a repository
+func ListWorkflowRuns(options *ListWorkflowRunsOptions, apiReqs *common.APIRequirements) (*common.WorkflowRunList, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	url := common.APIURL("/repos/%s/%s/actions/runs", options.Owner, options.Repo)
+	if options.Workflow != "" {
+		url = common.APIURL("/repos/%s/%s/actions/workflows/%s/runs", options.Owner, options.Repo, options.Workflow)
+	}
+
+	params := make(map[string]string)
+	for key, value := range map[string]string{
+		"branch":   options.Branch,
+		"event":    options.Event,
+		"status":   options.Status,
+		"actor":    options.Actor,
+		"head_sha": options.HeadSHA,
+	} {
+		if value != "" {
+			params[key] = value
+		}
+	}
+	if options.Page > 0 {
+		params["page"] = strconv.Itoa(options.Page)
+	}
+	if options.PerPage > 0 {
+		params["per_page"] = strconv.Itoa(options.PerPage)
+	}
+	url, err := common.BuildURL(url, params)
+	if err != nil {
+		return nil, err
+	}
+
+	runs, _, err := common.TypedGitHubRequest[common.WorkflowRunList](url, "GET", nil, apiReqs)
+	if err != nil {
+		return nil, fmt.Errorf("error listing workflow runs: %w", err)
+	}
+	return &runs, nil
+}
+
+// WorkflowRunOptions identifies a workflow run
+type WorkflowRunOptions struct {
+	Owner string `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo  string `json:"repo" jsonschema:"description=The name of the repository"`
+	RunID int64  `json:"run_id" jsonschema:"description=The ID of the workflow run"`
+}
+
+// Validate validates the WorkflowRunOptions
+func (o *WorkflowRunOptions) Validate() error {
+	if _, err := common.ValidateOwnerName(o.Owner); err != nil {
+		return err
+	}
+	if _, err := common.ValidateRepositoryName(o.Repo); err != nil {
+		return err
+	}
+	if o.RunID <= 0 {
+		return fmt.Errorf("run_id is required")
+	}
+	return nil
+}
+
+// GetWorkflowRun gets a workflow run with the jobs of its latest attempt and their steps
+func GetWorkflowRun(options *WorkflowRunOptions, apiReqs *common.APIRequirements) (*common.WorkflowRunDetails, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	run, err := getWorkflowRun(options.Owner, options.Repo, options.RunID, apiReqs)
+	if err != nil {
+		return nil, err
+	}
+	details := &common.WorkflowRunDetails{WorkflowRun: *run, Jobs: []common.WorkflowJob{}}
+
+	jobsURL := common.APIURL("/repos/%s/%s/actions/runs/%d/jobs?filter=latest&per_page=100", options.Owner, options.Repo, options.RunID)
+	for page := 0; jobsURL != "" && page < MAX_CHECK_PAGES; page++ {
+		jobs, resp, err := common.TypedGitHubRequest[common.WorkflowJobList](jobsURL, "GET", nil, apiReqs)
+		if err != nil {
+			return nil, fmt.Errorf("error listing jobs of workflow run: %w", err)
+		}
+		details.Jobs = append(details.Jobs, jobs.Jobs...)
+		jobsURL = resp.NextPageURL()
+	}
+	return details, nil
+}
+
+func getWorkflowRun(owner string, repo string, runID int64, apiReqs *common.APIRequirements) (*common.WorkflowRun, error) {
+	url := common.APIURL("/repos/%s/%s/actions/runs/%d", owner, repo, runID)
+	run, _, err := common.TypedGitHubRequest[common.WorkflowRun](url, "GET", nil, apiReqs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting workflow run: %w", err)
+	}
+	return &run, nil
+}
+
+// RerunFailedJobsOptions defines options for re-running the failed jobs of a workflow run
+type RerunFailedJobsOptions struct {
+	Owner              string `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo               string `json:"repo" jsonschema:"description=The name of the repository"`
+	RunID              int64  `json:"run_id" jsonschema:"description=The ID of the workflow run"`
+	EnableDebugLogging bool   `json:"enable_debug_logging,omitempty" jsonschema:"description=Enable step debug logging for the new attempt. Default: false"`
+}
+
+// Validate validates the RerunFailedJobsOptions
+func (o *RerunFailedJobsOptions) Validate() error {
+	run := WorkflowRunOptions{Owner: o.Owner, Repo: o.Repo, RunID: o.RunID}
+	return run.Validate()
+}
+
+// RerunFailedJobs starts a new attempt of a workflow run with its failed jobs and
+// the jobs that depend on them, and returns the run as it is after the request
+func RerunFailedJobs(options *RerunFailedJobsOptions, apiReqs *common.APIRequirements) (*common.WorkflowRun, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	url := common.APIURL("/repos/%s/%s/actions/runs/%d/rerun-failed-jobs", options.Owner, options.Repo, options.RunID)
+	body := map[string]interface{}{}
+	if options.EnableDebugLogging {
+		body["enable_debug_logging"] = true
+	}
+	if _, _, err := common.TypedGitHubRequest[interface{}](url, "POST", body, apiReqs); err != nil {
+		return nil, fmt.Errorf("error re-running failed jobs: %w", err)
+	}
+	return getWorkflowRun(options.Owner, options.Repo, options.RunID, apiReqs)
+}
+
+// CancelWorkflowRunOptions defines options for cancelling a workflow run
+type CancelWorkflowRunOptions struct {
+	Owner string `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo  string `json:"repo" jsonschema:"description=The name of the repository"`
+	RunID int64  `json:"run_id" jsonschema:"description=The ID of the workflow run"`
+	Force bool   `json:"force,omitempty" jsonschema:"description=Cancel the run even if its jobs ignore cancellation such as steps with if: always(). Default: false"`
+}
+
+// Validate validates the CancelWorkflowRunOptions
+func (o *CancelWorkflowRunOptions) Validate() error {
+	run := WorkflowRunOptions{Owner: o.Owner, Repo: o.Repo, RunID: o.RunID}
+	return run.Validate()
+}
+
+// CancelWorkflowRun requests the cancellation of a workflow run and returns the run as it is after the request
+func CancelWorkflowRun(options *CancelWorkflowRunOptions, apiReqs *common.APIRequirements) (*common.WorkflowRun, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	action := "cancel"
+	if options.Force {
+		action = "force-cancel"
+	}
+	url := common.APIURL("/repos/%s/%s/actions/runs/%d/%s", options.Owner, options.Repo, options.RunID, action)
+	if _, _, err := common.TypedGitHubRequest[interface{}](url, "POST", nil, apiReqs); err != nil {
+		return nil, fmt.Errorf("error cancelling workflow run: %w", err)
+	}
+	return getWorkflowRun(options.Owner, options.Repo, options.RunID, apiReqs)
+}
+
+// DispatchWorkflowOptions defines options for triggering a workflow_dispatch event
+type DispatchWorkflowOptions struct {
+	Owner    string            `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo     string            `json:"repo" jsonschema:"description=The name of the repository"`
+	Workflow string            `json:"workflow" jsonschema:"description=The workflow ID or file name such as deploy.yml"`
+	Ref      string            `json:"ref" jsonschema:"description=The branch or tag to run the workflow on"`
+	Inputs   map[string]string `json:"inputs,omitempty" jsonschema:"description=Values of the inputs declared under on.workflow_dispatch.inputs in the workflow. Booleans are true or false"`
+}
+
+// Validate validates the DispatchWorkflowOptions
+func (o *DispatchWorkflowOptions) Validate() error {
+	if _, err := common.ValidateOwnerName(o.Owner); err != nil {
+		return err
+	}
+	if _, err := common.ValidateRepositoryName(o.Repo); err != nil {
+		return err
+	}
+	if err := validateWorkflowID(o.Workflow); err != nil {
+		return err
+	}
+	if _, err := common.ValidateBranchName(o.Ref); err != nil {
+		return fmt.Errorf("invalid ref: %w", err)
+	}
+	return nil
+}
+
+// DispatchWorkflow triggers a workflow_dispatch event after checking the inputs against
+// the ones the workflow file declares at ref
+func DispatchWorkflow(options *DispatchWorkflowOptions, apiReqs *common.APIRequirements) (*common.WorkflowDispatchResult, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	url := common.APIURL("/repos/%s/%s/actions/workflows/%s", options.Owner, options.Repo, options.Workflow)
+	workflow, _, err := common.TypedGitHubRequest[common.Workflow](url, "GET", nil, apiReqs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting workflow: %w", err)
+	}
+
+	file, err := GetFileContents(&GetFileContentsOptions{
+		Owner:    options.Owner,
+		Repo:     options.Repo,
+		Path:     workflow.Path,
+		Ref:      options.Ref,
+		Encoding: ENCODING_UTF8,
+	}, apiReqs)
+	if err != nil {
+		return nil, fmt.Errorf("error reading workflow file %s: %w", workflow.Path, err)
+	}
+	content, ok := file.(common.FileContent)
+	if !ok {
+		return nil, fmt.Errorf("workflow file %s is not a file", workflow.Path)
+	}
+
+	declared, err := parseDispatchInputs([]byte(content.Content))
+	if err != nil {
+		return nil, fmt.Errorf("workflow file %s at %s: %w", workflow.Path, options.Ref, err)
+	}
+	defaults, err := validateDispatchInputs(declared, options.Inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := options.Inputs
+	if inputs == nil {
+		inputs = map[string]string{}
+	}
+	dispatchURL := common.APIURL("/repos/%s/%s/actions/workflows/%s/dispatches", options.Owner, options.Repo, options.Workflow)
+	body := map[string]interface{}{
+		"ref":    options.Ref,
+		"inputs": inputs,
+	}
+	if _, _, err := common.TypedGitHubRequest[interface{}](dispatchURL, "POST", body, apiReqs); err != nil {
+		return nil, fmt.Errorf("error dispatching workflow: %w", err)
+	}
+
+	return &common.WorkflowDispatchResult{
+		Workflow: workflow.Path,
+		Ref:      options.Ref,
+		Inputs:   inputs,
+		Defaults: defaults,
+		Message:  "The workflow was triggered. Use list_workflow_runs with event workflow_dispatch to find the run once it starts.",
+	}, nil
+}
+
+// dispatchInput is an input declared under on.workflow_dispatch.inputs
+type dispatchInput struct {
+	Description string      `yaml:"description"`
+	Required    bool        `yaml:"required"`
+	Default     interface{} `yaml:"default"`
+	Type        string      `yaml:"type"`
+	Options     []string    `yaml:"options"`
+}
+
+// parseDispatchInputs reads the workflow_dispatch inputs of a workflow file. The on key
+// may be a single event, a list of events or a map of events to their configuration.
+func parseDispatchInputs(content []byte) (map[string]dispatchInput, error) {
+	var workflow struct {
+		On yaml.Node `yaml:"on"`
+	}
+	if err := yaml.Unmarshal(content, &workflow); err != nil {
+		return nil, fmt.Errorf("error parsing workflow: %w", err)
+	}
+
+	switch workflow.On.Kind {
+	case yaml.ScalarNode:
+		if workflow.On.Value == "workflow_dispatch" {
+			return map[string]dispatchInput{}, nil
+		}
+	case yaml.SequenceNode:
+		var events []string
+		if err := workflow.On.Decode(&events); err != nil {
+			return nil, fmt.Errorf("error parsing on: %w", err)
+		}
+		if containsString(events, "workflow_dispatch") {
+			return map[string]dispatchInput{}, nil
+		}
+	case yaml.MappingNode:
+		var events map[string]*struct {
+			Inputs map[string]dispatchInput `yaml:"inputs"`
+		}
+		if err := workflow.On.Decode(&events); err != nil {
+			return nil, fmt.Errorf("error parsing on: %w", err)
+		}
+		if dispatch, ok := events["workflow_dispatch"]; ok {
+			if dispatch == nil || dispatch.Inputs == nil {
+				return map[string]dispatchInput{}, nil
+			}
+			return dispatch.Inputs, nil
+		}
+	}
+	return nil, fmt.Errorf("the workflow has no workflow_dispatch trigger")
+}
+
+// validateDispatchInputs checks inputs against the declared ones and returns the
+// names of the declared inputs that were omitted and fall back to their default
+func validateDispatchInputs(declared map[string]dispatchInput, inputs map[string]string) ([]string, error) {
+	names := make([]string, 0, len(declared))
+	for name := range declared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for name := range inputs {
+		if _, ok := declared[name]; !ok {
+			if len(names) == 0 {
+				return nil, fmt.Errorf("input %s is not declared, the workflow takes no inputs", name)
+			}
+			return nil, fmt.Errorf("input %s is not declared, the workflow takes: %s", name, strings.Join(names, ", "))
+		}
+	}
+
+	var defaults []string
+	for _, name := range names {
+		input := declared[name]
+		value, ok := inputs[name]
+		if !ok {
+			if input.Required && input.Default == nil {
+				return nil, fmt.Errorf("input %s is required", name)
+			}
+			if input.Default != nil {
+				defaults = append(defaults, name)
+			}
+			continue
+		}
+
+		switch input.Type {
+		case "boolean":
+			if value != "true" && value != "false" {
+				return nil, fmt.Errorf("input %s must be true or false", name)
+			}
+		case "number":
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return nil, fmt.Errorf("input %s must be a number", name)
+			}
+		case "choice":
+			if !containsString(input.Options, value) {
+				return nil, fmt.Errorf("input %s must be one of: %s", name, strings.Join(input.Options, ", "))
+			}
+		}
+	}
+	return defaults, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
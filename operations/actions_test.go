@@ -0,0 +1,200 @@
+package operations
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/metoro-io/github-mcp-server-go/common"
+)
+
+const deployWorkflow = `name: Deploy
+on:
+  push:
+    branches: [main]
+  workflow_dispatch:
+    inputs:
+      environment:
+        type: choice
+        required: true
+        options: [staging, production]
+      dry_run:
+        type: boolean
+        default: false
+      replicas:
+        type: number
+      reason:
+        description: Why the deploy is run
+        required: true
+        default: manual
+`
+
+func TestParseDispatchInputs(t *testing.T) {
+	tests := []struct {
+		name       string
+		workflow   string
+		wantInputs int
+		wantErr    string
+	}{
+		{name: "inputs", workflow: deployWorkflow, wantInputs: 4},
+		{name: "single event", workflow: "on: workflow_dispatch\n"},
+		{name: "list of events", workflow: "on: [push, workflow_dispatch]\n"},
+		{name: "trigger without inputs", workflow: "on:\n  workflow_dispatch:\n"},
+		{name: "no dispatch trigger", workflow: "on:\n  push:\n    branches: [main]\n", wantErr: "no workflow_dispatch trigger"},
+		{name: "invalid yaml", workflow: "on: [push\n", wantErr: "error parsing workflow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputs, err := parseDispatchInputs([]byte(tt.workflow))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseDispatchInputs() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseDispatchInputs() error = %v", err)
+			}
+			if len(inputs) != tt.wantInputs {
+				t.Errorf("got %d inputs, want %d", len(inputs), tt.wantInputs)
+			}
+		})
+	}
+}
+
+func TestDispatchWorkflow(t *testing.T) {
+	tests := []struct {
+		name         string
+		inputs       map[string]string
+		wantDefaults []string
+		wantErr      string
+	}{
+		{
+			name:         "valid inputs",
+			inputs:       map[string]string{"environment": "staging", "replicas": "3"},
+			wantDefaults: []string{"dry_run", "reason"},
+		},
+		{name: "missing required input", inputs: map[string]string{"dry_run": "true"}, wantErr: "input environment is required"},
+		{name: "undeclared input", inputs: map[string]string{"environment": "staging", "region": "eu"}, wantErr: "input region is not declared"},
+		{name: "invalid choice", inputs: map[string]string{"environment": "dev"}, wantErr: "must be one of: staging, production"},
+		{name: "invalid boolean", inputs: map[string]string{"environment": "staging", "dry_run": "yes"}, wantErr: "must be true or false"},
+		{name: "invalid number", inputs: map[string]string{"environment": "staging", "replicas": "three"}, wantErr: "must be a number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dispatched map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/repos/octo/repo/actions/workflows/deploy.yml":
+					fmt.Fprint(w, `{"id":1,"name":"Deploy","path":".github/workflows/deploy.yml","state":"active"}`)
+				case r.URL.Path == "/repos/octo/repo/contents/.github/workflows/deploy.yml":
+					if ref := r.URL.Query().Get("ref"); ref != "release" {
+						t.Errorf("workflow read at %q, want release", ref)
+					}
+					fmt.Fprintf(w, `{"type":"file","encoding":"base64","path":".github/workflows/deploy.yml","content":%q}`,
+						base64.StdEncoding.EncodeToString([]byte(deployWorkflow)))
+				case r.URL.Path == "/repos/octo/repo/actions/workflows/deploy.yml/dispatches" && r.Method == "POST":
+					json.NewDecoder(r.Body).Decode(&dispatched)
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			defer common.Configure(common.DefaultClientOptions())
+			common.Configure(common.ClientOptions{BaseURL: server.URL, Token: "test"})
+
+			result, err := DispatchWorkflow(&DispatchWorkflowOptions{Owner: "octo", Repo: "repo", Workflow: "deploy.yml", Ref: "release", Inputs: tt.inputs}, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("DispatchWorkflow() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if dispatched != nil {
+					t.Errorf("the workflow was dispatched despite invalid inputs")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DispatchWorkflow() error = %v", err)
+			}
+
+			if dispatched["ref"] != "release" {
+				t.Errorf("dispatched ref = %v, want release", dispatched["ref"])
+			}
+			if inputs, _ := dispatched["inputs"].(map[string]interface{}); len(inputs) != len(tt.inputs) {
+				t.Errorf("dispatched inputs = %v, want %v", dispatched["inputs"], tt.inputs)
+			}
+			if strings.Join(result.Defaults, ",") != strings.Join(tt.wantDefaults, ",") {
+				t.Errorf("Defaults = %v, want %v", result.Defaults, tt.wantDefaults)
+			}
+			if result.Workflow != ".github/workflows/deploy.yml" {
+				t.Errorf("Workflow = %v", result.Workflow)
+			}
+		})
+	}
+}
+
+func TestListWorkflowRuns(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   ListWorkflowRunsOptions
+		wantPath  string
+		wantQuery string
+		wantErr   string
+	}{
+		{
+			name:      "all workflows",
+			options:   ListWorkflowRunsOptions{Branch: "main", Status: "failure"},
+			wantPath:  "/repos/octo/repo/actions/runs",
+			wantQuery: "branch=main&status=failure",
+		},
+		{
+			name:      "one workflow",
+			options:   ListWorkflowRunsOptions{Workflow: "ci.yml", Event: "push", Actor: "mona", PerPage: 5},
+			wantPath:  "/repos/octo/repo/actions/workflows/ci.yml/runs",
+			wantQuery: "actor=mona&event=push&per_page=5",
+		},
+		{name: "invalid status", options: ListWorkflowRunsOptions{Status: "broken"}, wantErr: "status must be one of"},
+		{name: "invalid workflow", options: ListWorkflowRunsOptions{Workflow: "../ci"}, wantErr: "must be a workflow ID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path, query string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path, query = r.URL.Path, r.URL.Query().Encode()
+				fmt.Fprint(w, `{"total_count":1,"workflow_runs":[{"id":7,"status":"completed","conclusion":"failure"}]}`)
+			}))
+			defer server.Close()
+
+			defer common.Configure(common.DefaultClientOptions())
+			common.Configure(common.ClientOptions{BaseURL: server.URL, Token: "test"})
+
+			options := tt.options
+			options.Owner, options.Repo = "octo", "repo"
+			result, err := ListWorkflowRuns(&options, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ListWorkflowRuns() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListWorkflowRuns() error = %v", err)
+			}
+			if path != tt.wantPath || query != tt.wantQuery {
+				t.Errorf("requested %s?%s, want %s?%s", path, query, tt.wantPath, tt.wantQuery)
+			}
+			if len(result.WorkflowRuns) != 1 || result.WorkflowRuns[0].ID != 7 {
+				t.Errorf("result = %+v", result)
+			}
+		})
+	}
+}
@@ -0,0 +1,371 @@
+package operations
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/metoro-io/github-mcp-server-go/common"
+)
+
+const (
+	// MAX_ARTIFACT_SIZE bounds the compressed size of artifacts, which are extracted in memory
+	MAX_ARTIFACT_SIZE = 100 * 1024 * 1024
+	// MAX_ARTIFACT_MANIFEST bounds the files listed from an archive
+	MAX_ARTIFACT_MANIFEST = 1000
+	// DEFAULT_ARTIFACT_FILE_SIZE is the text returned per file when max_file_size is not set
+	DEFAULT_ARTIFACT_FILE_SIZE = 32 * 1024
+	// MAX_ARTIFACT_FILE_SIZE bounds the max_file_size a caller can ask for
+	MAX_ARTIFACT_FILE_SIZE = 256 * 1024
+	// MAX_ARTIFACT_CONTENT_SIZE bounds the text returned from all files together
+	MAX_ARTIFACT_CONTENT_SIZE = 256 * 1024
+	// MAX_JUNIT_FILE_SIZE bounds the uncompressed size of a JUnit report that is parsed
+	MAX_JUNIT_FILE_SIZE = 20 * 1024 * 1024
+	// MAX_JUNIT_TOTAL_SIZE bounds the uncompressed size of all XML files read for JUnit reports
+	MAX_JUNIT_TOTAL_SIZE = 50 * 1024 * 1024
+	// MAX_TEST_FAILURES bounds the failed and errored test cases listed across all reports
+	MAX_TEST_FAILURES = 50
+)
+
+// ListArtifactsOptions defines options for listing the artifacts of a workflow run
+type ListArtifactsOptions struct {
+	Owner   string `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo    string `json:"repo" jsonschema:"description=The name of the repository"`
+	RunID   int64  `json:"run_id" jsonschema:"description=The ID of the workflow run"`
+	Name    string `json:"name,omitempty" jsonschema:"description=Only list artifacts with this name"`
+	Page    int    `json:"page,omitempty" jsonschema:"description=Page number of the results to fetch. Default: 1"`
+	PerPage int    `json:"per_page,omitempty" jsonschema:"description=Number of results per page. Default: 30. Maximum: 100"`
+}
+
+// Validate validates the ListArtifactsOptions
+func (o *ListArtifactsOptions) Validate() error {
+	run := WorkflowRunOptions{Owner: o.Owner, Repo: o.Repo, RunID: o.RunID}
+	return run.Validate()
+}
+
+// ListArtifacts lists the artifacts uploaded by a workflow run
+func ListArtifacts(options *ListArtifactsOptions, apiReqs *common.APIRequirements) (*common.ArtifactList, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	url := common.APIURL("/repos/%s/%s/actions/runs/%d/artifacts", options.Owner, options.Repo, options.RunID)
+	params := make(map[string]string)
+	if options.Name != "" {
+		params["name"] = options.Name
+	}
+	if options.Page > 0 {
+		params["page"] = strconv.Itoa(options.Page)
+	}
+	if options.PerPage > 0 {
+		params["per_page"] = strconv.Itoa(options.PerPage)
+	}
+	url, err := common.BuildURL(url, params)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts, _, err := common.TypedGitHubRequest[common.ArtifactList](url, "GET", nil, apiReqs)
+	if err != nil {
+		return nil, fmt.Errorf("error listing artifacts: %w", err)
+	}
+	return &artifacts, nil
+}
+
+// DownloadArtifactOptions defines options for extracting an artifact
+type DownloadArtifactOptions struct {
+	Owner       string   `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo        string   `json:"repo" jsonschema:"description=The name of the repository"`
+	ArtifactID  int64    `json:"artifact_id" jsonschema:"description=The ID of the artifact as listed by list_artifacts"`
+	Files       []string `json:"files,omitempty" jsonschema:"description=Paths or glob patterns such as reports/*.txt of the files to return. Default: all text files except JUnit reports"`
+	MaxFileSize int      `json:"max_file_size,omitempty" jsonschema:"description=Maximum number of bytes returned per file. Default: 32768. Maximum: 262144"`
+}
+
+// Validate validates the DownloadArtifactOptions
+func (o *DownloadArtifactOptions) Validate() error {
+	if _, err := common.ValidateOwnerName(o.Owner); err != nil {
+		return err
+	}
+	if _, err := common.ValidateRepositoryName(o.Repo); err != nil {
+		return err
+	}
+	if o.ArtifactID <= 0 {
+		return fmt.Errorf("artifact_id is required")
+	}
+	for _, pattern := range o.Files {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid files pattern %q: %w", pattern, err)
+		}
+	}
+	if o.MaxFileSize < 0 || o.MaxFileSize > MAX_ARTIFACT_FILE_SIZE {
+		return fmt.Errorf("max_file_size must be between 0 and %d", MAX_ARTIFACT_FILE_SIZE)
+	}
+	return nil
+}
+
+// DownloadArtifact downloads an artifact and extracts it in memory. The result lists the
+// files of the archive, the text of the selected files and a summary of the JUnit reports.
+func DownloadArtifact(options *DownloadArtifactOptions, apiReqs *common.APIRequirements) (*common.ArtifactContents, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	url := common.APIURL("/repos/%s/%s/actions/artifacts/%d", options.Owner, options.Repo, options.ArtifactID)
+	artifact, _, err := common.TypedGitHubRequest[common.Artifact](url, "GET", nil, apiReqs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting artifact: %w", err)
+	}
+	if artifact.Expired {
+		return nil, fmt.Errorf("artifact %s has expired and can no longer be downloaded", artifact.Name)
+	}
+	if artifact.SizeInBytes > MAX_ARTIFACT_SIZE {
+		return nil, fmt.Errorf("artifact %s is %d bytes, artifacts over %d bytes are not extracted", artifact.Name, artifact.SizeInBytes, MAX_ARTIFACT_SIZE)
+	}
+
+	// Like job logs, the archive is served from a short-lived URL the API redirects to. It is
+	// read past the response cache, as an archive of up to MAX_ARTIFACT_SIZE is not worth keeping.
+	// The size is enforced on the download too, as it may not match the artifact metadata.
+	zipURL := common.APIURL("/repos/%s/%s/actions/artifacts/%d/zip", options.Owner, options.Repo, options.ArtifactID)
+	data, _, err := common.TypedGitHubRequest[[]byte](zipURL, "GET", nil, apiReqs, common.WithNoCache(), common.WithMaxSize(MAX_ARTIFACT_SIZE))
+	if errors.Is(err, common.ErrResponseTooLarge) {
+		return nil, fmt.Errorf("artifact %s is over %d bytes and is not extracted", artifact.Name, MAX_ARTIFACT_SIZE)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error downloading artifact: %w", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("error opening artifact archive: %w", err)
+	}
+
+	maxFileSize := DEFAULT_ARTIFACT_FILE_SIZE
+	if options.MaxFileSize > 0 {
+		maxFileSize = options.MaxFileSize
+	}
+
+	result := &common.ArtifactContents{
+		Artifact: artifact,
+		Files:    []common.ArtifactFile{},
+		Contents: []common.ArtifactFileContent{},
+	}
+	report := &common.TestReport{Files: []string{}, Suites: []common.TestSuite{}, Failures: []common.TestFailure{}}
+	contentSize := 0
+	junitSize := 0
+
+	files := make([]*zip.File, 0, len(archive.File))
+	for _, file := range archive.File {
+		if !file.FileInfo().IsDir() {
+			files = append(files, file)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	for _, file := range files {
+		if len(result.Files) < MAX_ARTIFACT_MANIFEST {
+			result.Files = append(result.Files, common.ArtifactFile{Path: file.Name, Size: int64(file.UncompressedSize64)})
+		} else {
+			result.Truncated = true
+		}
+
+		junit := strings.EqualFold(path.Ext(file.Name), ".xml") && file.UncompressedSize64 <= MAX_JUNIT_FILE_SIZE
+		if junit && uint64(junitSize)+file.UncompressedSize64 > MAX_JUNIT_TOTAL_SIZE {
+			report.UnparsedFiles = append(report.UnparsedFiles, file.Name)
+			junit = false
+		}
+		if junit {
+			// The recorded size may be wrong, the limit applies to the data actually read
+			content, truncated, err := readZipFile(file, min(MAX_JUNIT_FILE_SIZE, MAX_JUNIT_TOTAL_SIZE-junitSize))
+			if err != nil {
+				return nil, err
+			}
+			junitSize += len(content)
+			junit = !truncated && addJUnitReport(report, file.Name, content)
+		}
+
+		selected := !junit
+		if len(options.Files) > 0 {
+			selected = matchesAny(options.Files, file.Name)
+		}
+		if !selected {
+			continue
+		}
+		if contentSize >= MAX_ARTIFACT_CONTENT_SIZE {
+			result.Truncated = true
+			continue
+		}
+
+		content, truncated, err := readZipFile(file, min(maxFileSize, MAX_ARTIFACT_CONTENT_SIZE-contentSize))
+		if err != nil {
+			return nil, err
+		}
+		if truncated {
+			content = trimPartialRune(content)
+		}
+		if _, binary := detectContentType(content); binary {
+			continue
+		}
+		contentSize += len(content)
+		result.Contents = append(result.Contents, common.ArtifactFileContent{
+			Path:      file.Name,
+			Content:   string(content),
+			Truncated: truncated,
+		})
+		result.Truncated = result.Truncated || truncated
+	}
+
+	if len(report.Files) > 0 || len(report.UnparsedFiles) > 0 {
+		result.TestResults = report
+	}
+	return result, nil
+}
+
+// readZipFile reads up to limit bytes of an archive entry, reporting whether it was cut short.
+// The limit applies to the decompressed data, so a small entry cannot expand without bound.
+func readZipFile(file *zip.File, limit int) ([]byte, bool, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, false, fmt.Errorf("error extracting %s: %w", file.Name, err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, int64(limit)+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("error extracting %s: %w", file.Name, err)
+	}
+	if len(data) > limit {
+		return data[:limit], true, nil
+	}
+	return data, false, nil
+}
+
+// trimPartialRune drops a UTF-8 sequence cut off at the end of data
+func trimPartialRune(data []byte) []byte {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return data[:i]
+			}
+			break
+		}
+	}
+	return data
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if pattern == name {
+			return true
+		}
+		// Validate has already checked the patterns
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// junitSuite is a testsuite or testsuites element. Suites can be nested.
+type junitSuite struct {
+	XMLName xml.Name
+	Name    string       `xml:"name,attr"`
+	Time    string       `xml:"time,attr"`
+	Suites  []junitSuite `xml:"testsuite"`
+	Cases   []junitCase  `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string       `xml:"name,attr"`
+	ClassName string       `xml:"classname,attr"`
+	File      string       `xml:"file,attr"`
+	Failure   *junitResult `xml:"failure"`
+	Error     *junitResult `xml:"error"`
+	Skipped   *struct{}    `xml:"skipped"`
+	SystemOut string       `xml:"system-out"`
+}
+
+type junitResult struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// addJUnitReport adds the results of a JUnit XML report to report. It returns false when
+// the file is not a JUnit report. The counts are taken from the test cases rather than
+// the attributes of the suites, which not every tool writes.
+func addJUnitReport(report *common.TestReport, file string, content []byte) bool {
+	var root junitSuite
+	if err := xml.Unmarshal(content, &root); err != nil {
+		return false
+	}
+	if root.XMLName.Local != "testsuite" && root.XMLName.Local != "testsuites" {
+		return false
+	}
+
+	report.Files = append(report.Files, file)
+	var walk func(suite junitSuite)
+	walk = func(suite junitSuite) {
+		for _, child := range suite.Suites {
+			walk(child)
+		}
+		if suite.XMLName.Local != "testsuite" {
+			return
+		}
+
+		summary := common.TestSuite{Name: suite.Name, File: file, Tests: len(suite.Cases)}
+		summary.Time, _ = strconv.ParseFloat(suite.Time, 64)
+		for _, tc := range suite.Cases {
+			kind, result := "", tc.Failure
+			switch {
+			case tc.Failure != nil:
+				kind = "failure"
+				summary.Failed++
+			case tc.Error != nil:
+				kind, result = "error", tc.Error
+				summary.Errors++
+			case tc.Skipped != nil:
+				summary.Skipped++
+			}
+			if kind == "" {
+				continue
+			}
+			if len(report.Failures) == MAX_TEST_FAILURES {
+				report.Truncated = true
+				continue
+			}
+			details := strings.TrimSpace(result.Text)
+			if details == "" {
+				details = strings.TrimSpace(tc.SystemOut)
+			}
+			report.Failures = append(report.Failures, common.TestFailure{
+				Suite:     suite.Name,
+				ClassName: tc.ClassName,
+				Name:      tc.Name,
+				File:      tc.File,
+				Kind:      kind,
+				Type:      result.Type,
+				Message:   result.Message,
+				Details:   outputExcerpt(details),
+			})
+		}
+		if summary.Tests == 0 && len(suite.Suites) > 0 {
+			return
+		}
+
+		report.Suites = append(report.Suites, summary)
+		report.Tests += summary.Tests
+		report.Failed += summary.Failed
+		report.Errors += summary.Errors
+		report.Skipped += summary.Skipped
+		report.Passed += summary.Tests - summary.Failed - summary.Errors - summary.Skipped
+	}
+	walk(root)
+	return true
+}
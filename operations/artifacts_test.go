@@ -0,0 +1,262 @@
+package operations
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/metoro-io/github-mcp-server-go/common"
+)
+
+const testJUnitReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="api" tests="3" time="1.5">
+    <testcase classname="api.UsersTest" name="creates a user" time="0.1"/>
+    <testcase classname="api.UsersTest" name="deletes a user" file="api/users_test.py">
+      <failure message="expected 204 but got 500" type="AssertionError">Traceback (most recent call last):
+  File "api/users_test.py", line 12
+AssertionError: expected 204 but got 500</failure>
+    </testcase>
+    <testcase classname="api.UsersTest" name="lists users"><skipped/></testcase>
+  </testsuite>
+  <testsuite name="db">
+    <testcase classname="db.MigrationTest" name="migrates">
+      <error message="connection refused" type="OperationalError"/>
+      <system-out>connecting to localhost:5432</system-out>
+    </testcase>
+    <testcase classname="db.MigrationTest" name="rolls back"/>
+  </testsuite>
+</testsuites>`
+
+func testArtifactArchive(t *testing.T) []byte {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, file := range []struct {
+		name    string
+		content string
+	}{
+		{"summary.txt", "2 failed, 2 passed\n"},
+		{"reports/junit.xml", testJUnitReport},
+		{"reports/config.xml", "<config><retries>2</retries></config>"},
+		{"coverage/coverage-summary.json", `{"total":{"lines":{"pct":81.5}}}`},
+		{"screenshot.png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01"},
+		{"build.log", strings.Repeat("compiling\n", 4000)},
+	} {
+		w, err := archive.Create(file.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(file.content))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDownloadArtifact(t *testing.T) {
+	tests := []struct {
+		name         string
+		options      DownloadArtifactOptions
+		expired      bool
+		wantContents []string
+		wantErr      string
+	}{
+		{
+			name:         "text files and test results",
+			wantContents: []string{"build.log 32768 truncated", "coverage/coverage-summary.json 32", "reports/config.xml 37", "summary.txt 19"},
+		},
+		{
+			name:         "selected files",
+			options:      DownloadArtifactOptions{Files: []string{"reports/*.xml", "summary.txt"}},
+			wantContents: []string{"reports/config.xml 37", "reports/junit.xml " + fmt.Sprint(len(testJUnitReport)), "summary.txt 19"},
+		},
+		{
+			name:         "smaller files",
+			options:      DownloadArtifactOptions{Files: []string{"build.log", "screenshot.png"}, MaxFileSize: 100},
+			wantContents: []string{"build.log 100 truncated"},
+		},
+		{name: "expired artifact", expired: true, wantErr: "has expired"},
+		{name: "invalid pattern", options: DownloadArtifactOptions{Files: []string{"reports/["}}, wantErr: "invalid files pattern"},
+	}
+
+	archive := testArtifactArchive(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/repos/octo/repo/actions/artifacts/9":
+					fmt.Fprintf(w, `{"id":9,"name":"test-results","size_in_bytes":%d,"expired":%t}`, len(archive), tt.expired)
+				case "/repos/octo/repo/actions/artifacts/9/zip":
+					http.Redirect(w, r, "/archive/9.zip", http.StatusFound)
+				case "/archive/9.zip":
+					w.Header().Set("Content-Type", "application/zip")
+					w.Write(archive)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			defer common.Configure(common.DefaultClientOptions())
+			common.Configure(common.ClientOptions{BaseURL: server.URL, Token: "test"})
+
+			options := tt.options
+			options.Owner, options.Repo, options.ArtifactID = "octo", "repo", 9
+			result, err := DownloadArtifact(&options, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("DownloadArtifact() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DownloadArtifact() error = %v", err)
+			}
+
+			if len(result.Files) != 6 {
+				t.Errorf("manifest has %d files, want 6", len(result.Files))
+			}
+			var contents []string
+			for _, file := range result.Contents {
+				entry := fmt.Sprintf("%s %d", file.Path, len(file.Content))
+				if file.Truncated {
+					entry += " truncated"
+				}
+				contents = append(contents, entry)
+			}
+			if strings.Join(contents, ",") != strings.Join(tt.wantContents, ",") {
+				t.Errorf("contents = %v, want %v", contents, tt.wantContents)
+			}
+
+			report := result.TestResults
+			if report == nil {
+				t.Fatalf("TestResults = nil, want the JUnit summary")
+			}
+			if got := fmt.Sprintf("%d/%d/%d/%d/%d", report.Tests, report.Passed, report.Failed, report.Errors, report.Skipped); got != "5/2/1/1/1" {
+				t.Errorf("tests/passed/failed/errors/skipped = %s, want 5/2/1/1/1", got)
+			}
+			if len(report.Suites) != 2 || report.Suites[0].Name != "api" || report.Suites[0].Time != 1.5 {
+				t.Errorf("Suites = %+v", report.Suites)
+			}
+			if len(report.Failures) != 2 {
+				t.Fatalf("Failures = %+v, want 2", report.Failures)
+			}
+			failure, testError := report.Failures[0], report.Failures[1]
+			if failure.Kind != "failure" || failure.Name != "deletes a user" || failure.Message != "expected 204 but got 500" || !strings.HasSuffix(failure.Details, "AssertionError: expected 204 but got 500") {
+				t.Errorf("failure = %+v", failure)
+			}
+			if testError.Kind != "error" || testError.Type != "OperationalError" || testError.Details != "connecting to localhost:5432" {
+				t.Errorf("error = %+v", testError)
+			}
+		})
+	}
+}
+
+func TestAddJUnitReport(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantJUnit bool
+		wantTests int
+	}{
+		{name: "single suite", content: `<testsuite name="unit"><testcase name="a"/><testcase name="b"/></testsuite>`, wantJUnit: true, wantTests: 2},
+		{name: "nested suites", content: `<testsuites><testsuite name="outer"><testsuite name="inner"><testcase name="a"/></testsuite></testsuite></testsuites>`, wantJUnit: true, wantTests: 1},
+		{name: "other XML", content: `<project><name>app</name></project>`},
+		{name: "invalid XML", content: `<testsuite name="unit"><testcase`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &common.TestReport{}
+			if got := addJUnitReport(report, "report.xml", []byte(tt.content)); got != tt.wantJUnit {
+				t.Fatalf("addJUnitReport() = %v, want %v", got, tt.wantJUnit)
+			}
+			if report.Tests != tt.wantTests || report.Passed != tt.wantTests {
+				t.Errorf("tests = %d, passed = %d, want %d", report.Tests, report.Passed, tt.wantTests)
+			}
+		})
+	}
+}
+
+func TestDownloadArtifactIsNotCached(t *testing.T) {
+	archive := testArtifactArchive(t)
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octo/repo/actions/artifacts/9":
+			fmt.Fprintf(w, `{"id":9,"name":"test-results","size_in_bytes":%d}`, len(archive))
+		case "/repos/octo/repo/actions/artifacts/9/zip":
+			downloads++
+			w.Write(archive)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	defer common.Configure(common.DefaultClientOptions())
+	common.Configure(common.ClientOptions{
+		BaseURL: server.URL,
+		Token:   "test",
+		Cache:   common.CacheOptions{Enabled: true, TTL: time.Hour},
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := DownloadArtifact(&DownloadArtifactOptions{Owner: "octo", Repo: "repo", ArtifactID: 9}, nil); err != nil {
+			t.Fatalf("DownloadArtifact() error = %v", err)
+		}
+	}
+	if downloads != 2 {
+		t.Errorf("archive was downloaded %d times, want 2", downloads)
+	}
+}
+
+func TestDownloadArtifactJUnitBudget(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	// Each report is under MAX_JUNIT_FILE_SIZE, but the three together are over MAX_JUNIT_TOTAL_SIZE
+	padding := strings.Repeat(" ", MAX_JUNIT_TOTAL_SIZE/3)
+	for _, name := range []string{"a.xml", "b.xml", "c.xml"} {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(w, `<testsuite name="%s"><testcase name="ok"/>%s</testsuite>`, name, padding)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octo/repo/actions/artifacts/9":
+			fmt.Fprintf(w, `{"id":9,"name":"test-results","size_in_bytes":%d}`, buf.Len())
+		case "/repos/octo/repo/actions/artifacts/9/zip":
+			w.Write(buf.Bytes())
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	defer common.Configure(common.DefaultClientOptions())
+	common.Configure(common.ClientOptions{BaseURL: server.URL, Token: "test"})
+
+	result, err := DownloadArtifact(&DownloadArtifactOptions{Owner: "octo", Repo: "repo", ArtifactID: 9, Files: []string{"none"}}, nil)
+	if err != nil {
+		t.Fatalf("DownloadArtifact() error = %v", err)
+	}
+	report := result.TestResults
+	if report == nil || strings.Join(report.Files, ",") != "a.xml,b.xml" || strings.Join(report.UnparsedFiles, ",") != "c.xml" {
+		t.Fatalf("TestResults = %+v, want a.xml and b.xml parsed and c.xml unparsed", report)
+	}
+	if report.Tests != 2 {
+		t.Errorf("Tests = %d, want 2", report.Tests)
+	}
+}
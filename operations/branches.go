@@ -1,8 +1,8 @@
 package operations
 
 import (
-	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/metoro-io/github-mcp-server-go/common"
 )
@@ -12,7 +12,7 @@ type CreateBranchOptions struct {
 	Owner      string `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
 	Repo       string `json:"repo" jsonschema:"description=The name of the repository where the branch will be created"`
 	Branch     string `json:"branch" jsonschema:"description=The name of the new branch to create. Must follow Git branch naming rules (no spaces no .. sequences etc.)"`
-	FromBranch string `json:"from_branch" jsonschema:"description=The name of the source branch to create the new branch from. The new branch will start with the same commit history as this branch"`
+	FromBranch string `json:"from_branch" jsonschema:"description=The source branch tag or commit SHA to create the new branch from. The new branch will start with the same commit history as this ref"`
 }
 
 // Validate validates the CreateBranchOptions
@@ -35,54 +35,215 @@ func (o *CreateBranchOptions) Validate() error {
 // CreateBranchFromRef creates a new branch in a GitHub repository
 func CreateBranchFromRef(options *CreateBranchOptions, apiReqs *common.APIRequirements) (*common.GitHubBranch, error) {
 	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	// First resolve the source to the SHA of its commit
+	sha, err := resolveCommitSHA(options.Owner, options.Repo, options.FromBranch, apiReqs)
+	if err != nil {
 		return nil, err
 	}
 
-	// First get the source branch to get the SHA
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/branches/%s", options.Owner, options.Repo, options.FromBranch)
-	resp, err := common.GitHubRequest(url, "GET", nil, apiReqs)
+	// Now create the new branch as a reference
+	if err := createBranchRef(options.Owner, options.Repo, options.Branch, sha, apiReqs); err != nil {
+		return nil, err
+	}
+
+	// Verify branch was created
+	branchURL := common.APIURL("/repos/%s/%s/branches/%s", options.Owner, options.Repo, options.Branch)
+	newBranch, _, err := common.TypedGitHubRequest[common.GitHubBranch](branchURL, "GET", nil, apiReqs)
 	if err != nil {
-		return nil, fmt.Errorf("error getting source branch: %w", err)
+		return nil, fmt.Errorf("branch might have been created but verification failed: %w", err)
 	}
 
-	var sourceBranch common.GitHubBranch
-	jsonData, err := json.Marshal(resp)
+	return &newBranch, nil
+}
+
+// getBranchSHA returns the SHA of the commit a branch points to
+func getBranchSHA(owner string, repo string, branch string, apiReqs *common.APIRequirements) (string, error) {
+	url := common.APIURL("/repos/%s/%s/git/refs/heads/%s", owner, repo, branch)
+	ref, _, err := common.TypedGitHubRequest[common.GitHubRef](url, "GET", nil, apiReqs)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("error getting branch reference: %w", err)
 	}
+	if ref.Object.SHA == "" {
+		return "", fmt.Errorf("sha not found in response")
+	}
+	return ref.Object.SHA, nil
+}
 
-	if err := json.Unmarshal(jsonData, &sourceBranch); err != nil {
-		return nil, err
+// resolveCommitSHA returns the SHA of the commit a branch, tag or commit SHA points to
+func resolveCommitSHA(owner string, repo string, ref string, apiReqs *common.APIRequirements) (string, error) {
+	url := common.APIURL("/repos/%s/%s/commits/%s", owner, repo, ref)
+	commit, _, err := common.TypedGitHubRequest[common.GitHubCommit](url, "GET", nil, apiReqs)
+	if err != nil {
+		return "", fmt.Errorf("error resolving source ref %s: %w", ref, err)
 	}
+	if commit.SHA == "" {
+		return "", fmt.Errorf("sha not found in response")
+	}
+	return commit.SHA, nil
+}
 
-	// Now create the new branch as a reference
-	refURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/refs", options.Owner, options.Repo)
+// createBranchRef creates a branch pointing to the commit sha
+func createBranchRef(owner string, repo string, branch string, sha string, apiReqs *common.APIRequirements) error {
+	url := common.APIURL("/repos/%s/%s/git/refs", owner, repo)
 	refData := map[string]string{
-		"ref": fmt.Sprintf("refs/heads/%s", options.Branch),
-		"sha": sourceBranch.Commit.SHA,
+		"ref": fmt.Sprintf("refs/heads/%s", branch),
+		"sha": sha,
 	}
 
-	_, err = common.GitHubRequest(refURL, "POST", refData, apiReqs)
+	_, _, err := common.TypedGitHubRequest[common.GitHubRef](url, "POST", refData, apiReqs)
 	if err != nil {
-		return nil, fmt.Errorf("error creating branch: %w", err)
+		return fmt.Errorf("error creating branch: %w", err)
 	}
+	return nil
+}
 
-	// Verify branch was created
-	branchURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/branches/%s", options.Owner, options.Repo, options.Branch)
-	branchResp, err := common.GitHubRequest(branchURL, "GET", nil, apiReqs)
+// SquashBranchOptions defines the options for squashing the commits of a branch
+type SquashBranchOptions struct {
+	Owner           string          `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo            string          `json:"repo" jsonschema:"description=The name of the repository"`
+	Branch          string          `json:"branch" jsonschema:"description=The feature branch whose commits are squashed"`
+	Base            string          `json:"base" jsonschema:"description=The branch the feature branch is merged into such as main. All commits since the merge base with it are squashed"`
+	Message         string          `json:"message" jsonschema:"description=The message of the single commit that replaces them"`
+	ExpectedHeadSHA string          `json:"expected_head_sha,omitempty" jsonschema:"description=The commit SHA the branch is expected to point to. The branch is only rewritten while it still points there. Default: the head read at the start of the call"`
+	Author          *CommitterInfo  `json:"author,omitempty" jsonschema:"description=The author of the squashed commit. Default: the author of the oldest squashed commit"`
+	CoAuthors       []CommitterInfo `json:"co_authors,omitempty" jsonschema:"description=People credited with Co-authored-by trailers appended to the commit message"`
+}
+
+// Validate validates the SquashBranchOptions
+func (o *SquashBranchOptions) Validate() error {
+	if _, err := common.ValidateOwnerName(o.Owner); err != nil {
+		return err
+	}
+	if _, err := common.ValidateRepositoryName(o.Repo); err != nil {
+		return err
+	}
+	if _, err := common.ValidateBranchName(o.Branch); err != nil {
+		return err
+	}
+	if _, err := common.ValidateBranchName(o.Base); err != nil {
+		return fmt.Errorf("invalid base: %w", err)
+	}
+	if o.Branch == o.Base {
+		return fmt.Errorf("branch and base must differ")
+	}
+	if o.Message == "" {
+		return fmt.Errorf("commit message is required")
+	}
+	if o.ExpectedHeadSHA != "" && !commitSHAPattern.MatchString(o.ExpectedHeadSHA) {
+		return fmt.Errorf("expected_head_sha must be a full 40 character commit SHA")
+	}
+	return validateCommitMetadata(o.Author, nil, o.CoAuthors)
+}
+
+// SquashBranch replaces the commits of a branch since its merge base with base by a single
+// commit with the same tree, then force-updates the branch if it has not moved in the meantime
+func SquashBranch(options *SquashBranchOptions, apiReqs *common.APIRequirements) (*common.SquashBranchResult, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	headSHA, err := getBranchSHA(options.Owner, options.Repo, options.Branch, apiReqs)
 	if err != nil {
-		return nil, fmt.Errorf("branch might have been created but verification failed: %w", err)
+		return nil, err
+	}
+	ref := "refs/heads/" + options.Branch
+	if options.ExpectedHeadSHA != "" && headSHA != options.ExpectedHeadSHA {
+		return nil, &common.ConflictError{
+			Ref:         ref,
+			ExpectedSHA: options.ExpectedHeadSHA,
+			ActualSHA:   headSHA,
+			Reason:      "review the new commits before squashing",
+		}
+	}
+
+	comparison, err := compareCommits(options.Owner, options.Repo, options.Base, headSHA, apiReqs)
+	if err != nil {
+		return nil, err
+	}
+	if comparison.AheadBy == 0 {
+		return nil, fmt.Errorf("branch %s has no commits that are not on %s", options.Branch, options.Base)
 	}
 
-	var newBranch common.GitHubBranch
-	jsonData, err = json.Marshal(branchResp)
+	// Like an interactive rebase, the squashed commit keeps the author of the first commit
+	author := options.Author
+	if author == nil && len(comparison.Commits) > 0 {
+		first := comparison.Commits[0].Commit.Author
+		author = &CommitterInfo{Name: first.Name, Email: first.Email, Date: first.Date.UTC().Format(time.RFC3339)}
+	}
+
+	url := common.APIURL("/repos/%s/%s/git/commits/%s", options.Owner, options.Repo, headSHA)
+	head, _, err := common.TypedGitHubRequest[common.GitCommit](url, "GET", nil, apiReqs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting commit: %w", err)
+	}
+
+	message := addCoAuthorTrailers(options.Message, options.CoAuthors)
+	newCommit, err := createCommit(options.Owner, options.Repo, message, head.Tree.SHA, comparison.MergeBaseCommit.SHA, author, nil, apiReqs)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := json.Unmarshal(jsonData, &newBranch); err != nil {
+	if err := swapBranchHead(options.Owner, options.Repo, options.Branch, headSHA, newCommit.SHA, apiReqs); err != nil {
 		return nil, err
 	}
 
-	return &newBranch, nil
+	return &common.SquashBranchResult{
+		Commit:          *newCommit,
+		PreviousHeadSHA: headSHA,
+		MergeBaseSHA:    comparison.MergeBaseCommit.SHA,
+		SquashedCommits: comparison.AheadBy,
+	}, nil
+}
+
+const repositoryIDQuery = `query($owner: String!, $name: String!) {
+  repository(owner: $owner, name: $name) { id }
+}`
+
+const updateRefsMutation = `mutation($repositoryId: ID!, $refUpdates: [RefUpdate!]!) {
+  updateRefs(input: {repositoryId: $repositoryId, refUpdates: $refUpdates}) { clientMutationId }
+}`
+
+// swapBranchHead force-updates a branch from beforeSHA to afterSHA. The GraphQL updateRefs
+// mutation only moves the branch while it still points at beforeSHA, so unlike a forced
+// REST update it never overwrites a push that landed in the meantime.
+func swapBranchHead(owner string, repo string, branch string, beforeSHA string, afterSHA string, apiReqs *common.APIRequirements) error {
+	// owner and name identify the repository to the allowed repositories policy
+	variables := map[string]interface{}{"owner": owner, "name": repo}
+	resp, err := common.GraphQLRequest(repositoryIDQuery, variables, apiReqs)
+	if err != nil {
+		return fmt.Errorf("error getting repository: %w", err)
+	}
+	var data struct {
+		Repository struct {
+			ID string `json:"id"`
+		} `json:"repository"`
+	}
+	if err := resp.Decode(&data); err != nil {
+		return fmt.Errorf("error getting repository: %w", err)
+	}
+
+	ref := "refs/heads/" + branch
+	variables["repositoryId"] = data.Repository.ID
+	variables["refUpdates"] = []map[string]interface{}{{
+		"name":      ref,
+		"beforeOid": beforeSHA,
+		"afterOid":  afterSHA,
+		"force":     true,
+	}}
+	if _, err := common.GraphQLRequest(updateRefsMutation, variables, apiReqs); err != nil {
+		// The mutation fails when the branch no longer points at beforeSHA
+		if currentSHA, readErr := getBranchSHA(owner, repo, branch, apiReqs); readErr == nil && currentSHA != beforeSHA {
+			return &common.ConflictError{
+				Ref:         ref,
+				ExpectedSHA: beforeSHA,
+				ActualSHA:   currentSHA,
+				Reason:      "the branch moved while it was being rewritten",
+			}
+		}
+		return fmt.Errorf("error updating reference: %w", err)
+	}
+	return nil
 }
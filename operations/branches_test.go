@@ -0,0 +1,167 @@
+package operations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/metoro-io/github-mcp-server-go/common"
+)
+
+func TestSquashBranch(t *testing.T) {
+	head := strings.Repeat("a", 40)
+	moved := strings.Repeat("b", 40)
+
+	tests := []struct {
+		name         string
+		options      SquashBranchOptions
+		compare      string
+		headAfter    string
+		wantAuthor   string
+		wantReason   string
+		wantErr      string
+		wantValidate bool
+	}{
+		{
+			name:       "squash onto the merge base",
+			compare:    `{"ahead_by":3,"merge_base_commit":{"sha":"base"},"commits":[{"sha":"c1","commit":{"author":{"name":"Mona","email":"mona@example.com","date":"2024-01-02T03:04:05Z"}}}]}`,
+			wantAuthor: "Mona 2024-01-02T03:04:05Z",
+		},
+		{
+			name:       "author override",
+			options:    SquashBranchOptions{Author: &CommitterInfo{Name: "Hubot", Email: "hubot@example.com"}},
+			compare:    `{"ahead_by":3,"merge_base_commit":{"sha":"base"},"commits":[{"sha":"c1","commit":{"author":{"name":"Mona","email":"mona@example.com"}}}]}`,
+			wantAuthor: "Hubot ",
+		},
+		{
+			name:       "expected head does not match",
+			options:    SquashBranchOptions{ExpectedHeadSHA: moved},
+			wantReason: "review the new commits",
+		},
+		{
+			name:       "branch moves during the squash",
+			compare:    `{"ahead_by":3,"merge_base_commit":{"sha":"base"},"commits":[]}`,
+			headAfter:  moved,
+			wantReason: "moved while",
+		},
+		{
+			name:    "nothing to squash",
+			compare: `{"ahead_by":0,"merge_base_commit":{"sha":"base"},"commits":[]}`,
+			wantErr: "no commits",
+		},
+		{
+			name:         "same branch and base",
+			options:      SquashBranchOptions{Base: "feature"},
+			wantValidate: true,
+			wantErr:      "must differ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refReads := 0
+			var (
+				created struct {
+					Tree    string         `json:"tree"`
+					Parents []string       `json:"parents"`
+					Author  *CommitterInfo `json:"author"`
+				}
+				update map[string]interface{}
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/repos/octo/repo/git/refs/heads/feature" && r.Method == "GET":
+					sha := head
+					if refReads++; refReads > 1 && tt.headAfter != "" {
+						sha = tt.headAfter
+					}
+					fmt.Fprintf(w, `{"ref":"refs/heads/feature","object":{"sha":%q}}`, sha)
+				case r.URL.Path == "/graphql":
+					var body struct {
+						Query     string `json:"query"`
+						Variables struct {
+							RepositoryID string                   `json:"repositoryId"`
+							RefUpdates   []map[string]interface{} `json:"refUpdates"`
+						} `json:"variables"`
+					}
+					json.NewDecoder(r.Body).Decode(&body)
+					switch {
+					case strings.Contains(body.Query, "repository("):
+						fmt.Fprint(w, `{"data":{"repository":{"id":"R_1"}}}`)
+					case tt.headAfter != "":
+						// The branch no longer points at beforeOid
+						fmt.Fprint(w, `{"data":null,"errors":[{"type":"UNPROCESSABLE","message":"A ref update failed"}]}`)
+					case body.Variables.RepositoryID == "R_1" && len(body.Variables.RefUpdates) == 1:
+						update = body.Variables.RefUpdates[0]
+						fmt.Fprint(w, `{"data":{"updateRefs":{"clientMutationId":null}}}`)
+					default:
+						w.WriteHeader(http.StatusBadRequest)
+					}
+				case r.URL.Path == "/repos/octo/repo/compare/main..."+head:
+					fmt.Fprint(w, tt.compare)
+				case r.URL.Path == "/repos/octo/repo/git/commits/"+head:
+					fmt.Fprintf(w, `{"sha":%q,"tree":{"sha":"headtree"}}`, head)
+				case r.URL.Path == "/repos/octo/repo/git/commits" && r.Method == "POST":
+					json.NewDecoder(r.Body).Decode(&created)
+					fmt.Fprint(w, `{"sha":"squashed"}`)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			defer common.Configure(common.DefaultClientOptions())
+			common.Configure(common.ClientOptions{BaseURL: server.URL, Token: "test"})
+
+			options := tt.options
+			options.Owner, options.Repo, options.Branch, options.Message = "octo", "repo", "feature", "Add feature"
+			if options.Base == "" {
+				options.Base = "main"
+			}
+
+			result, err := SquashBranch(&options, nil)
+			if tt.wantReason != "" {
+				var conflict *common.ConflictError
+				if !errors.As(err, &conflict) {
+					t.Fatalf("SquashBranch() error = %v, want a ConflictError", err)
+				}
+				if !strings.Contains(conflict.Reason, tt.wantReason) {
+					t.Errorf("Reason = %q, should contain %q", conflict.Reason, tt.wantReason)
+				}
+				if update != nil {
+					t.Errorf("the branch was updated despite the conflict")
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SquashBranch() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if tt.wantValidate && refReads > 0 {
+					t.Errorf("the branch was read before the options were validated")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SquashBranch() error = %v", err)
+			}
+
+			if created.Tree != "headtree" || strings.Join(created.Parents, ",") != "base" {
+				t.Errorf("created commit of tree %s with parents %v, want headtree with parent base", created.Tree, created.Parents)
+			}
+			if author := created.Author.Name + " " + created.Author.Date; author != tt.wantAuthor {
+				t.Errorf("author = %q, want %q", author, tt.wantAuthor)
+			}
+			if update["name"] != "refs/heads/feature" || update["beforeOid"] != head || update["afterOid"] != "squashed" || update["force"] != true {
+				t.Errorf("ref update = %v, want a forced update from the head to squashed", update)
+			}
+			if result.PreviousHeadSHA != head || result.MergeBaseSHA != "base" || result.SquashedCommits != 3 {
+				t.Errorf("result = %+v", result)
+			}
+		})
+	}
+}
@@ -0,0 +1,694 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/metoro-io/github-mcp-server-go/common"
+)
+
+const (
+	// MAX_CHECK_PAGES bounds the pages of 100 check runs or suites read for one commit
+	MAX_CHECK_PAGES = 5
+	// MAX_ANNOTATIONS_PER_REQUEST is the number of annotations GitHub accepts in one request
+	MAX_ANNOTATIONS_PER_REQUEST = 50
+	// DEFAULT_MAX_ANNOTATIONS is the number of annotations returned per check run by default
+	DEFAULT_MAX_ANNOTATIONS = 50
+)
+
+// GetRefStatusOptions defines options for getting the CI status of a commit
+type GetRefStatusOptions struct {
+	Owner              string `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo               string `json:"repo" jsonschema:"description=The name of the repository"`
+	Ref                string `json:"ref" jsonschema:"description=The commit SHA or a branch or tag name whose latest commit is checked"`
+	IncludeAnnotations bool   `json:"include_annotations,omitempty" jsonschema:"description=Include the annotations of check runs that have any. Default: false"`
+	MaxAnnotations     int    `json:"max_annotations,omitempty" jsonschema:"description=Maximum number of annotations returned per check run. Default: 50. Maximum: 100"`
+}
+
+// Validate validates the GetRefStatusOptions
+func (o *GetRefStatusOptions) Validate() error {
+	if _, err := common.ValidateOwnerName(o.Owner); err != nil {
+		return err
+	}
+	if _, err := common.ValidateRepositoryName(o.Repo); err != nil {
+		return err
+	}
+	if _, err := common.ValidateBranchName(o.Ref); err != nil {
+		return fmt.Errorf("invalid ref: %w", err)
+	}
+	if o.MaxAnnotations < 0 || o.MaxAnnotations > 100 {
+		return fmt.Errorf("max_annotations must be between 0 and 100")
+	}
+	return nil
+}
+
+// GetRefStatus gets the combined commit status, check runs and check suites of a commit
+func GetRefStatus(options *GetRefStatusOptions, apiReqs *common.APIRequirements) (*common.RefStatus, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	status, err := getRefStatus(context.Background(), options.Owner, options.Repo, options.Ref, apiReqs)
+	if err != nil {
+		return nil, err
+	}
+
+	suitesURL := common.APIURL("/repos/%s/%s/commits/%s/check-suites?per_page=100", options.Owner, options.Repo, status.SHA)
+	for page := 0; suitesURL != "" && page < MAX_CHECK_PAGES; page++ {
+		suites, resp, err := common.TypedGitHubRequest[common.CheckSuiteList](suitesURL, "GET", nil, apiReqs)
+		if err != nil {
+			return nil, fmt.Errorf("error listing check suites: %w", err)
+		}
+		status.CheckSuites = append(status.CheckSuites, suites.CheckSuites...)
+		suitesURL = resp.NextPageURL()
+	}
+
+	if options.IncludeAnnotations {
+		maxAnnotations := options.MaxAnnotations
+		if maxAnnotations == 0 {
+			maxAnnotations = DEFAULT_MAX_ANNOTATIONS
+		}
+		for i := range status.CheckRuns {
+			run := &status.CheckRuns[i]
+			if run.Output.AnnotationsCount == 0 {
+				continue
+			}
+			url := common.APIURL("/repos/%s/%s/check-runs/%d/annotations?per_page=%d", options.Owner, options.Repo, run.ID, maxAnnotations)
+			annotations, _, err := common.TypedGitHubRequest[[]common.CheckAnnotation](url, "GET", nil, apiReqs)
+			if err != nil {
+				return nil, fmt.Errorf("error listing annotations of check run %s: %w", run.Name, err)
+			}
+			run.Annotations = annotations
+		}
+	}
+	return status, nil
+}
+
+// getRefStatus reads the commit statuses and check runs of a ref and combines their state.
+// They are read past the response cache, as wait_for_checks polls them for changes, and are
+// abandoned when ctx is cancelled.
+func getRefStatus(ctx context.Context, owner string, repo string, ref string, apiReqs *common.APIRequirements) (*common.RefStatus, error) {
+	url := common.APIURL("/repos/%s/%s/commits/%s/status?per_page=100", owner, repo, ref)
+	combined, _, err := common.TypedGitHubRequest[common.CombinedStatus](url, "GET", nil, apiReqs, common.WithNoCache(), common.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting commit status: %w", err)
+	}
+
+	status := &common.RefStatus{
+		Ref:         ref,
+		SHA:         combined.SHA,
+		Statuses:    combined.Statuses,
+		CheckRuns:   []common.CheckRun{},
+		CheckSuites: []common.CheckSuite{},
+	}
+	if status.Statuses == nil {
+		status.Statuses = []common.CommitStatus{}
+	}
+
+	runsURL := common.APIURL("/repos/%s/%s/commits/%s/check-runs?per_page=100", owner, repo, combined.SHA)
+	for page := 0; runsURL != "" && page < MAX_CHECK_PAGES; page++ {
+		runs, resp, err := common.TypedGitHubRequest[common.CheckRunList](runsURL, "GET", nil, apiReqs, common.WithNoCache(), common.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("error listing check runs: %w", err)
+		}
+		status.CheckRuns = append(status.CheckRuns, runs.CheckRuns...)
+		runsURL = resp.NextPageURL()
+	}
+
+	status.State = combinedCheckState(status.Statuses, status.CheckRuns)
+	return status, nil
+}
+
+// combinedCheckState is failure if any status or check run failed, pending while any is
+// still running, success once all passed and none if nothing was reported
+func combinedCheckState(statuses []common.CommitStatus, runs []common.CheckRun) string {
+	if len(statuses) == 0 && len(runs) == 0 {
+		return common.CHECK_STATE_NONE
+	}
+	state := common.CHECK_STATE_SUCCESS
+	for _, status := range statuses {
+		switch status.State {
+		case "failure", "error":
+			return common.CHECK_STATE_FAILURE
+		case "pending":
+			state = common.CHECK_STATE_PENDING
+		}
+	}
+	for _, run := range runs {
+		if run.Status != "completed" {
+			state = common.CHECK_STATE_PENDING
+		} else if checkRunFailed(run.Conclusion) {
+			return common.CHECK_STATE_FAILURE
+		}
+	}
+	return state
+}
+
+// checkRunFailed reports whether a check run conclusion should block a merge
+func checkRunFailed(conclusion string) bool {
+	switch conclusion {
+	case "failure", "timed_out", "cancelled", "action_required", "startup_failure":
+		return true
+	}
+	return false
+}
+
+// CheckAnnotationInput is an annotation to attach to lines of a file
+type CheckAnnotationInput struct {
+	Path            string `json:"path" jsonschema:"description=The path of the file relative to the repository root"`
+	StartLine       int    `json:"start_line" jsonschema:"description=The first line of the annotation"`
+	EndLine         int    `json:"end_line,omitempty" jsonschema:"description=The last line of the annotation. Default: start_line"`
+	StartColumn     int    `json:"start_column,omitempty" jsonschema:"description=The first column of the annotation. Only allowed when start_line and end_line are the same"`
+	EndColumn       int    `json:"end_column,omitempty" jsonschema:"description=The last column of the annotation. Only allowed when start_line and end_line are the same"`
+	AnnotationLevel string `json:"annotation_level" jsonschema:"description=The severity of the annotation. Can be one of: notice warning failure"`
+	Message         string `json:"message" jsonschema:"description=A short description of the problem"`
+	Title           string `json:"title,omitempty" jsonschema:"description=The title of the annotation"`
+	RawDetails      string `json:"raw_details,omitempty" jsonschema:"description=Details such as the raw output of the linter"`
+}
+
+func (a *CheckAnnotationInput) validate() error {
+	if a.Path == "" {
+		return fmt.Errorf("path is required")
+	}
+	if a.StartLine < 1 {
+		return fmt.Errorf("start_line must be at least 1")
+	}
+	if a.EndLine != 0 && a.EndLine < a.StartLine {
+		return fmt.Errorf("end_line must not be before start_line")
+	}
+	if (a.StartColumn != 0 || a.EndColumn != 0) && a.EndLine != 0 && a.EndLine != a.StartLine {
+		return fmt.Errorf("start_column and end_column are only allowed when the annotation spans one line")
+	}
+	switch a.AnnotationLevel {
+	case "notice", "warning", "failure":
+	default:
+		return fmt.Errorf("annotation_level must be one of: notice, warning, failure")
+	}
+	if a.Message == "" {
+		return fmt.Errorf("message is required")
+	}
+	return nil
+}
+
+// body returns the annotation as GitHub expects it, with end_line defaulting to start_line
+func (a *CheckAnnotationInput) body() map[string]interface{} {
+	endLine := a.EndLine
+	if endLine == 0 {
+		endLine = a.StartLine
+	}
+	body := map[string]interface{}{
+		"path":             a.Path,
+		"start_line":       a.StartLine,
+		"end_line":         endLine,
+		"annotation_level": a.AnnotationLevel,
+		"message":          a.Message,
+	}
+	if a.StartColumn != 0 {
+		body["start_column"] = a.StartColumn
+	}
+	if a.EndColumn != 0 {
+		body["end_column"] = a.EndColumn
+	}
+	if a.Title != "" {
+		body["title"] = a.Title
+	}
+	if a.RawDetails != "" {
+		body["raw_details"] = a.RawDetails
+	}
+	return body
+}
+
+// CreateCheckRunOptions defines options for creating a check run
+type CreateCheckRunOptions struct {
+	Owner       string                 `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo        string                 `json:"repo" jsonschema:"description=The name of the repository"`
+	Name        string                 `json:"name" jsonschema:"description=The name of the check such as lint"`
+	HeadSHA     string                 `json:"head_sha" jsonschema:"description=The full SHA of the commit the check run is for"`
+	Status      string                 `json:"status,omitempty" jsonschema:"description=The status of the check run. Can be one of: queued in_progress completed. Default: queued or completed when conclusion is set"`
+	Conclusion  string                 `json:"conclusion,omitempty" jsonschema:"description=The result of a completed check run. Can be one of: action_required cancelled failure neutral success skipped timed_out"`
+	DetailsURL  string                 `json:"details_url,omitempty" jsonschema:"description=The URL with the full details of the check"`
+	ExternalID  string                 `json:"external_id,omitempty" jsonschema:"description=An identifier of the check run in the system that runs it"`
+	Title       string                 `json:"title,omitempty" jsonschema:"description=The title of the check run output. Required with summary text or annotations"`
+	Summary     string                 `json:"summary,omitempty" jsonschema:"description=The summary of the check run output in Markdown. Required with title text or annotations"`
+	Text        string                 `json:"text,omitempty" jsonschema:"description=The details of the check run output in Markdown"`
+	Annotations []CheckAnnotationInput `json:"annotations,omitempty" jsonschema:"description=Annotations to attach to lines of files. More than 50 are sent in several requests"`
+}
+
+// Validate validates the CreateCheckRunOptions
+func (o *CreateCheckRunOptions) Validate() error {
+	if _, err := common.ValidateOwnerName(o.Owner); err != nil {
+		return err
+	}
+	if _, err := common.ValidateRepositoryName(o.Repo); err != nil {
+		return err
+	}
+	if o.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !commitSHAPattern.MatchString(o.HeadSHA) {
+		return fmt.Errorf("head_sha must be a full 40 character commit SHA")
+	}
+	return validateCheckRunFields(o.Status, o.Conclusion, o.Title, o.Summary, o.Text, o.Annotations)
+}
+
+// UpdateCheckRunOptions defines options for updating a check run
+type UpdateCheckRunOptions struct {
+	Owner       string                 `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo        string                 `json:"repo" jsonschema:"description=The name of the repository"`
+	CheckRunID  int64                  `json:"check_run_id" jsonschema:"description=The ID of the check run"`
+	Name        string                 `json:"name,omitempty" jsonschema:"description=A new name for the check"`
+	Status      string                 `json:"status,omitempty" jsonschema:"description=The status of the check run. Can be one of: queued in_progress completed"`
+	Conclusion  string                 `json:"conclusion,omitempty" jsonschema:"description=The result of a completed check run. Can be one of: action_required cancelled failure neutral success skipped timed_out"`
+	DetailsURL  string                 `json:"details_url,omitempty" jsonschema:"description=The URL with the full details of the check"`
+	ExternalID  string                 `json:"external_id,omitempty" jsonschema:"description=An identifier of the check run in the system that runs it"`
+	Title       string                 `json:"title,omitempty" jsonschema:"description=The title of the check run output. Required with summary text or annotations"`
+	Summary     string                 `json:"summary,omitempty" jsonschema:"description=The summary of the check run output in Markdown. Required with title text or annotations"`
+	Text        string                 `json:"text,omitempty" jsonschema:"description=The details of the check run output in Markdown"`
+	Annotations []CheckAnnotationInput `json:"annotations,omitempty" jsonschema:"description=Annotations to add to the check run. They are appended to existing annotations"`
+}
+
+// Validate validates the UpdateCheckRunOptions
+func (o *UpdateCheckRunOptions) Validate() error {
+	if _, err := common.ValidateOwnerName(o.Owner); err != nil {
+		return err
+	}
+	if _, err := common.ValidateRepositoryName(o.Repo); err != nil {
+		return err
+	}
+	if o.CheckRunID <= 0 {
+		return fmt.Errorf("check_run_id is required")
+	}
+	return validateCheckRunFields(o.Status, o.Conclusion, o.Title, o.Summary, o.Text, o.Annotations)
+}
+
+func validateCheckRunFields(status string, conclusion string, title string, summary string, text string, annotations []CheckAnnotationInput) error {
+	switch status {
+	case "", "queued", "in_progress", "completed":
+	default:
+		return fmt.Errorf("status must be one of: queued, in_progress, completed")
+	}
+	switch conclusion {
+	case "":
+		if status == "completed" {
+			return fmt.Errorf("conclusion is required when status is completed")
+		}
+	case "action_required", "cancelled", "failure", "neutral", "success", "skipped", "timed_out":
+		if status != "" && status != "completed" {
+			return fmt.Errorf("conclusion can only be set when status is completed")
+		}
+	default:
+		return fmt.Errorf("conclusion must be one of: action_required, cancelled, failure, neutral, success, skipped, timed_out")
+	}
+
+	if (title == "") != (summary == "") || (title == "" && (text != "" || len(annotations) > 0)) {
+		return fmt.Errorf("title and summary are required together, and with text or annotations")
+	}
+	for i, annotation := range annotations {
+		if err := annotation.validate(); err != nil {
+			return fmt.Errorf("invalid annotation at index %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// CreateCheckRun creates a check run, for example to report the findings of a linter as annotations
+func CreateCheckRun(options *CreateCheckRunOptions, apiReqs *common.APIRequirements) (*common.CheckRun, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	body := checkRunBody(options.Name, options.Status, options.Conclusion, options.DetailsURL, options.ExternalID)
+	body["head_sha"] = options.HeadSHA
+	url := common.APIURL("/repos/%s/%s/check-runs", options.Owner, options.Repo)
+	run, err := writeCheckRun(options.Owner, options.Repo, url, "POST", body, options.Title, options.Summary, options.Text, options.Annotations, apiReqs)
+	if err != nil {
+		return nil, fmt.Errorf("error creating check run: %w", err)
+	}
+	return run, nil
+}
+
+// UpdateCheckRun updates the status, conclusion or output of a check run
+func UpdateCheckRun(options *UpdateCheckRunOptions, apiReqs *common.APIRequirements) (*common.CheckRun, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	body := checkRunBody(options.Name, options.Status, options.Conclusion, options.DetailsURL, options.ExternalID)
+	url := common.APIURL("/repos/%s/%s/check-runs/%d", options.Owner, options.Repo, options.CheckRunID)
+	run, err := writeCheckRun(options.Owner, options.Repo, url, "PATCH", body, options.Title, options.Summary, options.Text, options.Annotations, apiReqs)
+	if err != nil {
+		return nil, fmt.Errorf("error updating check run: %w", err)
+	}
+	return run, nil
+}
+
+func checkRunBody(name string, status string, conclusion string, detailsURL string, externalID string) map[string]interface{} {
+	body := map[string]interface{}{}
+	for key, value := range map[string]string{
+		"name":        name,
+		"status":      status,
+		"conclusion":  conclusion,
+		"details_url": detailsURL,
+		"external_id": externalID,
+	} {
+		if value != "" {
+			body[key] = value
+		}
+	}
+	return body
+}
+
+// writeCheckRun sends a check run with its output. GitHub takes at most
+// MAX_ANNOTATIONS_PER_REQUEST annotations per request, so the rest are
+// appended with further updates.
+func writeCheckRun(owner string, repo string, url string, method string, body map[string]interface{},
+	title string, summary string, text string, annotations []CheckAnnotationInput, apiReqs *common.APIRequirements) (*common.CheckRun, error) {
+	output := func(batch []CheckAnnotationInput) map[string]interface{} {
+		out := map[string]interface{}{
+			"title":   title,
+			"summary": summary,
+		}
+		if text != "" {
+			out["text"] = text
+		}
+		if len(batch) > 0 {
+			items := make([]map[string]interface{}, 0, len(batch))
+			for i := range batch {
+				items = append(items, batch[i].body())
+			}
+			out["annotations"] = items
+		}
+		return out
+	}
+
+	batch := annotations
+	if len(batch) > MAX_ANNOTATIONS_PER_REQUEST {
+		batch = batch[:MAX_ANNOTATIONS_PER_REQUEST]
+	}
+	if title != "" {
+		body["output"] = output(batch)
+	}
+	run, _, err := common.TypedGitHubRequest[common.CheckRun](url, method, body, apiReqs)
+	if err != nil {
+		return nil, err
+	}
+
+	for sent := len(batch); sent < len(annotations); sent += len(batch) {
+		batch = annotations[sent:]
+		if len(batch) > MAX_ANNOTATIONS_PER_REQUEST {
+			batch = batch[:MAX_ANNOTATIONS_PER_REQUEST]
+		}
+		updateURL := common.APIURL("/repos/%s/%s/check-runs/%d", owner, repo, run.ID)
+		run, _, err = common.TypedGitHubRequest[common.CheckRun](updateURL, "PATCH", map[string]interface{}{"output": output(batch)}, apiReqs)
+		if err != nil {
+			return nil, fmt.Errorf("error adding annotations %d to %d: %w", sent+1, sent+len(batch), err)
+		}
+	}
+	return &run, nil
+}
+
+const (
+	// DEFAULT_WAIT_TIMEOUT_SECONDS is how long wait_for_checks waits by default
+	DEFAULT_WAIT_TIMEOUT_SECONDS = 600
+	// MAX_WAIT_TIMEOUT_SECONDS bounds how long a single call may wait
+	MAX_WAIT_TIMEOUT_SECONDS = 3600
+	// MAX_EXCERPT_LINES is the number of trailing output lines kept for a failed check
+	MAX_EXCERPT_LINES = 20
+	// MAX_EXCERPT_SIZE is the number of bytes kept for a failed check
+	MAX_EXCERPT_SIZE = 2000
+	// MAX_FAILURE_ANNOTATIONS is the number of failure annotations kept for a failed check
+	MAX_FAILURE_ANNOTATIONS = 5
+)
+
+// Polling intervals of WaitForChecks. The interval doubles after each poll up to the maximum.
+var (
+	waitInitialInterval = 5 * time.Second
+	waitMaxInterval     = 60 * time.Second
+	// waitNoChecksGrace is how long to wait for CI to report anything before giving up
+	waitNoChecksGrace = 60 * time.Second
+)
+
+// WaitForChecksOptions defines options for waiting until the checks of a commit complete
+type WaitForChecksOptions struct {
+	Owner          string   `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo           string   `json:"repo" jsonschema:"description=The name of the repository"`
+	Ref            string   `json:"ref,omitempty" jsonschema:"description=The commit SHA or branch to wait for. Required unless pull_number is set"`
+	PullNumber     int      `json:"pull_number,omitempty" jsonschema:"description=Wait for the head commit of this pull request instead of ref"`
+	Checks         []string `json:"checks,omitempty" jsonschema:"description=Names of the required check runs or status contexts. Default: every reported check"`
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty" jsonschema:"description=How long to wait in seconds. Default: 600. Maximum: 3600"`
+	FailFast       bool     `json:"fail_fast,omitempty" jsonschema:"description=Return as soon as a required check fails instead of waiting for the others. Default: false"`
+
+	// Progress is called after each poll. It is set by the server and not part of the tool arguments.
+	Progress func(progress common.CheckWaitProgress) `json:"-"`
+}
+
+// Validate validates the WaitForChecksOptions
+func (o *WaitForChecksOptions) Validate() error {
+	if _, err := common.ValidateOwnerName(o.Owner); err != nil {
+		return err
+	}
+	if _, err := common.ValidateRepositoryName(o.Repo); err != nil {
+		return err
+	}
+	switch {
+	case o.Ref == "" && o.PullNumber == 0:
+		return fmt.Errorf("either ref or pull_number is required")
+	case o.Ref != "" && o.PullNumber != 0:
+		return fmt.Errorf("ref and pull_number cannot be combined")
+	case o.PullNumber < 0:
+		return fmt.Errorf("pull_number must be positive")
+	}
+	if o.Ref != "" {
+		if _, err := common.ValidateBranchName(o.Ref); err != nil {
+			return fmt.Errorf("invalid ref: %w", err)
+		}
+	}
+	if o.TimeoutSeconds < 0 || o.TimeoutSeconds > MAX_WAIT_TIMEOUT_SECONDS {
+		return fmt.Errorf("timeout_seconds must be between 0 and %d", MAX_WAIT_TIMEOUT_SECONDS)
+	}
+	return nil
+}
+
+// WaitForChecks polls the commit statuses and check runs of a commit with a doubling interval
+// until every required check completed, one failed with FailFast, or the timeout passes
+func WaitForChecks(ctx context.Context, options *WaitForChecksOptions, apiReqs *common.APIRequirements) (*common.CheckWaitResult, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	ref := options.Ref
+	if options.PullNumber != 0 {
+		url := common.APIURL("/repos/%s/%s/pulls/%d", options.Owner, options.Repo, options.PullNumber)
+		pr, _, err := common.TypedGitHubRequest[common.GitHubPullRequest](url, "GET", nil, apiReqs, common.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("error getting pull request: %w", err)
+		}
+		ref = pr.Head.SHA
+	}
+
+	timeout := time.Duration(options.TimeoutSeconds) * time.Second
+	if timeout == 0 {
+		timeout = DEFAULT_WAIT_TIMEOUT_SECONDS * time.Second
+	}
+	start := time.Now()
+	deadline := start.Add(timeout)
+	interval := waitInitialInterval
+
+	for poll := 1; ; poll++ {
+		status, err := getRefStatus(ctx, options.Owner, options.Repo, ref, apiReqs)
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("stopped waiting for checks: %w", ctx.Err())
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Later polls use the SHA, so a branch that moves keeps being compared to the same commit
+		ref = status.SHA
+
+		statuses, runs, missing := selectChecks(status, options.Checks)
+		state := combinedCheckState(statuses, runs)
+		completed := 0
+		for _, s := range statuses {
+			if s.State != "pending" {
+				completed++
+			}
+		}
+		for _, run := range runs {
+			if run.Status == "completed" {
+				completed++
+			}
+		}
+		total := len(statuses) + len(runs) + len(missing)
+		if len(missing) > 0 && (state == common.CHECK_STATE_SUCCESS || state == common.CHECK_STATE_NONE) {
+			state = common.CHECK_STATE_PENDING
+		}
+
+		elapsed := time.Since(start)
+		if options.Progress != nil {
+			options.Progress(common.CheckWaitProgress{
+				SHA:       status.SHA,
+				Poll:      poll,
+				Elapsed:   elapsed,
+				State:     state,
+				Completed: completed,
+				Total:     total,
+			})
+		}
+
+		done := false
+		switch state {
+		case common.CHECK_STATE_SUCCESS:
+			done = true
+		case common.CHECK_STATE_FAILURE:
+			done = options.FailFast || completed == total
+		case common.CHECK_STATE_NONE:
+			done = elapsed >= waitNoChecksGrace
+		}
+
+		remaining := time.Until(deadline)
+		if done || remaining <= 0 {
+			result := &common.CheckWaitResult{
+				SHA:      status.SHA,
+				State:    state,
+				TimedOut: !done,
+				Elapsed:  elapsed.Round(time.Second).String(),
+				Polls:    poll,
+				Missing:  missing,
+			}
+			result.Checks, err = summarizeChecks(options.Owner, options.Repo, statuses, runs, apiReqs)
+			if err != nil {
+				return nil, err
+			}
+			return result, nil
+		}
+
+		wait := interval
+		if wait > remaining {
+			wait = remaining
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("stopped waiting for checks: %w", ctx.Err())
+		case <-time.After(wait):
+		}
+		if interval *= 2; interval > waitMaxInterval {
+			interval = waitMaxInterval
+		}
+	}
+}
+
+// selectChecks keeps the statuses and check runs named in required and lists the
+// required names that were not reported. Without required names every check is kept.
+func selectChecks(status *common.RefStatus, required []string) ([]common.CommitStatus, []common.CheckRun, []string) {
+	if len(required) == 0 {
+		return status.Statuses, status.CheckRuns, nil
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range required {
+		wanted[name] = true
+	}
+	seen := make(map[string]bool)
+	var statuses []common.CommitStatus
+	for _, s := range status.Statuses {
+		if wanted[s.Context] {
+			statuses = append(statuses, s)
+			seen[s.Context] = true
+		}
+	}
+	var runs []common.CheckRun
+	for _, run := range status.CheckRuns {
+		if wanted[run.Name] {
+			runs = append(runs, run)
+			seen[run.Name] = true
+		}
+	}
+	var missing []string
+	for _, name := range required {
+		if !seen[name] {
+			missing = append(missing, name)
+		}
+	}
+	return statuses, runs, missing
+}
+
+// summarizeChecks describes each check, with the end of the output and the
+// failure annotations of the check runs that failed
+func summarizeChecks(owner string, repo string, statuses []common.CommitStatus, runs []common.CheckRun, apiReqs *common.APIRequirements) ([]common.CheckSummary, error) {
+	summaries := make([]common.CheckSummary, 0, len(statuses)+len(runs))
+	for _, s := range statuses {
+		summary := common.CheckSummary{
+			Name:       s.Context,
+			Kind:       "status",
+			Status:     "completed",
+			Conclusion: s.State,
+			DetailsURL: s.TargetURL,
+		}
+		if s.State == "pending" {
+			summary.Status, summary.Conclusion = "pending", ""
+		} else if s.State != "success" {
+			summary.Excerpt = s.Description
+		}
+		summaries = append(summaries, summary)
+	}
+
+	for _, run := range runs {
+		summary := common.CheckSummary{
+			Name:       run.Name,
+			Kind:       "check_run",
+			Status:     run.Status,
+			Conclusion: run.Conclusion,
+			DetailsURL: run.DetailsURL,
+		}
+		if run.StartedAt != nil && run.CompletedAt != nil {
+			summary.Duration = run.CompletedAt.Sub(*run.StartedAt).String()
+		}
+		if run.Status == "completed" && checkRunFailed(run.Conclusion) {
+			output := run.Output.Text
+			if output == "" {
+				output = run.Output.Summary
+			}
+			summary.Excerpt = outputExcerpt(output)
+
+			if run.Output.AnnotationsCount > 0 {
+				url := common.APIURL("/repos/%s/%s/check-runs/%d/annotations?per_page=100", owner, repo, run.ID)
+				annotations, _, err := common.TypedGitHubRequest[[]common.CheckAnnotation](url, "GET", nil, apiReqs)
+				if err != nil {
+					return nil, fmt.Errorf("error listing annotations of check run %s: %w", run.Name, err)
+				}
+				for _, a := range annotations {
+					if a.AnnotationLevel != "failure" {
+						continue
+					}
+					if len(summary.Annotations) == MAX_FAILURE_ANNOTATIONS {
+						break
+					}
+					summary.Annotations = append(summary.Annotations, fmt.Sprintf("%s:%d: %s", a.Path, a.StartLine, a.Message))
+				}
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// outputExcerpt keeps the last lines of a check output, where failures are usually reported
+func outputExcerpt(output string) string {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if len(lines) > MAX_EXCERPT_LINES {
+		lines = lines[len(lines)-MAX_EXCERPT_LINES:]
+	}
+	excerpt := strings.Join(lines, "\n")
+	if len(excerpt) > MAX_EXCERPT_SIZE {
+		excerpt = excerpt[len(excerpt)-MAX_EXCERPT_SIZE:]
+		// Start at a full line, or at least a full UTF-8 character
+		if i := strings.IndexByte(excerpt, '\n'); i >= 0 {
+			excerpt = excerpt[i+1:]
+		} else {
+			excerpt = strings.ToValidUTF8(excerpt, "")
+		}
+	}
+	return excerpt
+}
@@ -0,0 +1,423 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/metoro-io/github-mcp-server-go/common"
+)
+
+func TestCombinedCheckState(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []common.CommitStatus
+		runs     []common.CheckRun
+		want     string
+	}{
+		{
+			name: "nothing reported",
+			want: common.CHECK_STATE_NONE,
+		},
+		{
+			name:     "all passed",
+			statuses: []common.CommitStatus{{State: "success"}},
+			runs:     []common.CheckRun{{Status: "completed", Conclusion: "success"}, {Status: "completed", Conclusion: "skipped"}},
+			want:     common.CHECK_STATE_SUCCESS,
+		},
+		{
+			name: "check run still running",
+			runs: []common.CheckRun{{Status: "completed", Conclusion: "success"}, {Status: "in_progress"}},
+			want: common.CHECK_STATE_PENDING,
+		},
+		{
+			name:     "pending status",
+			statuses: []common.CommitStatus{{State: "pending"}},
+			runs:     []common.CheckRun{{Status: "completed", Conclusion: "success"}},
+			want:     common.CHECK_STATE_PENDING,
+		},
+		{
+			name: "failure wins over pending",
+			runs: []common.CheckRun{{Status: "queued"}, {Status: "completed", Conclusion: "timed_out"}},
+			want: common.CHECK_STATE_FAILURE,
+		},
+		{
+			name:     "errored status",
+			statuses: []common.CommitStatus{{State: "error"}},
+			want:     common.CHECK_STATE_FAILURE,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := combinedCheckState(tt.statuses, tt.runs); got != tt.want {
+				t.Errorf("combinedCheckState() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateCheckRunOptionsValidate(t *testing.T) {
+	sha := strings.Repeat("a", 40)
+	annotation := CheckAnnotationInput{Path: "main.go", StartLine: 3, AnnotationLevel: "warning", Message: "unused variable"}
+
+	tests := []struct {
+		name    string
+		modify  func(o *CreateCheckRunOptions)
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			modify: func(o *CreateCheckRunOptions) {},
+		},
+		{
+			name:    "short head sha",
+			modify:  func(o *CreateCheckRunOptions) { o.HeadSHA = "abc123" },
+			wantErr: "head_sha",
+		},
+		{
+			name:    "completed without conclusion",
+			modify:  func(o *CreateCheckRunOptions) { o.Status = "completed"; o.Conclusion = "" },
+			wantErr: "conclusion is required",
+		},
+		{
+			name:    "conclusion while in progress",
+			modify:  func(o *CreateCheckRunOptions) { o.Status = "in_progress" },
+			wantErr: "only be set when status is completed",
+		},
+		{
+			name:    "annotations without summary",
+			modify:  func(o *CreateCheckRunOptions) { o.Summary = "" },
+			wantErr: "title and summary",
+		},
+		{
+			name: "columns across lines",
+			modify: func(o *CreateCheckRunOptions) {
+				o.Annotations[0].EndLine = 5
+				o.Annotations[0].StartColumn = 2
+			},
+			wantErr: "annotation at index 0",
+		},
+		{
+			name:    "unknown level",
+			modify:  func(o *CreateCheckRunOptions) { o.Annotations[0].AnnotationLevel = "error" },
+			wantErr: "annotation_level",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := CreateCheckRunOptions{
+				Owner:       "octo",
+				Repo:        "repo",
+				Name:        "lint",
+				HeadSHA:     sha,
+				Conclusion:  "failure",
+				Title:       "1 warning",
+				Summary:     "Found 1 problem",
+				Annotations: []CheckAnnotationInput{annotation},
+			}
+			tt.modify(&options)
+
+			err := options.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetRefStatus(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/octo/repo/commits/main/status":
+			fmt.Fprint(w, `{"state":"success","sha":"abc","statuses":[{"state":"success","context":"ci/jenkins"}]}`)
+		case r.URL.Path == "/repos/octo/repo/commits/abc/check-runs" && r.URL.Query().Get("page") == "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/octo/repo/commits/abc/check-runs?per_page=100&page=2>; rel="next"`, server.URL))
+			fmt.Fprint(w, `{"total_count":2,"check_runs":[{"id":1,"name":"build","status":"completed","conclusion":"success","output":{"annotations_count":0}}]}`)
+		case r.URL.Path == "/repos/octo/repo/commits/abc/check-runs":
+			fmt.Fprint(w, `{"total_count":2,"check_runs":[{"id":2,"name":"lint","status":"completed","conclusion":"failure","output":{"title":"1 error","annotations_count":1}}]}`)
+		case r.URL.Path == "/repos/octo/repo/commits/abc/check-suites":
+			fmt.Fprint(w, `{"total_count":1,"check_suites":[{"id":7,"status":"completed","conclusion":"failure"}]}`)
+		case r.URL.Path == "/repos/octo/repo/check-runs/2/annotations":
+			fmt.Fprint(w, `[{"path":"main.go","start_line":3,"end_line":3,"annotation_level":"failure","message":"undefined: x"}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	defer common.Configure(common.DefaultClientOptions())
+	common.Configure(common.ClientOptions{BaseURL: server.URL, Token: "test"})
+
+	status, err := GetRefStatus(&GetRefStatusOptions{Owner: "octo", Repo: "repo", Ref: "main", IncludeAnnotations: true}, nil)
+	if err != nil {
+		t.Fatalf("GetRefStatus() error = %v", err)
+	}
+	if status.SHA != "abc" || status.State != common.CHECK_STATE_FAILURE {
+		t.Errorf("status = %s at %s, want failure at abc", status.State, status.SHA)
+	}
+	if len(status.Statuses) != 1 || len(status.CheckRuns) != 2 || len(status.CheckSuites) != 1 {
+		t.Fatalf("got %d statuses, %d check runs and %d suites, want 1, 2 and 1", len(status.Statuses), len(status.CheckRuns), len(status.CheckSuites))
+	}
+	if len(status.CheckRuns[0].Annotations) != 0 || len(status.CheckRuns[1].Annotations) != 1 {
+		t.Errorf("annotations = %v and %v, want only the lint run annotated", status.CheckRuns[0].Annotations, status.CheckRuns[1].Annotations)
+	}
+}
+
+func TestCreateCheckRunBatchesAnnotations(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Output struct {
+				Annotations []map[string]interface{} `json:"annotations"`
+			} `json:"output"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, fmt.Sprintf("%s %s %d", r.Method, r.URL.Path, len(body.Output.Annotations)))
+		fmt.Fprint(w, `{"id":42,"name":"lint","status":"completed","conclusion":"failure"}`)
+	}))
+	defer server.Close()
+
+	defer common.Configure(common.DefaultClientOptions())
+	common.Configure(common.ClientOptions{BaseURL: server.URL, Token: "test"})
+
+	annotations := make([]CheckAnnotationInput, 120)
+	for i := range annotations {
+		annotations[i] = CheckAnnotationInput{Path: "main.go", StartLine: i + 1, AnnotationLevel: "notice", Message: "style"}
+	}
+	run, err := CreateCheckRun(&CreateCheckRunOptions{
+		Owner:       "octo",
+		Repo:        "repo",
+		Name:        "lint",
+		HeadSHA:     strings.Repeat("a", 40),
+		Conclusion:  "failure",
+		Title:       "120 notices",
+		Summary:     "Style problems",
+		Annotations: annotations,
+	}, nil)
+	if err != nil {
+		t.Fatalf("CreateCheckRun() error = %v", err)
+	}
+	if run.ID != 42 {
+		t.Errorf("check run ID = %d, want 42", run.ID)
+	}
+
+	want := []string{
+		"POST /repos/octo/repo/check-runs 50",
+		"PATCH /repos/octo/repo/check-runs/42 50",
+		"PATCH /repos/octo/repo/check-runs/42 20",
+	}
+	if strings.Join(requests, ",") != strings.Join(want, ",") {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+}
+
+func TestWaitForChecks(t *testing.T) {
+	defer func(initial, max, grace time.Duration) {
+		waitInitialInterval, waitMaxInterval, waitNoChecksGrace = initial, max, grace
+	}(waitInitialInterval, waitMaxInterval, waitNoChecksGrace)
+	waitInitialInterval, waitMaxInterval, waitNoChecksGrace = time.Millisecond, 10*time.Millisecond, 50*time.Millisecond
+
+	tests := []struct {
+		name          string
+		options       WaitForChecksOptions
+		polls         []string
+		wantState     string
+		wantTimedOut  bool
+		wantPolls     int
+		wantMissing   []string
+		wantExcerpt   string
+		wantAnnotated []string
+	}{
+		{
+			name:    "passes after a pending poll",
+			options: WaitForChecksOptions{Ref: "main"},
+			polls: []string{
+				`[{"id":1,"name":"build","status":"in_progress"}]`,
+				`[{"id":1,"name":"build","status":"completed","conclusion":"success"}]`,
+			},
+			wantState: common.CHECK_STATE_SUCCESS,
+			wantPolls: 2,
+		},
+		{
+			name:    "fail fast with excerpts",
+			options: WaitForChecksOptions{PullNumber: 7, FailFast: true},
+			polls: []string{
+				`[{"id":1,"name":"build","status":"in_progress"},{"id":2,"name":"test","status":"completed","conclusion":"failure","output":{"summary":"ok 1\nFAIL TestX\nexit 1\n","annotations_count":2}}]`,
+			},
+			wantState:     common.CHECK_STATE_FAILURE,
+			wantPolls:     1,
+			wantExcerpt:   "ok 1\nFAIL TestX\nexit 1",
+			wantAnnotated: []string{"x_test.go:12: expected 1"},
+		},
+		{
+			name:    "failure waits for the other checks",
+			options: WaitForChecksOptions{Ref: "main"},
+			polls: []string{
+				`[{"id":1,"name":"build","status":"in_progress"},{"id":2,"name":"test","status":"completed","conclusion":"failure"}]`,
+				`[{"id":1,"name":"build","status":"completed","conclusion":"success"},{"id":2,"name":"test","status":"completed","conclusion":"failure"}]`,
+			},
+			wantState: common.CHECK_STATE_FAILURE,
+			wantPolls: 2,
+		},
+		{
+			name:         "required check never reported",
+			options:      WaitForChecksOptions{Ref: "main", Checks: []string{"build", "deploy"}, TimeoutSeconds: 1},
+			polls:        []string{`[{"id":1,"name":"build","status":"completed","conclusion":"success"}]`},
+			wantState:    common.CHECK_STATE_PENDING,
+			wantTimedOut: true,
+			wantMissing:  []string{"deploy"},
+		},
+		{
+			name:      "no checks at all",
+			options:   WaitForChecksOptions{Ref: "main"},
+			polls:     []string{`[]`},
+			wantState: common.CHECK_STATE_NONE,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poll := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/repos/octo/repo/pulls/7":
+					fmt.Fprint(w, `{"number":7,"head":{"sha":"abc"}}`)
+				case "/repos/octo/repo/commits/main/status", "/repos/octo/repo/commits/abc/status":
+					fmt.Fprint(w, `{"state":"pending","sha":"abc","statuses":[]}`)
+				case "/repos/octo/repo/commits/abc/check-runs":
+					runs := tt.polls[len(tt.polls)-1]
+					if poll < len(tt.polls) {
+						runs = tt.polls[poll]
+					}
+					poll++
+					fmt.Fprintf(w, `{"check_runs":%s}`, runs)
+				case "/repos/octo/repo/check-runs/2/annotations":
+					fmt.Fprint(w, `[{"path":"x_test.go","start_line":12,"annotation_level":"failure","message":"expected 1"},{"path":"x.go","start_line":3,"annotation_level":"notice","message":"note"}]`)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			defer common.Configure(common.DefaultClientOptions())
+			// Polls must reach the server even while the cached response is fresh
+			common.Configure(common.ClientOptions{
+				BaseURL: server.URL,
+				Token:   "test",
+				Cache:   common.CacheOptions{Enabled: true, TTL: time.Hour},
+			})
+
+			options := tt.options
+			options.Owner, options.Repo = "octo", "repo"
+			var progress []common.CheckWaitProgress
+			options.Progress = func(p common.CheckWaitProgress) { progress = append(progress, p) }
+
+			result, err := WaitForChecks(context.Background(), &options, nil)
+			if err != nil {
+				t.Fatalf("WaitForChecks() error = %v", err)
+			}
+			if result.State != tt.wantState || result.TimedOut != tt.wantTimedOut {
+				t.Errorf("result = %s (timed out %v), want %s (timed out %v)", result.State, result.TimedOut, tt.wantState, tt.wantTimedOut)
+			}
+			if tt.wantPolls != 0 && result.Polls != tt.wantPolls {
+				t.Errorf("Polls = %d, want %d", result.Polls, tt.wantPolls)
+			}
+			if len(progress) != result.Polls {
+				t.Errorf("progress reported %d times for %d polls", len(progress), result.Polls)
+			}
+			if strings.Join(result.Missing, ",") != strings.Join(tt.wantMissing, ",") {
+				t.Errorf("Missing = %v, want %v", result.Missing, tt.wantMissing)
+			}
+			if tt.wantExcerpt != "" || tt.wantAnnotated != nil {
+				var failed *common.CheckSummary
+				for i := range result.Checks {
+					if result.Checks[i].Conclusion == "failure" {
+						failed = &result.Checks[i]
+					}
+				}
+				if failed == nil {
+					t.Fatalf("no failed check in %+v", result.Checks)
+				}
+				if failed.Excerpt != tt.wantExcerpt {
+					t.Errorf("Excerpt = %q, want %q", failed.Excerpt, tt.wantExcerpt)
+				}
+				if strings.Join(failed.Annotations, ",") != strings.Join(tt.wantAnnotated, ",") {
+					t.Errorf("Annotations = %v, want %v", failed.Annotations, tt.wantAnnotated)
+				}
+			}
+		})
+	}
+}
+
+func TestWaitForChecksCancelled(t *testing.T) {
+	defer func(initial time.Duration) { waitInitialInterval = initial }(waitInitialInterval)
+	waitInitialInterval = time.Millisecond
+
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octo/repo/commits/main/status", "/repos/octo/repo/commits/abc/status":
+			fmt.Fprint(w, `{"state":"pending","sha":"abc","statuses":[]}`)
+		case "/repos/octo/repo/commits/abc/check-runs":
+			// The second poll hangs until the client gives up on it
+			if polls++; polls > 1 {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(5 * time.Second):
+				}
+				return
+			}
+			fmt.Fprint(w, `{"check_runs":[{"id":1,"name":"build","status":"in_progress"}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	defer common.Configure(common.DefaultClientOptions())
+	common.Configure(common.ClientOptions{BaseURL: server.URL, Token: "test"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	options := WaitForChecksOptions{Owner: "octo", Repo: "repo", Ref: "main"}
+	options.Progress = func(common.CheckWaitProgress) { time.AfterFunc(50*time.Millisecond, cancel) }
+
+	start := time.Now()
+	_, err := WaitForChecks(ctx, &options, nil)
+	if err == nil || !strings.Contains(err.Error(), "stopped waiting for checks") {
+		t.Fatalf("WaitForChecks() error = %v, want it to stop waiting", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("WaitForChecks() returned after %s, want it to stop with the cancelled poll", elapsed)
+	}
+}
+
+func TestOutputExcerpt(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 30; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	excerpt := outputExcerpt(strings.Join(lines, "\n") + "\n")
+	if !strings.HasPrefix(excerpt, "line 11\n") || !strings.HasSuffix(excerpt, "line 30") {
+		t.Errorf("outputExcerpt() = %q, want lines 11 to 30", excerpt)
+	}
+
+	long := strings.Repeat("x", 3000) + "\n" + strings.Repeat("y", 100)
+	if got := outputExcerpt(long); got != strings.Repeat("y", 100) {
+		t.Errorf("outputExcerpt() of a long line = %d bytes, want the last line only", len(got))
+	}
+}
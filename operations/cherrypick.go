@@ -0,0 +1,253 @@
+package operations
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/metoro-io/github-mcp-server-go/common"
+)
+
+// CherryPickCommitOptions defines options for applying the changes of a commit to a branch
+type CherryPickCommitOptions struct {
+	Owner   string `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo    string `json:"repo" jsonschema:"description=The name of the repository"`
+	Branch  string `json:"branch" jsonschema:"description=The branch to apply the commit to"`
+	SHA     string `json:"sha" jsonschema:"description=The SHA of the commit to cherry-pick"`
+	Message string `json:"message,omitempty" jsonschema:"description=The commit message. Default: the message of the cherry-picked commit followed by a (cherry picked from commit ...) line"`
+}
+
+// Validate validates the CherryPickCommitOptions
+func (o *CherryPickCommitOptions) Validate() error {
+	return validateApplyCommit(o.Owner, o.Repo, o.Branch, o.SHA)
+}
+
+// RevertCommitOptions defines options for undoing the changes of a commit on a branch
+type RevertCommitOptions struct {
+	Owner   string `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo    string `json:"repo" jsonschema:"description=The name of the repository"`
+	Branch  string `json:"branch" jsonschema:"description=The branch to revert the commit on"`
+	SHA     string `json:"sha" jsonschema:"description=The SHA of the commit to revert"`
+	Message string `json:"message,omitempty" jsonschema:"description=The commit message. Default: Revert followed by the subject of the reverted commit"`
+}
+
+// Validate validates the RevertCommitOptions
+func (o *RevertCommitOptions) Validate() error {
+	return validateApplyCommit(o.Owner, o.Repo, o.Branch, o.SHA)
+}
+
+func validateApplyCommit(owner string, repo string, branch string, sha string) error {
+	if _, err := common.ValidateOwnerName(owner); err != nil {
+		return err
+	}
+	if _, err := common.ValidateRepositoryName(repo); err != nil {
+		return err
+	}
+	if _, err := common.ValidateBranchName(branch); err != nil {
+		return err
+	}
+	if sha == "" {
+		return fmt.Errorf("sha is required")
+	}
+	if _, err := common.ValidateBranchName(sha); err != nil {
+		return fmt.Errorf("invalid sha: %w", err)
+	}
+	return nil
+}
+
+// CherryPickCommit applies the changes of a commit onto the head of a branch in a new commit.
+// The original author is kept.
+func CherryPickCommit(options *CherryPickCommitOptions, apiReqs *common.APIRequirements) (*common.AppliedCommitResult, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	source, parentSHA, err := getSingleParentCommit(options.Owner, options.Repo, options.SHA, apiReqs)
+	if err != nil {
+		return nil, err
+	}
+
+	message := options.Message
+	if message == "" {
+		message = fmt.Sprintf("%s\n\n(cherry picked from commit %s)", strings.TrimRight(source.Commit.Message, "\n"), source.SHA)
+	}
+	author := &CommitterInfo{
+		Name:  source.Commit.Author.Name,
+		Email: source.Commit.Author.Email,
+		Date:  source.Commit.Author.Date.UTC().Format(time.RFC3339),
+	}
+
+	result, err := applyCommitChanges(options.Owner, options.Repo, options.Branch, source, parentSHA, source.SHA, message, author, apiReqs)
+	if err != nil {
+		return nil, fmt.Errorf("error cherry-picking commit: %w", err)
+	}
+	return result, nil
+}
+
+// RevertCommit applies the inverse of the changes of a commit onto the head of a branch in a new commit
+func RevertCommit(options *RevertCommitOptions, apiReqs *common.APIRequirements) (*common.AppliedCommitResult, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	source, parentSHA, err := getSingleParentCommit(options.Owner, options.Repo, options.SHA, apiReqs)
+	if err != nil {
+		return nil, err
+	}
+
+	message := options.Message
+	if message == "" {
+		subject, _, _ := strings.Cut(source.Commit.Message, "\n")
+		message = fmt.Sprintf("Revert \"%s\"\n\nThis reverts commit %s.", subject, source.SHA)
+	}
+
+	result, err := applyCommitChanges(options.Owner, options.Repo, options.Branch, source, source.SHA, parentSHA, message, nil, apiReqs)
+	if err != nil {
+		return nil, fmt.Errorf("error reverting commit: %w", err)
+	}
+	return result, nil
+}
+
+// getSingleParentCommit gets a commit with its changed files and the SHA of its parent
+func getSingleParentCommit(owner string, repo string, ref string, apiReqs *common.APIRequirements) (*common.GitHubCommit, string, error) {
+	url := common.APIURL("/repos/%s/%s/commits/%s", owner, repo, ref)
+	commit, _, err := common.TypedGitHubRequest[common.GitHubCommit](url, "GET", nil, apiReqs)
+	if err != nil {
+		return nil, "", fmt.Errorf("error getting commit: %w", err)
+	}
+
+	switch {
+	case len(commit.Parents) == 0:
+		return nil, "", fmt.Errorf("commit %s has no parent to compare it with", commit.SHA)
+	case len(commit.Parents) > 1:
+		return nil, "", fmt.Errorf("commit %s is a merge commit, only commits with a single parent are supported", commit.SHA)
+	case len(commit.Files) >= COMPARE_MAX_FILES:
+		// GitHub lists at most this many files without pagination, so the changes may be incomplete
+		return nil, "", fmt.Errorf("commit %s changes %d or more files, which is more than can be applied safely", commit.SHA, COMPARE_MAX_FILES)
+	}
+	return &commit, commit.Parents[0].SHA, nil
+}
+
+// applyCommitChanges makes every file changed by source look on branch as it does in toSHA,
+// provided it still looks as in fromSHA. Cherry-picks go from the parent to the commit and
+// reverts the other way round. Files that already match toSHA are left alone and any other
+// difference is a conflict.
+func applyCommitChanges(owner string, repo string, branch string, source *common.GitHubCommit, fromSHA string, toSHA string,
+	message string, author *CommitterInfo, apiReqs *common.APIRequirements) (*common.AppliedCommitResult, error) {
+	headSHA, err := getBranchSHA(owner, repo, branch, apiReqs)
+	if err != nil {
+		return nil, err
+	}
+
+	lookups := make(map[string]*treeLookup)
+	for _, sha := range []string{fromSHA, toSHA, headSHA} {
+		if _, ok := lookups[sha]; ok {
+			continue
+		}
+		url := common.APIURL("/repos/%s/%s/git/commits/%s", owner, repo, sha)
+		commit, _, err := common.TypedGitHubRequest[common.GitCommit](url, "GET", nil, apiReqs)
+		if err != nil {
+			return nil, fmt.Errorf("error getting commit %s: %w", sha, err)
+		}
+		lookups[sha] = newTreeLookup(owner, repo, commit.Tree.SHA, apiReqs)
+	}
+
+	result := &common.AppliedCommitResult{SourceSHA: source.SHA}
+	var (
+		pushFiles   []PushFileDefinition
+		conflicting []string
+	)
+	for _, path := range changedPaths(source.Files) {
+		from, err := lookups[fromSHA].find(path)
+		if err != nil {
+			return nil, fmt.Errorf("error looking up %s: %w", path, err)
+		}
+		to, err := lookups[toSHA].find(path)
+		if err != nil {
+			return nil, fmt.Errorf("error looking up %s: %w", path, err)
+		}
+		current, err := lookups[headSHA].find(path)
+		if err != nil {
+			return nil, fmt.Errorf("error looking up %s: %w", path, err)
+		}
+
+		switch {
+		case sameTreeEntry(current, to):
+			result.Unchanged = append(result.Unchanged, path)
+		case !sameTreeEntry(current, from):
+			conflicting = append(conflicting, path)
+		case to == nil:
+			pushFiles = append(pushFiles, PushFileDefinition{Path: path, Delete: true})
+			result.Files = append(result.Files, common.AppliedFile{Path: path, Status: common.PATCH_STATUS_DELETED})
+		default:
+			file := PushFileDefinition{Path: path, Mode: to.Mode, blobSHA: to.SHA}
+			if to.Type == "commit" {
+				file = PushFileDefinition{Path: path, SubmoduleSHA: to.SHA}
+			}
+			status := common.PATCH_STATUS_MODIFIED
+			if from == nil {
+				status = common.PATCH_STATUS_ADDED
+			}
+			pushFiles = append(pushFiles, file)
+			result.Files = append(result.Files, common.AppliedFile{Path: path, Status: status})
+		}
+	}
+
+	if len(conflicting) > 0 {
+		return nil, &common.ConflictError{
+			Ref:              "refs/heads/" + branch,
+			ConflictingPaths: conflicting,
+			Reason: fmt.Sprintf("the files changed by %s were changed differently on the branch at %s, resolve them and commit the result with push_files",
+				shortSHA(source.SHA), shortSHA(headSHA)),
+		}
+	}
+	if len(pushFiles) == 0 {
+		return nil, fmt.Errorf("the branch already contains the result of applying %s", source.SHA)
+	}
+
+	commit, err := commitFiles(&PushFilesOptions{
+		Owner:   owner,
+		Repo:    repo,
+		Branch:  branch,
+		Message: message,
+		Files:   pushFiles,
+		BaseSHA: headSHA,
+		Author:  author,
+	}, apiReqs)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Commit = *commit
+	return result, nil
+}
+
+// changedPaths lists the paths touched by files, including the old path of renames
+func changedPaths(files []common.CommitFile) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, file := range files {
+		for _, path := range []string{file.PreviousFilename, file.Filename} {
+			if path != "" && !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+// sameTreeEntry reports whether two entries, either of which may be missing, have the same content and mode
+func sameTreeEntry(a *common.TreeEntry, b *common.TreeEntry) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.SHA == b.SHA && a.Mode == b.Mode
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
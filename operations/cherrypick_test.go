@@ -0,0 +1,197 @@
+package operations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/metoro-io/github-mcp-server-go/common"
+)
+
+func TestApplyCommit(t *testing.T) {
+	trees := map[string]string{
+		"parent": `[{"path":"a.txt","mode":"100644","type":"blob","sha":"a1"},{"path":"old.txt","mode":"100644","type":"blob","sha":"o1"}]`,
+		"source": `[{"path":"a.txt","mode":"100644","type":"blob","sha":"a2"},{"path":"new.txt","mode":"100755","type":"blob","sha":"n1"}]`,
+	}
+
+	tests := []struct {
+		name          string
+		revert        bool
+		sha           string
+		headTree      string
+		wantItems     []string
+		wantUnchanged []string
+		wantMessage   string
+		wantAuthor    string
+		wantConflicts []string
+		wantErr       string
+	}{
+		{
+			name:        "cherry-pick onto unrelated changes",
+			sha:         "source",
+			headTree:    `[{"path":"a.txt","mode":"100644","type":"blob","sha":"a1"},{"path":"old.txt","mode":"100644","type":"blob","sha":"o1"},{"path":"other.txt","mode":"100644","type":"blob","sha":"x1"}]`,
+			wantItems:   []string{"a.txt 100644 a2", "new.txt 100755 n1", "old.txt 100644 <nil>"},
+			wantMessage: "Fix bug\n\nDetails\n\n(cherry picked from commit source)",
+			wantAuthor:  "Mona 2024-01-02T03:04:05Z",
+		},
+		{
+			name:          "cherry-pick partly on the branch already",
+			sha:           "source",
+			headTree:      `[{"path":"a.txt","mode":"100644","type":"blob","sha":"a1"},{"path":"new.txt","mode":"100755","type":"blob","sha":"n1"}]`,
+			wantItems:     []string{"a.txt 100644 a2"},
+			wantUnchanged: []string{"old.txt", "new.txt"},
+			wantMessage:   "Fix bug\n\nDetails\n\n(cherry picked from commit source)",
+			wantAuthor:    "Mona 2024-01-02T03:04:05Z",
+		},
+		{
+			name:          "cherry-pick onto a changed file",
+			sha:           "source",
+			headTree:      `[{"path":"a.txt","mode":"100644","type":"blob","sha":"a3"},{"path":"old.txt","mode":"100644","type":"blob","sha":"o1"}]`,
+			wantConflicts: []string{"a.txt"},
+		},
+		{
+			name:        "revert",
+			revert:      true,
+			sha:         "source",
+			headTree:    trees["source"],
+			wantItems:   []string{"a.txt 100644 a1", "new.txt 100644 <nil>", "old.txt 100644 o1"},
+			wantMessage: "Revert \"Fix bug\"\n\nThis reverts commit source.",
+		},
+		{
+			name:          "revert of a file changed since",
+			revert:        true,
+			sha:           "source",
+			headTree:      `[{"path":"a.txt","mode":"100644","type":"blob","sha":"a2"},{"path":"new.txt","mode":"100644","type":"blob","sha":"n1"}]`,
+			wantConflicts: []string{"new.txt"},
+		},
+		{
+			name:     "revert already reverted",
+			revert:   true,
+			sha:      "source",
+			headTree: trees["parent"],
+			wantErr:  "already contains",
+		},
+		{
+			name:     "merge commit",
+			sha:      "merge",
+			headTree: trees["parent"],
+			wantErr:  "merge commit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				treeItems []map[string]interface{}
+				commit    struct {
+					Message string         `json:"message"`
+					Parents []string       `json:"parents"`
+					Author  *CommitterInfo `json:"author"`
+				}
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/repos/octo/repo/commits/source":
+					fmt.Fprint(w, `{"sha":"source","commit":{"message":"Fix bug\n\nDetails\n","author":{"name":"Mona","email":"mona@example.com","date":"2024-01-02T03:04:05Z"}},
+						"parents":[{"sha":"parent"}],
+						"files":[{"filename":"a.txt","status":"modified"},{"filename":"new.txt","status":"renamed","previous_filename":"old.txt"}]}`)
+				case r.URL.Path == "/repos/octo/repo/commits/merge":
+					fmt.Fprint(w, `{"sha":"merge","parents":[{"sha":"parent"},{"sha":"source"}]}`)
+				case r.URL.Path == "/repos/octo/repo/git/refs/heads/main":
+					fmt.Fprint(w, `{"ref":"refs/heads/main","object":{"sha":"head"}}`)
+				case strings.HasPrefix(r.URL.Path, "/repos/octo/repo/git/commits/"):
+					sha := strings.TrimPrefix(r.URL.Path, "/repos/octo/repo/git/commits/")
+					fmt.Fprintf(w, `{"sha":%q,"tree":{"sha":%q}}`, sha, "tree-"+sha)
+				case strings.HasPrefix(r.URL.Path, "/repos/octo/repo/git/trees/tree-"):
+					tree := tt.headTree
+					if sha := strings.TrimPrefix(r.URL.Path, "/repos/octo/repo/git/trees/tree-"); sha != "head" {
+						tree = trees[sha]
+					}
+					fmt.Fprintf(w, `{"tree":%s}`, tree)
+				case r.URL.Path == "/repos/octo/repo/git/trees":
+					var body struct {
+						Tree []map[string]interface{} `json:"tree"`
+					}
+					json.NewDecoder(r.Body).Decode(&body)
+					treeItems = body.Tree
+					fmt.Fprint(w, `{"sha":"newtree"}`)
+				case r.URL.Path == "/repos/octo/repo/git/commits":
+					json.NewDecoder(r.Body).Decode(&commit)
+					fmt.Fprint(w, `{"sha":"newcommit"}`)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			defer common.Configure(common.DefaultClientOptions())
+			common.Configure(common.ClientOptions{BaseURL: server.URL, Token: "test"})
+
+			var (
+				result *common.AppliedCommitResult
+				err    error
+			)
+			if tt.revert {
+				result, err = RevertCommit(&RevertCommitOptions{Owner: "octo", Repo: "repo", Branch: "main", SHA: tt.sha}, nil)
+			} else {
+				result, err = CherryPickCommit(&CherryPickCommitOptions{Owner: "octo", Repo: "repo", Branch: "main", SHA: tt.sha}, nil)
+			}
+
+			if tt.wantConflicts != nil {
+				var conflict *common.ConflictError
+				if !errors.As(err, &conflict) {
+					t.Fatalf("error = %v, want a ConflictError", err)
+				}
+				if strings.Join(conflict.ConflictingPaths, ",") != strings.Join(tt.wantConflicts, ",") {
+					t.Errorf("ConflictingPaths = %v, want %v", conflict.ConflictingPaths, tt.wantConflicts)
+				}
+				if treeItems != nil {
+					t.Errorf("a tree was created despite the conflict")
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var items []string
+			for _, item := range treeItems {
+				items = append(items, fmt.Sprintf("%s %s %v", item["path"], item["mode"], item["sha"]))
+			}
+			sort.Strings(items)
+			if strings.Join(items, ",") != strings.Join(tt.wantItems, ",") {
+				t.Errorf("tree items = %v, want %v", items, tt.wantItems)
+			}
+			if strings.Join(result.Unchanged, ",") != strings.Join(tt.wantUnchanged, ",") {
+				t.Errorf("Unchanged = %v, want %v", result.Unchanged, tt.wantUnchanged)
+			}
+			if commit.Message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", commit.Message, tt.wantMessage)
+			}
+			if len(commit.Parents) != 1 || commit.Parents[0] != "head" {
+				t.Errorf("parents = %v, want [head]", commit.Parents)
+			}
+			author := ""
+			if commit.Author != nil {
+				author = commit.Author.Name + " " + commit.Author.Date
+			}
+			if author != tt.wantAuthor {
+				t.Errorf("author = %q, want %q", author, tt.wantAuthor)
+			}
+			if result.SourceSHA != tt.sha || result.Commit.SHA != "newcommit" {
+				t.Errorf("result = %s from %s, want newcommit from %s", result.Commit.SHA, result.SourceSHA, tt.sha)
+			}
+		})
+	}
+}
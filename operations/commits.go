@@ -1,13 +1,24 @@
 package operations
 
 import (
-	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/metoro-io/github-mcp-server-go/common"
 )
 
+const (
+	// COMPARE_MAX_FILES is the number of changed files after which GitHub truncates a comparison
+	COMPARE_MAX_FILES = 300
+	// DEFAULT_COMPARE_MAX_COMMITS is the number of commits compare_refs returns by default
+	DEFAULT_COMPARE_MAX_COMMITS = 50
+	// DEFAULT_COMPARE_MAX_FILES is the number of changed files compare_refs returns by default
+	DEFAULT_COMPARE_MAX_FILES = 100
+	// DEFAULT_MAX_PATCH_SIZE is the number of bytes of each file patch returned by default
+	DEFAULT_MAX_PATCH_SIZE = 4000
+)
+
 // ListCommitsOptions defines options for listing commits
 type ListCommitsOptions struct {
 	Owner   string `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
@@ -39,10 +50,10 @@ func (o *ListCommitsOptions) Validate() error {
 // ListCommits lists commits in a GitHub repository
 func ListCommits(options *ListCommitsOptions, apiReqs *common.APIRequirements) ([]common.GitHubCommit, error) {
 	if err := options.Validate(); err != nil {
-		return nil, err
+		return nil, common.InvalidInput(err)
 	}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/commits", options.Owner, options.Repo)
+	url := common.APIURL("/repos/%s/%s/commits", options.Owner, options.Repo)
 
 	params := make(map[string]string)
 	if options.Branch != "" {
@@ -72,20 +83,237 @@ func ListCommits(options *ListCommitsOptions, apiReqs *common.APIRequirements) (
 		}
 	}
 
-	resp, err := common.GitHubRequest(url, "GET", nil, apiReqs)
+	commits, _, err := common.TypedGitHubRequest[[]common.GitHubCommit](url, "GET", nil, apiReqs)
 	if err != nil {
 		return nil, err
 	}
 
-	var commits []common.GitHubCommit
-	jsonData, err := json.Marshal(resp)
+	return commits, nil
+}
+
+// compareCommits compares base with head. GitHub lists at most COMPARE_MAX_FILES changed files.
+func compareCommits(owner string, repo string, base string, head string, apiReqs *common.APIRequirements) (*common.CommitComparison, error) {
+	url := common.APIURL("/repos/%s/%s/compare/%s...%s", owner, repo, base, head)
+	comparison, _, err := common.TypedGitHubRequest[common.CommitComparison](url, "GET", nil, apiReqs)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error comparing %s with %s: %w", base, head, err)
 	}
+	return &comparison, nil
+}
 
-	if err := json.Unmarshal(jsonData, &commits); err != nil {
+// CompareRefsOptions defines options for comparing two refs
+type CompareRefsOptions struct {
+	Owner          string `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo           string `json:"repo" jsonschema:"description=The name of the repository"`
+	Base           string `json:"base" jsonschema:"description=The branch tag or commit SHA to compare from"`
+	Head           string `json:"head" jsonschema:"description=The branch tag or commit SHA to compare to"`
+	IncludePatches bool   `json:"include_patches,omitempty" jsonschema:"description=Include the unified diff of each file. Default: false"`
+	MaxCommits     int    `json:"max_commits,omitempty" jsonschema:"description=Maximum number of commits to return. Default: 50. Maximum: 250"`
+	MaxFiles       int    `json:"max_files,omitempty" jsonschema:"description=Maximum number of changed files to return. Default: 100. Maximum: 300"`
+	MaxPatchSize   int    `json:"max_patch_size,omitempty" jsonschema:"description=Maximum number of bytes of each file patch. Longer patches are cut at a line boundary. Default: 4000"`
+}
+
+// Validate validates the CompareRefsOptions
+func (o *CompareRefsOptions) Validate() error {
+	if _, err := common.ValidateOwnerName(o.Owner); err != nil {
+		return err
+	}
+	if _, err := common.ValidateRepositoryName(o.Repo); err != nil {
+		return err
+	}
+	if _, err := common.ValidateBranchName(o.Base); err != nil {
+		return fmt.Errorf("invalid base: %w", err)
+	}
+	if _, err := common.ValidateBranchName(o.Head); err != nil {
+		return fmt.Errorf("invalid head: %w", err)
+	}
+	if o.MaxCommits < 0 || o.MaxCommits > 250 {
+		return fmt.Errorf("max_commits must be between 0 and 250")
+	}
+	if o.MaxFiles < 0 || o.MaxFiles > COMPARE_MAX_FILES {
+		return fmt.Errorf("max_files must be between 0 and %d", COMPARE_MAX_FILES)
+	}
+	if o.MaxPatchSize < 0 {
+		return fmt.Errorf("max_patch_size must not be negative")
+	}
+	return nil
+}
+
+// CompareRefs compares base...head and summarises the commits and files that differ
+func CompareRefs(options *CompareRefsOptions, apiReqs *common.APIRequirements) (*common.RefComparison, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	comparison, err := compareCommits(options.Owner, options.Repo, options.Base, options.Head, apiReqs)
+	if err != nil {
 		return nil, err
 	}
 
-	return commits, nil
+	maxCommits := options.MaxCommits
+	if maxCommits == 0 {
+		maxCommits = DEFAULT_COMPARE_MAX_COMMITS
+	}
+
+	result := &common.RefComparison{
+		Base:         options.Base,
+		Head:         options.Head,
+		Status:       comparison.Status,
+		AheadBy:      comparison.AheadBy,
+		BehindBy:     comparison.BehindBy,
+		MergeBaseSHA: comparison.MergeBaseCommit.SHA,
+		HTMLURL:      comparison.HTMLURL,
+		TotalCommits: comparison.TotalCommits,
+		Commits:      comparison.Commits,
+		ChangedFiles: len(comparison.Files),
+	}
+
+	// Commits are listed oldest first, keep the most recent of the listed ones
+	if len(result.Commits) > maxCommits {
+		result.Commits = result.Commits[len(result.Commits)-maxCommits:]
+	}
+	result.CommitsTruncated = len(result.Commits) < comparison.TotalCommits
+
+	for _, file := range comparison.Files {
+		result.Additions += file.Additions
+		result.Deletions += file.Deletions
+	}
+	result.Files, result.FilesTruncated = limitFiles(comparison.Files, options.MaxFiles, options.IncludePatches, options.MaxPatchSize)
+
+	if len(comparison.Files) >= COMPARE_MAX_FILES {
+		result.Warning = fmt.Sprintf("GitHub lists at most %d changed files, the totals only cover the listed files", COMPARE_MAX_FILES)
+	}
+	return result, nil
+}
+
+// limitFiles keeps the first maxFiles files and their patches, if included, cut to maxPatchSize bytes.
+// Zero limits select the defaults. It reports whether files were dropped.
+func limitFiles(files []common.CommitFile, maxFiles int, includePatches bool, maxPatchSize int) ([]common.CommitFile, bool) {
+	if maxFiles == 0 {
+		maxFiles = DEFAULT_COMPARE_MAX_FILES
+	}
+	if maxPatchSize == 0 {
+		maxPatchSize = DEFAULT_MAX_PATCH_SIZE
+	}
+
+	truncated := false
+	if len(files) > maxFiles {
+		files = files[:maxFiles]
+		truncated = true
+	}
+	for i := range files {
+		file := &files[i]
+		if !includePatches {
+			file.Patch = ""
+			continue
+		}
+		file.Patch, file.PatchTruncated = truncatePatch(file.Patch, maxPatchSize)
+	}
+	return files, truncated
+}
+
+// truncatePatch cuts patch to at most maxSize bytes, ending at a line boundary when possible
+func truncatePatch(patch string, maxSize int) (string, bool) {
+	if len(patch) <= maxSize {
+		return patch, false
+	}
+	cut := patch[:maxSize]
+	if i := strings.LastIndexByte(cut, '\n'); i > 0 {
+		cut = cut[:i+1]
+	}
+	return cut, true
+}
+
+// GetCommitOptions defines options for getting a single commit
+type GetCommitOptions struct {
+	Owner          string `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo           string `json:"repo" jsonschema:"description=The name of the repository"`
+	Ref            string `json:"ref" jsonschema:"description=The commit SHA or a branch or tag name whose latest commit is returned"`
+	IncludePatches bool   `json:"include_patches,omitempty" jsonschema:"description=Include the unified diff of each file. Default: false"`
+	MaxFiles       int    `json:"max_files,omitempty" jsonschema:"description=Maximum number of changed files to return. Default: 100. Maximum: 300"`
+	MaxPatchSize   int    `json:"max_patch_size,omitempty" jsonschema:"description=Maximum number of bytes of each file patch. Longer patches are cut at a line boundary. Default: 4000"`
+}
+
+// Validate validates the GetCommitOptions
+func (o *GetCommitOptions) Validate() error {
+	if _, err := common.ValidateOwnerName(o.Owner); err != nil {
+		return err
+	}
+	if _, err := common.ValidateRepositoryName(o.Repo); err != nil {
+		return err
+	}
+	if _, err := common.ValidateBranchName(o.Ref); err != nil {
+		return fmt.Errorf("invalid ref: %w", err)
+	}
+	if o.MaxFiles < 0 || o.MaxFiles > COMPARE_MAX_FILES {
+		return fmt.Errorf("max_files must be between 0 and %d", COMPARE_MAX_FILES)
+	}
+	if o.MaxPatchSize < 0 {
+		return fmt.Errorf("max_patch_size must not be negative")
+	}
+	return nil
+}
+
+// GetCommit gets a commit with its stats, changed files, verification and associated pull requests
+func GetCommit(options *GetCommitOptions, apiReqs *common.APIRequirements) (*common.CommitDetails, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	url := common.APIURL("/repos/%s/%s/commits/%s", options.Owner, options.Repo, options.Ref)
+	commit, _, err := common.TypedGitHubRequest[common.GitHubCommit](url, "GET", nil, apiReqs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting commit: %w", err)
+	}
+
+	pullsURL := common.APIURL("/repos/%s/%s/commits/%s/pulls", options.Owner, options.Repo, commit.SHA)
+	pulls, _, err := common.TypedGitHubRequest[[]common.GitHubPullRequest](pullsURL, "GET", nil, apiReqs)
+	if err != nil {
+		return nil, fmt.Errorf("error listing pull requests of the commit: %w", err)
+	}
+
+	details := &common.CommitDetails{
+		GitHubCommit: commit,
+		Verification: common.VerificationStatus{
+			Verified:      commit.Commit.Verification.Verified,
+			Reason:        commit.Commit.Verification.Reason,
+			SignatureType: signatureType(commit.Commit.Verification.Signature),
+		},
+		PullRequests: make([]common.PullRequestSummary, 0, len(pulls)),
+	}
+	details.Files, details.FilesTruncated = limitFiles(commit.Files, options.MaxFiles, options.IncludePatches, options.MaxPatchSize)
+	if len(commit.Files) >= COMPARE_MAX_FILES {
+		details.Warning = fmt.Sprintf("GitHub lists at most %d changed files, the stats cover the whole commit but some files are missing", COMPARE_MAX_FILES)
+	}
+
+	for _, pr := range pulls {
+		details.PullRequests = append(details.PullRequests, common.PullRequestSummary{
+			Number:   pr.Number,
+			Title:    pr.Title,
+			State:    pr.State,
+			Draft:    pr.Draft,
+			HTMLURL:  pr.HTMLURL,
+			User:     pr.User.Login,
+			Base:     pr.Base.Ref,
+			Head:     pr.Head.Ref,
+			MergedAt: pr.MergedAt,
+		})
+	}
+	return details, nil
+}
+
+// signatureType tells the kind of an armored commit signature from its header
+func signatureType(signature string) string {
+	switch {
+	case signature == "":
+		return ""
+	case strings.Contains(signature, "-----BEGIN PGP SIGNATURE-----"):
+		return "gpg"
+	case strings.Contains(signature, "-----BEGIN SSH SIGNATURE-----"):
+		return "ssh"
+	case strings.Contains(signature, "-----BEGIN SIGNED MESSAGE-----"):
+		return "x509"
+	default:
+		return "unknown"
+	}
 }
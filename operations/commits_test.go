@@ -1,8 +1,13 @@
 package operations
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/metoro-io/github-mcp-server-go/common"
 )
 
 func TestListCommitsOptionsValidate(t *testing.T) {
@@ -59,3 +64,123 @@ func TestListCommitsOptionsValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestCompareRefs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/octo/repo/compare/v1.2...main" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{
+			"status": "ahead", "ahead_by": 3, "behind_by": 0, "total_commits": 3,
+			"merge_base_commit": {"sha": "base"},
+			"commits": [{"sha": "c1"}, {"sha": "c2"}, {"sha": "c3"}],
+			"files": [
+				{"filename": "a.go", "status": "modified", "additions": 2, "deletions": 1, "patch": "@@ -1 +1,2 @@\n-a\n+b\n+c\n"},
+				{"filename": "b.go", "status": "added", "additions": 5, "deletions": 0, "patch": "@@ -0,0 +1 @@\n+x\n"}
+			]
+		}`)
+	}))
+	defer server.Close()
+
+	defer common.Configure(common.DefaultClientOptions())
+	common.Configure(common.ClientOptions{BaseURL: server.URL, Token: "test"})
+
+	result, err := CompareRefs(&CompareRefsOptions{
+		Owner:          "octo",
+		Repo:           "repo",
+		Base:           "v1.2",
+		Head:           "main",
+		IncludePatches: true,
+		MaxCommits:     2,
+		MaxFiles:       1,
+		MaxPatchSize:   18,
+	}, nil)
+	if err != nil {
+		t.Fatalf("CompareRefs() error = %v", err)
+	}
+
+	if result.MergeBaseSHA != "base" || result.AheadBy != 3 {
+		t.Errorf("merge base = %s, ahead by %d, want base and 3", result.MergeBaseSHA, result.AheadBy)
+	}
+	if len(result.Commits) != 2 || result.Commits[0].SHA != "c2" || !result.CommitsTruncated {
+		t.Errorf("commits = %+v, truncated %v, want the two most recent commits", result.Commits, result.CommitsTruncated)
+	}
+	if result.ChangedFiles != 2 || result.Additions != 7 || result.Deletions != 1 {
+		t.Errorf("changed files = %d, +%d -%d, want 2 files +7 -1", result.ChangedFiles, result.Additions, result.Deletions)
+	}
+	if len(result.Files) != 1 || !result.FilesTruncated {
+		t.Fatalf("files = %+v, want one file and files_truncated", result.Files)
+	}
+	if result.Files[0].Patch != "@@ -1 +1,2 @@\n-a\n" || !result.Files[0].PatchTruncated {
+		t.Errorf("patch = %q, want the patch cut after the second line", result.Files[0].Patch)
+	}
+}
+
+func TestGetCommit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octo/repo/commits/v1.0":
+			fmt.Fprint(w, `{
+				"sha": "abc",
+				"commit": {"message": "Fix bug", "verification": {"verified": true, "reason": "valid", "signature": "-----BEGIN SSH SIGNATURE-----\nAAAA\n-----END SSH SIGNATURE-----\n"}},
+				"stats": {"additions": 3, "deletions": 1, "total": 4},
+				"files": [{"filename": "main.go", "status": "modified", "additions": 3, "deletions": 1, "patch": "@@ -1 +1 @@"}]
+			}`)
+		case "/repos/octo/repo/commits/abc/pulls":
+			fmt.Fprint(w, `[{"number": 7, "title": "Fix bug", "state": "closed", "user": {"login": "mona"}, "base": {"ref": "main"}, "head": {"ref": "fix"}}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	defer common.Configure(common.DefaultClientOptions())
+	common.Configure(common.ClientOptions{BaseURL: server.URL, Token: "test"})
+
+	commit, err := GetCommit(&GetCommitOptions{Owner: "octo", Repo: "repo", Ref: "v1.0"}, nil)
+	if err != nil {
+		t.Fatalf("GetCommit() error = %v", err)
+	}
+	if commit.Stats == nil || commit.Stats.Additions != 3 || len(commit.Files) != 1 {
+		t.Errorf("stats = %+v, files = %+v, want +3 in one file", commit.Stats, commit.Files)
+	}
+	if commit.Files[0].Patch != "" {
+		t.Errorf("patch = %q, want no patch unless include_patches is set", commit.Files[0].Patch)
+	}
+	if !commit.Verification.Verified || commit.Verification.SignatureType != "ssh" {
+		t.Errorf("verification = %+v, want a verified ssh signature", commit.Verification)
+	}
+	if len(commit.PullRequests) != 1 || commit.PullRequests[0].Number != 7 || commit.PullRequests[0].Head != "fix" {
+		t.Errorf("pull requests = %+v, want #7 from fix", commit.PullRequests)
+	}
+}
+
+func TestGetCommitWithTooManyFiles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octo/repo/commits/big":
+			files := make([]string, COMPARE_MAX_FILES)
+			for i := range files {
+				files[i] = fmt.Sprintf(`{"filename": "gen/%d.go", "status": "added", "additions": 1}`, i)
+			}
+			fmt.Fprintf(w, `{"sha": "big", "stats": {"additions": 500, "total": 500}, "files": [%s]}`, strings.Join(files, ","))
+		case "/repos/octo/repo/commits/big/pulls":
+			fmt.Fprint(w, `[]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	defer common.Configure(common.DefaultClientOptions())
+	common.Configure(common.ClientOptions{BaseURL: server.URL, Token: "test"})
+
+	commit, err := GetCommit(&GetCommitOptions{Owner: "octo", Repo: "repo", Ref: "big"}, nil)
+	if err != nil {
+		t.Fatalf("GetCommit() error = %v", err)
+	}
+	if !strings.Contains(commit.Warning, "at most 300 changed files") {
+		t.Errorf("Warning = %q, want the file limit of GitHub", commit.Warning)
+	}
+}
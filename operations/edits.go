@@ -0,0 +1,177 @@
+package operations
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/metoro-io/github-mcp-server-go/common"
+)
+
+// EditFileOptions defines options for editing files with exact string replacements
+type EditFileOptions struct {
+	Owner   string     `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo    string     `json:"repo" jsonschema:"description=The name of the repository containing the files"`
+	Branch  string     `json:"branch" jsonschema:"description=The branch to commit the edits to"`
+	Message string     `json:"message" jsonschema:"description=The commit message for the edits"`
+	Files   []FileEdit `json:"files" jsonschema:"description=The files to edit. All edits are committed together in one commit"`
+}
+
+// FileEdit is a list of replacements to apply to one file
+type FileEdit struct {
+	Path        string              `json:"path" jsonschema:"description=The path to the file within the repository"`
+	ExpectedSHA string              `json:"expected_sha,omitempty" jsonschema:"description=The blob SHA the file is expected to have. The edit fails if the file has changed since"`
+	Edits       []StringReplacement `json:"edits" jsonschema:"description=Replacements applied in order. Each edit sees the result of the previous ones"`
+}
+
+// StringReplacement replaces an exact string in a file
+type StringReplacement struct {
+	OldString  string `json:"old_string" jsonschema:"description=The exact text to replace including whitespace and indentation. Must match exactly once unless replace_all is set"`
+	NewString  string `json:"new_string" jsonschema:"description=The text to replace old_string with"`
+	ReplaceAll bool   `json:"replace_all,omitempty" jsonschema:"description=Replace every occurrence of old_string instead of requiring a unique match. Default: false"`
+}
+
+// Validate validates the EditFileOptions
+func (o *EditFileOptions) Validate() error {
+	if _, err := common.ValidateOwnerName(o.Owner); err != nil {
+		return err
+	}
+	if _, err := common.ValidateRepositoryName(o.Repo); err != nil {
+		return err
+	}
+	if _, err := common.ValidateBranchName(o.Branch); err != nil {
+		return err
+	}
+	if o.Message == "" {
+		return fmt.Errorf("commit message is required")
+	}
+	if len(o.Files) == 0 {
+		return fmt.Errorf("at least one file is required")
+	}
+
+	paths := make(map[string]bool)
+	for i, file := range o.Files {
+		if file.Path == "" {
+			return fmt.Errorf("path is required for file at index %d", i)
+		}
+		if paths[file.Path] {
+			return fmt.Errorf("file %s is listed more than once, combine its edits", file.Path)
+		}
+		paths[file.Path] = true
+		if len(file.Edits) == 0 {
+			return fmt.Errorf("at least one edit is required for %s", file.Path)
+		}
+		for j, edit := range file.Edits {
+			if edit.OldString == "" {
+				return fmt.Errorf("old_string is required for edit %d of %s", j, file.Path)
+			}
+			if edit.OldString == edit.NewString {
+				return fmt.Errorf("old_string and new_string are identical for edit %d of %s", j, file.Path)
+			}
+		}
+	}
+	return nil
+}
+
+// EditFile applies exact string replacements to files on a branch and commits them in one commit
+func EditFile(options *EditFileOptions, apiReqs *common.APIRequirements) (*common.EditFileResult, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	// Read every file at the same commit the new commit will be based on
+	headSHA, err := getBranchSHA(options.Owner, options.Repo, options.Branch, apiReqs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &common.EditFileResult{}
+	pushFiles := make([]PushFileDefinition, 0, len(options.Files))
+	for _, fileEdit := range options.Files {
+		file, content, err := readTextFile(options.Owner, options.Repo, fileEdit.Path, headSHA, apiReqs)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", fileEdit.Path, err)
+		}
+		if fileEdit.ExpectedSHA != "" && fileEdit.ExpectedSHA != file.SHA {
+			return nil, &common.ConflictError{
+				Ref:         "refs/heads/" + options.Branch,
+				Path:        fileEdit.Path,
+				ExpectedSHA: fileEdit.ExpectedSHA,
+				ActualSHA:   file.SHA,
+				Reason:      "read the file again before editing",
+			}
+		}
+
+		newContent, replacements, err := applyReplacements(content, fileEdit.Path, fileEdit.Edits)
+		if err != nil {
+			return nil, err
+		}
+
+		pushFiles = append(pushFiles, PushFileDefinition{
+			Path:    fileEdit.Path,
+			Content: newContent,
+		})
+		result.Files = append(result.Files, common.EditedFile{
+			Path:         fileEdit.Path,
+			PreviousSHA:  file.SHA,
+			Replacements: replacements,
+		})
+	}
+
+	// Basing the commit on the commit the files were read from means a concurrent
+	// push makes the reference update fail instead of being overwritten
+	commit, err := commitFiles(&PushFilesOptions{
+		Owner:   options.Owner,
+		Repo:    options.Repo,
+		Branch:  options.Branch,
+		Message: options.Message,
+		Files:   pushFiles,
+		BaseSHA: headSHA,
+	}, apiReqs)
+	if err != nil {
+		return nil, fmt.Errorf("error committing edits: %w", err)
+	}
+
+	result.Commit = *commit
+	return result, nil
+}
+
+// applyReplacements applies edits to content in order and returns the new content and
+// the total number of replacements made
+func applyReplacements(content string, path string, edits []StringReplacement) (string, int, error) {
+	total := 0
+	for i, edit := range edits {
+		count := strings.Count(content, edit.OldString)
+		switch {
+		case count == 0:
+			return "", 0, fmt.Errorf("old_string of edit %d was not found in %s", i, path)
+		case count > 1 && !edit.ReplaceAll:
+			return "", 0, fmt.Errorf("old_string of edit %d matches %d times in %s, include more surrounding context to make it unique or set replace_all", i, count, path)
+		}
+		content = strings.ReplaceAll(content, edit.OldString, edit.NewString)
+		total += count
+	}
+	return content, total, nil
+}
+
+// readTextFile reads a text file at ref, failing for directories and binary files
+func readTextFile(owner string, repo string, path string, ref string, apiReqs *common.APIRequirements) (*common.FileContent, string, error) {
+	file, fileList, err := getContents(owner, repo, path, ref, apiReqs)
+	if err != nil {
+		return nil, "", err
+	}
+	if fileList != nil {
+		return nil, "", fmt.Errorf("%s is a directory", path)
+	}
+
+	data, ok, err := readFileData(owner, repo, file, apiReqs)
+	if err != nil {
+		return nil, "", err
+	}
+	if !ok {
+		return nil, "", fmt.Errorf("%s is a %s and has no text content", path, file.Type)
+	}
+	if mimeType, binary := detectContentType(data); binary {
+		return nil, "", fmt.Errorf("%s is a binary file (%s)", path, mimeType)
+	}
+	return file, string(data), nil
+}
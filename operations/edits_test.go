@@ -0,0 +1,152 @@
+package operations
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEditFileOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		options       EditFileOptions
+		wantErr       bool
+		errorContains string
+	}{
+		{
+			name: "valid edit",
+			options: EditFileOptions{
+				Owner:   "validowner",
+				Repo:    "valid-repo",
+				Branch:  "main",
+				Message: "Rename variable",
+				Files: []FileEdit{
+					{Path: "main.go", Edits: []StringReplacement{{OldString: "foo", NewString: "bar"}}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty old string",
+			options: EditFileOptions{
+				Owner:   "validowner",
+				Repo:    "valid-repo",
+				Branch:  "main",
+				Message: "Rename variable",
+				Files: []FileEdit{
+					{Path: "main.go", Edits: []StringReplacement{{NewString: "bar"}}},
+				},
+			},
+			wantErr:       true,
+			errorContains: "old_string is required",
+		},
+		{
+			name: "identical strings",
+			options: EditFileOptions{
+				Owner:   "validowner",
+				Repo:    "valid-repo",
+				Branch:  "main",
+				Message: "Rename variable",
+				Files: []FileEdit{
+					{Path: "main.go", Edits: []StringReplacement{{OldString: "foo", NewString: "foo"}}},
+				},
+			},
+			wantErr:       true,
+			errorContains: "identical",
+		},
+		{
+			name: "duplicate file",
+			options: EditFileOptions{
+				Owner:   "validowner",
+				Repo:    "valid-repo",
+				Branch:  "main",
+				Message: "Rename variable",
+				Files: []FileEdit{
+					{Path: "main.go", Edits: []StringReplacement{{OldString: "foo", NewString: "bar"}}},
+					{Path: "main.go", Edits: []StringReplacement{{OldString: "baz", NewString: "qux"}}},
+				},
+			},
+			wantErr:       true,
+			errorContains: "more than once",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.options.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil && tt.errorContains != "" {
+				if !strings.Contains(err.Error(), tt.errorContains) {
+					t.Errorf("Validate() error = %v, should contain %v", err, tt.errorContains)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyReplacements(t *testing.T) {
+	content := "a := 1\nb := a + 1\nc := a + b\n"
+
+	tests := []struct {
+		name             string
+		edits            []StringReplacement
+		want             string
+		wantReplacements int
+		errorContains    string
+	}{
+		{
+			name:             "unique match",
+			edits:            []StringReplacement{{OldString: "b := a + 1", NewString: "b := a + 2"}},
+			want:             "a := 1\nb := a + 2\nc := a + b\n",
+			wantReplacements: 1,
+		},
+		{
+			name:          "ambiguous match",
+			edits:         []StringReplacement{{OldString: "a +", NewString: "x +"}},
+			errorContains: "matches 2 times",
+		},
+		{
+			name:             "replace all",
+			edits:            []StringReplacement{{OldString: "a +", NewString: "x +", ReplaceAll: true}},
+			want:             "a := 1\nb := x + 1\nc := x + b\n",
+			wantReplacements: 2,
+		},
+		{
+			name: "edits applied in order",
+			edits: []StringReplacement{
+				{OldString: "a := 1", NewString: "a := 10"},
+				{OldString: "a := 10\n", NewString: "a := 100\n"},
+			},
+			want:             "a := 100\nb := a + 1\nc := a + b\n",
+			wantReplacements: 2,
+		},
+		{
+			name:          "not found",
+			edits:         []StringReplacement{{OldString: "d :=", NewString: "e :="}},
+			errorContains: "was not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, replacements, err := applyReplacements(content, "main.go", tt.edits)
+			if tt.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+					t.Errorf("applyReplacements() error = %v, should contain %v", err, tt.errorContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyReplacements() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("applyReplacements() = %q, want %q", got, tt.want)
+			}
+			if replacements != tt.wantReplacements {
+				t.Errorf("replacements = %d, want %d", replacements, tt.wantReplacements)
+			}
+		})
+	}
+}
@@ -1,20 +1,57 @@
 package operations
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/mail"
+	"regexp"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/gabriel-vasile/mimetype"
 	"github.com/metoro-io/github-mcp-server-go/common"
 )
 
+// Content encodings accepted and returned by the file tools
+const (
+	ENCODING_UTF8   = "utf-8"
+	ENCODING_BASE64 = "base64"
+)
+
+// Git file modes of tree entries
+const (
+	FILE_MODE_REGULAR    = "100644"
+	FILE_MODE_EXECUTABLE = "100755"
+	FILE_MODE_SYMLINK    = "120000"
+	FILE_MODE_SUBMODULE  = "160000"
+)
+
+const (
+	// MAX_FILE_CHUNK_SIZE is the number of bytes returned when a file is read without a length
+	MAX_FILE_CHUNK_SIZE = 1024 * 1024
+	// MAX_GREP_MATCHES is the number of matching lines returned by a grep
+	MAX_GREP_MATCHES = 100
+	// MAX_PUSH_ATTEMPTS is the number of times a push is rebased onto a branch that keeps moving
+	MAX_PUSH_ATTEMPTS = 3
+)
+
 // GetFileContentsOptions defines options for getting file contents
 type GetFileContentsOptions struct {
-	Owner string `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
-	Repo  string `json:"repo" jsonschema:"description=The name of the repository containing the file"`
-	Path  string `json:"path" jsonschema:"description=The path to the file or directory within the repository"`
-	Ref   string `json:"ref,omitempty" jsonschema:"description=The name of the commit/branch/tag. Default: the repository's default branch (usually main)"`
+	Owner    string `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo     string `json:"repo" jsonschema:"description=The name of the repository containing the file"`
+	Path     string `json:"path" jsonschema:"description=The path to the file or directory within the repository"`
+	Ref      string `json:"ref,omitempty" jsonschema:"description=The name of the commit/branch/tag. Default: the repository's default branch (usually main)"`
+	Encoding string `json:"encoding,omitempty" jsonschema:"description=How to return the file content. Can be one of: utf-8 base64. Default: utf-8 for text files and base64 for binary files"`
+	Offset   int    `json:"offset,omitempty" jsonschema:"description=Byte offset to start reading from. Use with length to read large files in chunks. Default: 0"`
+	Length   int    `json:"length,omitempty" jsonschema:"description=Maximum number of bytes to return. Default: the whole file up to 1 MB"`
+	// Line selection returns line-numbered excerpts instead of the raw content
+	StartLine    int    `json:"start_line,omitempty" jsonschema:"description=First line to return (1-based). Returns line-numbered excerpts instead of the file content. Default: 1"`
+	EndLine      int    `json:"end_line,omitempty" jsonschema:"description=Last line to return (inclusive). Default: the last line"`
+	Grep         string `json:"grep,omitempty" jsonschema:"description=Regular expression (Go syntax). Only matching lines within the line range are returned as line-numbered excerpts"`
+	ContextLines int    `json:"context_lines,omitempty" jsonschema:"description=Number of lines of context to include around each grep match. Default: 0"`
 }
 
 // Validate validates the GetFileContentsOptions
@@ -28,26 +65,131 @@ func (o *GetFileContentsOptions) Validate() error {
 	if o.Path == "" {
 		return fmt.Errorf("path is required")
 	}
-	return nil
+	if o.Offset < 0 {
+		return fmt.Errorf("offset must not be negative")
+	}
+	if o.Length < 0 {
+		return fmt.Errorf("length must not be negative")
+	}
+	if o.StartLine < 0 || o.EndLine < 0 || o.ContextLines < 0 {
+		return fmt.Errorf("start_line, end_line and context_lines must not be negative")
+	}
+	if o.EndLine > 0 && o.StartLine > o.EndLine {
+		return fmt.Errorf("start_line must not be greater than end_line")
+	}
+	if o.Grep != "" {
+		if _, err := regexp.Compile(o.Grep); err != nil {
+			return fmt.Errorf("invalid grep pattern: %w", err)
+		}
+	}
+	if o.selectsLines() && (o.Offset > 0 || o.Length > 0) {
+		return fmt.Errorf("offset and length cannot be combined with start_line, end_line or grep")
+	}
+	return validateEncoding(o.Encoding)
+}
+
+// selectsLines returns true if the options ask for line-numbered excerpts
+func (o *GetFileContentsOptions) selectsLines() bool {
+	return o.StartLine > 0 || o.EndLine > 0 || o.Grep != ""
 }
 
 // CreateOrUpdateFileOptions defines options for creating or updating a file
 type CreateOrUpdateFileOptions struct {
-	Owner     string         `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
-	Repo      string         `json:"repo" jsonschema:"description=The name of the repository where the file will be created or updated"`
-	Path      string         `json:"path" jsonschema:"description=The path to the file within the repository"`
-	Message   string         `json:"message" jsonschema:"description=The commit message for the file creation or update"`
-	Content   string         `json:"content" jsonschema:"description=The new content of the file as a string"`
-	Branch    string         `json:"branch,omitempty" jsonschema:"description=The branch name to commit to. Default: the repository's default branch (usually main)"`
-	SHA       string         `json:"sha,omitempty" jsonschema:"description=The blob SHA of the file being replaced if updating an existing file"`
-	Committer *CommitterInfo `json:"committer,omitempty" jsonschema:"description=Information about the committer. If omitted the authenticated user's information is used"`
-	Author    *CommitterInfo `json:"author,omitempty" jsonschema:"description=Information about the author. If omitted the committer information is used"`
+	Owner     string          `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo      string          `json:"repo" jsonschema:"description=The name of the repository where the file will be created or updated"`
+	Path      string          `json:"path" jsonschema:"description=The path to the file within the repository"`
+	Message   string          `json:"message" jsonschema:"description=The commit message for the file creation or update"`
+	Content   string          `json:"content" jsonschema:"description=The new content of the file as a string"`
+	Encoding  string          `json:"encoding,omitempty" jsonschema:"description=The encoding of content. Can be one of: utf-8 base64. Use base64 for binary files. Default: utf-8"`
+	Branch    string          `json:"branch,omitempty" jsonschema:"description=The branch name to commit to. Default: the repository's default branch (usually main)"`
+	SHA       string          `json:"sha,omitempty" jsonschema:"description=The blob SHA of the file being replaced if updating an existing file"`
+	Committer *CommitterInfo  `json:"committer,omitempty" jsonschema:"description=Information about the committer. If omitted the authenticated user's information is used"`
+	Author    *CommitterInfo  `json:"author,omitempty" jsonschema:"description=Information about the author. If omitted the committer information is used"`
+	CoAuthors []CommitterInfo `json:"co_authors,omitempty" jsonschema:"description=People credited with Co-authored-by trailers appended to the commit message"`
+
+	CreateBranchFrom string `json:"create_branch_from,omitempty" jsonschema:"description=If branch does not exist create it from this branch tag or commit SHA first"`
 }
 
 // CommitterInfo represents author/committer information
 type CommitterInfo struct {
 	Name  string `json:"name" jsonschema:"description=The name of the author or committer"`
 	Email string `json:"email" jsonschema:"description=The email of the author or committer"`
+	Date  string `json:"date,omitempty" jsonschema:"description=The date of the authorship or commit. ISO 8601 format: YYYY-MM-DDTHH:MM:SSZ. Default: the current time"`
+}
+
+// validate checks that the name and email can be written to a commit header or trailer
+func (c *CommitterInfo) validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if strings.ContainsAny(c.Name, "<>\r\n") {
+		return fmt.Errorf("name %q cannot contain <, > or line breaks", c.Name)
+	}
+	address, err := mail.ParseAddress(c.Email)
+	if err != nil || address.Address != c.Email {
+		return fmt.Errorf("email %q is not a valid email address", c.Email)
+	}
+	if c.Date != "" {
+		if _, err := time.Parse(time.RFC3339, c.Date); err != nil {
+			return fmt.Errorf("date %q must be in ISO 8601 format: YYYY-MM-DDTHH:MM:SSZ", c.Date)
+		}
+	}
+	return nil
+}
+
+// validateCommitMetadata validates the author, committer and co-authors of a commit
+func validateCommitMetadata(author *CommitterInfo, committer *CommitterInfo, coAuthors []CommitterInfo) error {
+	if author != nil {
+		if err := author.validate(); err != nil {
+			return fmt.Errorf("invalid author: %w", err)
+		}
+	}
+	if committer != nil {
+		if err := committer.validate(); err != nil {
+			return fmt.Errorf("invalid committer: %w", err)
+		}
+	}
+	for i, coAuthor := range coAuthors {
+		if err := coAuthor.validate(); err != nil {
+			return fmt.Errorf("invalid co-author at index %d: %w", i, err)
+		}
+		if coAuthor.Date != "" {
+			return fmt.Errorf("invalid co-author at index %d: co-authors have no date", i)
+		}
+	}
+	return nil
+}
+
+// trailerPattern matches a git trailer line such as "Signed-off-by: Name <email>"
+var trailerPattern = regexp.MustCompile(`^[A-Za-z0-9-]+: \S`)
+
+// addCoAuthorTrailers appends a Co-authored-by trailer for each co-author not already credited
+// in message. Trailers join an existing trailer block or start a new paragraph.
+func addCoAuthorTrailers(message string, coAuthors []CommitterInfo) string {
+	var trailers []string
+	for _, coAuthor := range coAuthors {
+		trailer := fmt.Sprintf("Co-authored-by: %s <%s>", coAuthor.Name, coAuthor.Email)
+		if !strings.Contains(message, trailer) {
+			trailers = append(trailers, trailer)
+		}
+	}
+	if len(trailers) == 0 {
+		return message
+	}
+
+	message = strings.TrimRight(message, "\n")
+	paragraphs := strings.Split(message, "\n\n")
+	separator := "\n\n"
+	if last := paragraphs[len(paragraphs)-1]; len(paragraphs) > 1 {
+		separator = "\n"
+		for _, line := range strings.Split(last, "\n") {
+			if !trailerPattern.MatchString(line) {
+				separator = "\n\n"
+				break
+			}
+		}
+	}
+	return message + separator + strings.Join(trailers, "\n")
 }
 
 // Validate validates the CreateOrUpdateFileOptions
@@ -72,7 +214,21 @@ func (o *CreateOrUpdateFileOptions) Validate() error {
 			return err
 		}
 	}
-	return nil
+	if o.CreateBranchFrom != "" {
+		if o.Branch == "" {
+			return fmt.Errorf("branch is required when create_branch_from is set")
+		}
+		if _, err := common.ValidateBranchName(o.CreateBranchFrom); err != nil {
+			return fmt.Errorf("invalid create_branch_from: %w", err)
+		}
+	}
+	if err := validateEncoding(o.Encoding); err != nil {
+		return err
+	}
+	if _, err := decodeContent(o.Content, o.Encoding); err != nil {
+		return err
+	}
+	return validateCommitMetadata(o.Author, o.Committer, o.CoAuthors)
 }
 
 // PushFilesOptions defines options for pushing multiple files
@@ -83,13 +239,89 @@ type PushFilesOptions struct {
 	Message string               `json:"message" jsonschema:"description=The commit message for this push operation"`
 	Files   []PushFileDefinition `json:"files" jsonschema:"description=Array of files to create update or delete in this push operation"`
 	BaseSHA string               `json:"base_sha,omitempty" jsonschema:"description=The SHA of the base commit to apply changes to. Default: latest commit on the specified branch"`
+
+	ExpectedHeadSHA string `json:"expected_head_sha,omitempty" jsonschema:"description=The commit SHA the branch is expected to point to. The push fails with a conflict if the branch has moved unless rebase is set"`
+	Rebase          bool   `json:"rebase,omitempty" jsonschema:"description=If the branch has moved re-apply the file changes onto the new head when none of the files were changed there. Default: false"`
+
+	CreateBranchFrom string `json:"create_branch_from,omitempty" jsonschema:"description=If branch does not exist create it from this branch tag or commit SHA with the new commit on top"`
+
+	Author    *CommitterInfo  `json:"author,omitempty" jsonschema:"description=Information about the author such as the person who requested the change. Default: the committer"`
+	Committer *CommitterInfo  `json:"committer,omitempty" jsonschema:"description=Information about the committer. Default: the authenticated user"`
+	CoAuthors []CommitterInfo `json:"co_authors,omitempty" jsonschema:"description=People credited with Co-authored-by trailers appended to the commit message"`
 }
 
 // PushFileDefinition represents a file to push
 type PushFileDefinition struct {
-	Path    string `json:"path" jsonschema:"description=The path to the file within the repository"`
-	Content string `json:"content" jsonschema:"description=The content of the file as a string. Required unless Delete is true"`
-	Delete  bool   `json:"delete,omitempty" jsonschema:"description=Whether to delete this file. If true Content is not required"`
+	Path          string `json:"path" jsonschema:"description=The path to the file within the repository"`
+	Content       string `json:"content" jsonschema:"description=The content of the file as a string. Required unless delete or rename_from or symlink_target or submodule_sha is set"`
+	Encoding      string `json:"encoding,omitempty" jsonschema:"description=The encoding of content. Can be one of: utf-8 base64. Use base64 for binary files. Default: utf-8"`
+	Delete        bool   `json:"delete,omitempty" jsonschema:"description=Whether to delete this file. If true Content is not required"`
+	Mode          string `json:"mode,omitempty" jsonschema:"description=The file mode. Can be one of: 100644 (file) 100755 (executable) 120000 (symlink) 160000 (submodule). Default: the mode of the existing file or 100644"`
+	RenameFrom    string `json:"rename_from,omitempty" jsonschema:"description=Move the file from this path. Without content the file is moved unchanged"`
+	SymlinkTarget string `json:"symlink_target,omitempty" jsonschema:"description=Create a symbolic link at path pointing to this target instead of a regular file"`
+	SubmoduleSHA  string `json:"submodule_sha,omitempty" jsonschema:"description=Point the submodule at path to this commit SHA"`
+
+	// blobSHA points path at an existing blob with Mode, for changes copied from other commits
+	blobSHA string
+}
+
+var commitSHAPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// validate checks the combination of options of a single file
+func (f *PushFileDefinition) validate() error {
+	if err := validateEncoding(f.Encoding); err != nil {
+		return err
+	}
+
+	if f.Delete {
+		if f.Content != "" || f.Mode != "" || f.RenameFrom != "" || f.SymlinkTarget != "" || f.SubmoduleSHA != "" {
+			return fmt.Errorf("delete cannot be combined with content, mode, rename_from, symlink_target or submodule_sha")
+		}
+		return nil
+	}
+
+	if f.SymlinkTarget != "" && f.SubmoduleSHA != "" {
+		return fmt.Errorf("symlink_target and submodule_sha cannot be combined")
+	}
+	if f.Content != "" && (f.SymlinkTarget != "" || f.SubmoduleSHA != "") {
+		return fmt.Errorf("content cannot be combined with symlink_target or submodule_sha")
+	}
+	if f.SubmoduleSHA != "" && f.RenameFrom != "" {
+		return fmt.Errorf("rename_from cannot be combined with submodule_sha")
+	}
+	if f.SubmoduleSHA != "" && !commitSHAPattern.MatchString(f.SubmoduleSHA) {
+		return fmt.Errorf("submodule_sha must be a full 40 character commit SHA")
+	}
+	if f.RenameFrom != "" && f.RenameFrom == f.Path {
+		return fmt.Errorf("rename_from must differ from path")
+	}
+
+	switch f.Mode {
+	case "":
+	case FILE_MODE_REGULAR, FILE_MODE_EXECUTABLE:
+		if f.SymlinkTarget != "" || f.SubmoduleSHA != "" {
+			return fmt.Errorf("mode %s cannot be used for symlinks or submodules", f.Mode)
+		}
+	case FILE_MODE_SYMLINK:
+		if f.SymlinkTarget == "" {
+			return fmt.Errorf("mode %s requires symlink_target", f.Mode)
+		}
+	case FILE_MODE_SUBMODULE:
+		if f.SubmoduleSHA == "" {
+			return fmt.Errorf("mode %s requires submodule_sha", f.Mode)
+		}
+	default:
+		return fmt.Errorf("mode must be one of %s, %s, %s or %s, got %q",
+			FILE_MODE_REGULAR, FILE_MODE_EXECUTABLE, FILE_MODE_SYMLINK, FILE_MODE_SUBMODULE, f.Mode)
+	}
+
+	if f.Content == "" && f.RenameFrom == "" && f.SymlinkTarget == "" && f.SubmoduleSHA == "" {
+		return fmt.Errorf("content is required unless delete, rename_from, symlink_target or submodule_sha is set")
+	}
+	if _, err := decodeContent(f.Content, f.Encoding); err != nil {
+		return err
+	}
+	return nil
 }
 
 // Validate validates the PushFilesOptions
@@ -109,112 +341,174 @@ func (o *PushFilesOptions) Validate() error {
 	if len(o.Files) == 0 {
 		return fmt.Errorf("at least one file is required")
 	}
+	// Each path may only be touched once, including the sources of renames
+	touched := make(map[string]bool)
 	for i, file := range o.Files {
 		if file.Path == "" {
 			return fmt.Errorf("path is required for file at index %d", i)
 		}
-		if !file.Delete && file.Content == "" {
-			return fmt.Errorf("content is required for non-deleted file at index %d", i)
+		if err := file.validate(); err != nil {
+			return fmt.Errorf("invalid file at index %d: %w", i, err)
+		}
+		for _, path := range []string{file.Path, file.RenameFrom} {
+			if path == "" {
+				continue
+			}
+			if touched[path] {
+				return fmt.Errorf("invalid file at index %d: %s is changed by more than one file entry", i, path)
+			}
+			touched[path] = true
 		}
 	}
-	return nil
+	if o.ExpectedHeadSHA != "" {
+		if !commitSHAPattern.MatchString(o.ExpectedHeadSHA) {
+			return fmt.Errorf("expected_head_sha must be a full 40 character commit SHA")
+		}
+		if o.BaseSHA != "" && o.BaseSHA != o.ExpectedHeadSHA {
+			return fmt.Errorf("base_sha and expected_head_sha cannot differ, the commit is based on expected_head_sha")
+		}
+	}
+	if o.CreateBranchFrom != "" {
+		if _, err := common.ValidateBranchName(o.CreateBranchFrom); err != nil {
+			return fmt.Errorf("invalid create_branch_from: %w", err)
+		}
+		if o.BaseSHA != "" || o.ExpectedHeadSHA != "" {
+			return fmt.Errorf("create_branch_from cannot be combined with base_sha or expected_head_sha")
+		}
+	}
+	return validateCommitMetadata(o.Author, o.Committer, o.CoAuthors)
+}
+
+// validateEncoding checks that an encoding option is empty or one of the supported encodings
+func validateEncoding(encoding string) error {
+	switch encoding {
+	case "", ENCODING_UTF8, ENCODING_BASE64:
+		return nil
+	default:
+		return fmt.Errorf("encoding must be %s or %s, got %q", ENCODING_UTF8, ENCODING_BASE64, encoding)
+	}
+}
+
+// decodeContent returns the raw bytes of content given in the specified encoding
+func decodeContent(content string, encoding string) ([]byte, error) {
+	if encoding != ENCODING_BASE64 {
+		return []byte(content), nil
+	}
+	// Line-wrapped base64 is accepted, as returned by the contents API
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(content), ""))
+	if err != nil {
+		return nil, fmt.Errorf("content is not valid base64: %w", err)
+	}
+	return data, nil
+}
+
+// detectContentType returns the MIME type of data and whether it has to be treated as binary
+func detectContentType(data []byte) (string, bool) {
+	mtype := mimetype.Detect(data)
+	isText := false
+	for m := mtype; m != nil; m = m.Parent() {
+		if m.Is("text/plain") {
+			isText = true
+			break
+		}
+	}
+	return mtype.String(), !isText || !utf8.Valid(data)
 }
 
 // GetFileContents gets the contents of a file from a GitHub repository
 func GetFileContents(options *GetFileContentsOptions, apiReqs *common.APIRequirements) (interface{}, error) {
 	if err := options.Validate(); err != nil {
-		return nil, err
+		return nil, common.InvalidInput(err)
 	}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s", options.Owner, options.Repo, options.Path)
-	if options.Ref != "" {
-		params := map[string]string{
-			"ref": options.Ref,
-		}
-		var err error
-		url, err = common.BuildURL(url, params)
-		if err != nil {
-			return nil, err
-		}
+	fileContent, fileList, err := getContents(options.Owner, options.Repo, options.Path, options.Ref, apiReqs)
+	if err != nil {
+		return nil, err
+	}
+	if fileList != nil {
+		return fileList, nil
 	}
 
-	resp, err := common.GitHubRequest(url, "GET", nil, apiReqs)
+	decodedContent, ok, err := readFileData(options.Owner, options.Repo, fileContent, apiReqs)
 	if err != nil {
 		return nil, err
 	}
+	if !ok {
+		// Symlinks and submodules have no content to decode
+		return *fileContent, nil
+	}
 
-	// Handle both single file and directory responses
-	switch content := resp.(type) {
-	case []interface{}:
-		// This is a directory
-		var fileList []common.FileContent
-		jsonData, err := json.Marshal(content)
-		if err != nil {
-			return nil, err
-		}
-		if err := json.Unmarshal(jsonData, &fileList); err != nil {
-			return nil, err
-		}
-		return fileList, nil
-	case map[string]interface{}:
-		// This is a file
-		var fileContent common.FileContent
-		jsonData, err := json.Marshal(content)
-		if err != nil {
-			return nil, err
-		}
-		if err := json.Unmarshal(jsonData, &fileContent); err != nil {
-			return nil, err
-		}
+	mimeType, binary := detectContentType(decodedContent)
+	fileContent.MimeType = mimeType
+	if !binary {
+		fileContent.LineCount = countLines(decodedContent)
+	}
 
-		// If the file is binary, just return it as is
-		if fileContent.Encoding != "base64" {
-			return fileContent, nil
+	if options.selectsLines() {
+		if binary {
+			return nil, fmt.Errorf("%s is a binary file (%s) and cannot be read by line", options.Path, mimeType)
 		}
+		return extractLines(fileContent, string(decodedContent), options)
+	}
 
-		// Decode the content if it's base64 encoded
-		decodedContent, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(fileContent.Content, "\n", ""))
-		if err != nil {
-			return nil, fmt.Errorf("error decoding base64 content: %w", err)
-		}
-		fileContent.Content = string(decodedContent)
-		return fileContent, nil
+	decodedContent, fileContent.Range, err = sliceContent(decodedContent, options.Offset, options.Length, !binary)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case options.Encoding == ENCODING_BASE64 || (options.Encoding == "" && binary):
+		// Re-encode without the line breaks GitHub inserts every 60 characters
+		fileContent.Content = base64.StdEncoding.EncodeToString(decodedContent)
+		fileContent.Encoding = ENCODING_BASE64
+	case binary:
+		return nil, fmt.Errorf("%s is a binary file (%s) and cannot be returned as %s, use encoding %s instead",
+			options.Path, mimeType, ENCODING_UTF8, ENCODING_BASE64)
 	default:
-		return nil, fmt.Errorf("unexpected response type: %T", resp)
+		fileContent.Content = string(decodedContent)
+		fileContent.Encoding = ENCODING_UTF8
 	}
+	return *fileContent, nil
 }
 
 // CreateOrUpdateFile creates or updates a file in a GitHub repository
 func CreateOrUpdateFile(options *CreateOrUpdateFileOptions, apiReqs *common.APIRequirements) (*common.FileContent, error) {
 	if err := options.Validate(); err != nil {
-		return nil, err
+		return nil, common.InvalidInput(err)
+	}
+
+	if options.CreateBranchFrom != "" {
+		if err := ensureBranch(options.Owner, options.Repo, options.Branch, options.CreateBranchFrom, apiReqs); err != nil {
+			return nil, err
+		}
 	}
 
 	// First, check if the file exists to get its SHA (for update)
 	if options.SHA == "" {
-		getOptions := &GetFileContentsOptions{
-			Owner: options.Owner,
-			Repo:  options.Repo,
-			Path:  options.Path,
-			Ref:   options.Branch,
-		}
-		existingFile, err := GetFileContents(getOptions, apiReqs)
+		// Only the metadata is needed, so large files are not downloaded
+		existingFile, _, err := getContents(options.Owner, options.Repo, options.Path, options.Branch, apiReqs)
 		if err == nil {
 			// File exists, get its SHA
-			if fileContent, ok := existingFile.(common.FileContent); ok {
-				options.SHA = fileContent.SHA
+			if existingFile != nil {
+				options.SHA = existingFile.SHA
 			}
+		} else if !common.IsNotFound(err) {
+			return nil, fmt.Errorf("error checking for existing file: %w", err)
 		}
 		// If the file doesn't exist, that's fine - we'll create it
 	}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s", options.Owner, options.Repo, options.Path)
+	url := common.APIURL("/repos/%s/%s/contents/%s", options.Owner, options.Repo, options.Path)
 
-	// Encode the content as base64
-	content := base64.StdEncoding.EncodeToString([]byte(options.Content))
+	// The contents API always takes base64, already encoded content is normalised by decoding it first
+	data, err := decodeContent(options.Content, options.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	content := base64.StdEncoding.EncodeToString(data)
 
 	requestBody := map[string]interface{}{
-		"message": options.Message,
+		"message": addCoAuthorTrailers(options.Message, options.CoAuthors),
 		"content": content,
 	}
 
@@ -234,171 +528,580 @@ func CreateOrUpdateFile(options *CreateOrUpdateFileOptions, apiReqs *common.APIR
 		requestBody["author"] = options.Author
 	}
 
-	resp, err := common.GitHubRequest(url, "PUT", requestBody, apiReqs)
+	resp, _, err := common.TypedGitHubRequest[common.FileCommitResponse](url, "PUT", requestBody, apiReqs)
 	if err != nil {
 		return nil, err
 	}
 
-	respMap, ok := resp.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("unexpected response type: %T", resp)
+	if resp.Content == nil {
+		return nil, fmt.Errorf("content not found in response")
 	}
 
-	contentObj, ok := respMap["content"]
-	if !ok {
-		return nil, fmt.Errorf("content not found in response")
+	return resp.Content, nil
+}
+
+// PushFiles pushes multiple files to a GitHub repository in a single commit
+func PushFiles(options *PushFilesOptions, apiReqs *common.APIRequirements) (*common.GitCommit, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
 	}
+	return commitFiles(options, apiReqs)
+}
 
-	var fileContent common.FileContent
-	jsonData, err := json.Marshal(contentObj)
+// commitFiles creates a commit with the file changes of options and moves the branch to it.
+// Unlike PushFiles it allows empty file content, for tools that compute the new content themselves.
+func commitFiles(options *PushFilesOptions, apiReqs *common.APIRequirements) (*common.GitCommit, error) {
+	// First, get the latest commit SHA for the branch
+	headSHA, err := getBranchSHA(options.Owner, options.Repo, options.Branch, apiReqs)
 	if err != nil {
+		if options.CreateBranchFrom != "" && common.IsNotFound(err) {
+			return commitToNewBranch(options, apiReqs)
+		}
 		return nil, err
 	}
 
-	if err := json.Unmarshal(jsonData, &fileContent); err != nil {
-		return nil, err
+	parentSHA := headSHA
+	switch {
+	case options.ExpectedHeadSHA != "":
+		parentSHA = options.ExpectedHeadSHA
+	case options.BaseSHA != "":
+		parentSHA = options.BaseSHA
 	}
 
-	return &fileContent, nil
-}
-
-// PushFiles pushes multiple files to a GitHub repository in a single commit
-func PushFiles(options *PushFilesOptions, apiReqs *common.APIRequirements) (interface{}, error) {
-	if err := options.Validate(); err != nil {
-		return nil, err
+	// A base_sha ahead of the branch still fast-forwards it, so only expected_head_sha is checked up front
+	if options.ExpectedHeadSHA != "" && headSHA != options.ExpectedHeadSHA {
+		parentSHA, err = rebaseOnto(options, options.ExpectedHeadSHA, headSHA, apiReqs)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// First, get the latest commit SHA for the branch
-	baseSHA := options.BaseSHA
-	if baseSHA == "" {
-		url := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/refs/heads/%s",
-			options.Owner, options.Repo, options.Branch)
-		resp, err := common.GitHubRequest(url, "GET", nil, apiReqs)
+	for attempt := 1; ; attempt++ {
+		newCommit, err := createFilesCommit(options, parentSHA, apiReqs)
 		if err != nil {
-			return nil, fmt.Errorf("error getting branch reference: %w", err)
+			return nil, err
 		}
 
-		respMap, ok := resp.(map[string]interface{})
-		if !ok {
-			return nil, fmt.Errorf("unexpected response type: %T", resp)
+		// Update the reference without force, so GitHub rejects it if the branch has moved
+		updateRefURL := common.APIURL("/repos/%s/%s/git/refs/heads/%s",
+			options.Owner, options.Repo, options.Branch)
+		updateRefBody := map[string]interface{}{
+			"sha": newCommit.SHA,
 		}
 
-		objectMap, ok := respMap["object"].(map[string]interface{})
-		if !ok {
-			return nil, fmt.Errorf("object not found in response")
+		_, _, err = common.TypedGitHubRequest[common.GitHubRef](updateRefURL, "PATCH", updateRefBody, apiReqs)
+		if err == nil {
+			return newCommit, nil
+		}
+		if !common.IsValidationError(err) && !common.IsConflict(err) {
+			return nil, fmt.Errorf("error updating reference: %w", err)
 		}
 
-		sha, ok := objectMap["sha"].(string)
-		if !ok {
-			return nil, fmt.Errorf("sha not found in response")
+		// A rejected update is only a conflict if someone else moved the branch
+		currentSHA, headErr := getBranchSHA(options.Owner, options.Repo, options.Branch, apiReqs)
+		if headErr != nil || currentSHA == parentSHA {
+			return nil, fmt.Errorf("error updating reference: %w", err)
+		}
+		if attempt == MAX_PUSH_ATTEMPTS {
+			return nil, &common.ConflictError{
+				Ref:         "refs/heads/" + options.Branch,
+				ExpectedSHA: parentSHA,
+				ActualSHA:   currentSHA,
+				Reason:      fmt.Sprintf("the branch moved during each of %d attempts", MAX_PUSH_ATTEMPTS),
+			}
+		}
+		parentSHA, err = rebaseOnto(options, parentSHA, currentSHA, apiReqs)
+		if err != nil {
+			return nil, err
 		}
-		baseSHA = sha
 	}
+}
 
-	// Get the base tree
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/commits/%s",
-		options.Owner, options.Repo, baseSHA)
-	resp, err := common.GitHubRequest(url, "GET", nil, apiReqs)
+// commitToNewBranch commits the file changes of options on top of CreateBranchFrom and
+// creates the branch pointing to the new commit, so a failed push leaves no empty branch behind
+func commitToNewBranch(options *PushFilesOptions, apiReqs *common.APIRequirements) (*common.GitCommit, error) {
+	parentSHA, err := resolveCommitSHA(options.Owner, options.Repo, options.CreateBranchFrom, apiReqs)
 	if err != nil {
-		return nil, fmt.Errorf("error getting commit: %w", err)
+		return nil, err
 	}
 
-	respMap, ok := resp.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("unexpected response type: %T", resp)
+	newCommit, err := createFilesCommit(options, parentSHA, apiReqs)
+	if err != nil {
+		return nil, err
+	}
+	if err := createBranchRef(options.Owner, options.Repo, options.Branch, newCommit.SHA, apiReqs); err != nil {
+		return nil, err
 	}
+	return newCommit, nil
+}
 
-	treeMap, ok := respMap["tree"].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("tree not found in response")
+// ensureBranch creates branch from the branch, tag or commit SHA from unless it already exists
+func ensureBranch(owner string, repo string, branch string, from string, apiReqs *common.APIRequirements) error {
+	_, err := getBranchSHA(owner, repo, branch, apiReqs)
+	if err == nil || !common.IsNotFound(err) {
+		return err
 	}
 
-	baseTreeSHA, ok := treeMap["sha"].(string)
-	if !ok {
+	sha, err := resolveCommitSHA(owner, repo, from, apiReqs)
+	if err != nil {
+		return err
+	}
+	return createBranchRef(owner, repo, branch, sha, apiReqs)
+}
+
+// createFilesCommit creates a commit with the file changes of options on top of parentSHA
+func createFilesCommit(options *PushFilesOptions, parentSHA string, apiReqs *common.APIRequirements) (*common.GitCommit, error) {
+	// Get the base tree
+	url := common.APIURL("/repos/%s/%s/git/commits/%s",
+		options.Owner, options.Repo, parentSHA)
+	baseCommit, _, err := common.TypedGitHubRequest[common.GitCommit](url, "GET", nil, apiReqs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting commit: %w", err)
+	}
+	if baseCommit.Tree.SHA == "" {
 		return nil, fmt.Errorf("tree sha not found in response")
 	}
 
 	// Create a new tree with the changes
+	lookup := newTreeLookup(options.Owner, options.Repo, baseCommit.Tree.SHA, apiReqs)
 	var treeItems []map[string]interface{}
 	for _, file := range options.Files {
-		if file.Delete {
-			// To delete a file, we omit the content and set the sha to null
-			treeItems = append(treeItems, map[string]interface{}{
-				"path": file.Path,
-				"mode": "100644",
-				"type": "blob",
-				"sha":  nil,
-			})
-		} else {
-			// For new or updated files, we include the content
-			treeItems = append(treeItems, map[string]interface{}{
-				"path":    file.Path,
-				"mode":    "100644",
-				"type":    "blob",
-				"content": file.Content,
-			})
+		items, err := buildTreeItems(options.Owner, options.Repo, file, lookup, apiReqs)
+		if err != nil {
+			return nil, err
 		}
+		treeItems = append(treeItems, items...)
 	}
 
 	// Create a tree
-	createTreeURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/trees",
+	createTreeURL := common.APIURL("/repos/%s/%s/git/trees",
 		options.Owner, options.Repo)
 	createTreeBody := map[string]interface{}{
-		"base_tree": baseTreeSHA,
+		"base_tree": baseCommit.Tree.SHA,
 		"tree":      treeItems,
 	}
 
-	treeResp, err := common.GitHubRequest(createTreeURL, "POST", createTreeBody, apiReqs)
+	newTree, _, err := common.TypedGitHubRequest[common.GitHubTree](createTreeURL, "POST", createTreeBody, apiReqs)
 	if err != nil {
 		return nil, fmt.Errorf("error creating tree: %w", err)
 	}
-
-	treeRespMap, ok := treeResp.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("unexpected tree response type: %T", treeResp)
-	}
-
-	newTreeSHA, ok := treeRespMap["sha"].(string)
-	if !ok {
+	if newTree.SHA == "" {
 		return nil, fmt.Errorf("new tree sha not found in response")
 	}
 
-	// Create a commit
-	createCommitURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/commits",
-		options.Owner, options.Repo)
+	message := addCoAuthorTrailers(options.Message, options.CoAuthors)
+	return createCommit(options.Owner, options.Repo, message, newTree.SHA, parentSHA, options.Author, options.Committer, apiReqs)
+}
+
+// createCommit creates a commit object for a tree, signed if a signing key is configured
+func createCommit(owner string, repo string, message string, treeSHA string, parentSHA string, author *CommitterInfo, committer *CommitterInfo, apiReqs *common.APIRequirements) (*common.GitCommit, error) {
+	createCommitURL := common.APIURL("/repos/%s/%s/git/commits", owner, repo)
 	createCommitBody := map[string]interface{}{
-		"message": options.Message,
-		"tree":    newTreeSHA,
-		"parents": []string{baseSHA},
+		"message": message,
+		"tree":    treeSHA,
+		"parents": []string{parentSHA},
+	}
+	if signer := common.CurrentClientOptions().Signer; signer != nil {
+		if err := signCommit(createCommitBody, signer, author, committer, time.Now()); err != nil {
+			return nil, err
+		}
+	} else {
+		if author != nil {
+			createCommitBody["author"] = author
+		}
+		if committer != nil {
+			createCommitBody["committer"] = committer
+		}
 	}
 
-	commitResp, err := common.GitHubRequest(createCommitURL, "POST", createCommitBody, apiReqs)
+	newCommit, _, err := common.TypedGitHubRequest[common.GitCommit](createCommitURL, "POST", createCommitBody, apiReqs)
 	if err != nil {
 		return nil, fmt.Errorf("error creating commit: %w", err)
 	}
+	if newCommit.SHA == "" {
+		return nil, fmt.Errorf("new commit sha not found in response")
+	}
+	return &newCommit, nil
+}
 
-	commitRespMap, ok := commitResp.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("unexpected commit response type: %T", commitResp)
+// rebaseOnto checks that the file changes of options, made on top of fromSHA, can be
+// re-applied onto the branch head toSHA and returns toSHA as the new parent.
+// It returns a ConflictError when rebase is off or the branch changed the same paths.
+func rebaseOnto(options *PushFilesOptions, fromSHA string, toSHA string, apiReqs *common.APIRequirements) (string, error) {
+	conflict := &common.ConflictError{
+		Ref:         "refs/heads/" + options.Branch,
+		ExpectedSHA: fromSHA,
+		ActualSHA:   toSHA,
+	}
+	if !options.Rebase {
+		conflict.Reason = "the branch has moved, set rebase to re-apply the changes onto the new head"
+		return "", conflict
 	}
 
-	newCommitSHA, ok := commitRespMap["sha"].(string)
-	if !ok {
-		return nil, fmt.Errorf("new commit sha not found in response")
+	comparison, err := compareCommits(options.Owner, options.Repo, fromSHA, toSHA, apiReqs)
+	if err != nil {
+		return "", err
+	}
+	if comparison.Status != "ahead" {
+		conflict.Reason = fmt.Sprintf("the branch was rewritten and no longer contains %s", fromSHA)
+		return "", conflict
+	}
+	if len(comparison.Files) >= COMPARE_MAX_FILES {
+		conflict.Reason = fmt.Sprintf("the branch changed %d or more files and the changes cannot be checked for overlaps", COMPARE_MAX_FILES)
+		return "", conflict
+	}
+
+	var changed []string
+	for _, file := range comparison.Files {
+		changed = append(changed, file.Filename)
+		if file.PreviousFilename != "" {
+			changed = append(changed, file.PreviousFilename)
+		}
+	}
+	var touched []string
+	for _, file := range options.Files {
+		touched = append(touched, file.Path)
+		if file.RenameFrom != "" {
+			touched = append(touched, file.RenameFrom)
+		}
+	}
+	if paths := overlappingPaths(touched, changed); len(paths) > 0 {
+		conflict.ConflictingPaths = paths
+		conflict.Reason = "the branch changed the same files"
+		return "", conflict
+	}
+	return toSHA, nil
+}
+
+// overlappingPaths returns the paths of touched that are, contain or are contained in a path of changed
+func overlappingPaths(touched []string, changed []string) []string {
+	var overlaps []string
+	for _, t := range touched {
+		for _, c := range changed {
+			if t == c || strings.HasPrefix(t, c+"/") || strings.HasPrefix(c, t+"/") {
+				overlaps = append(overlaps, t)
+				break
+			}
+		}
+	}
+	return overlaps
+}
+
+// getContents fetches a path from the contents API. Either the file or, for a directory, its listing is returned.
+func getContents(owner string, repo string, path string, ref string, apiReqs *common.APIRequirements) (*common.FileContent, []common.FileContent, error) {
+	url := common.APIURL("/repos/%s/%s/contents/%s", owner, repo, path)
+	if ref != "" {
+		params := map[string]string{
+			"ref": ref,
+		}
+		var err error
+		url, err = common.BuildURL(url, params)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	resp, _, err := common.TypedGitHubRequest[json.RawMessage](url, "GET", nil, apiReqs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Handle both single file and directory responses
+	if trimmed := bytes.TrimSpace(resp); len(trimmed) > 0 && trimmed[0] == '[' {
+		// This is a directory
+		fileList := []common.FileContent{}
+		if err := json.Unmarshal(resp, &fileList); err != nil {
+			return nil, nil, err
+		}
+		return nil, fileList, nil
+	}
+
+	// This is a file
+	var fileContent common.FileContent
+	if err := json.Unmarshal(resp, &fileContent); err != nil {
+		return nil, nil, err
 	}
+	return &fileContent, nil, nil
+}
 
-	// Update the reference
-	updateRefURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/refs/heads/%s",
-		options.Owner, options.Repo, options.Branch)
-	updateRefBody := map[string]interface{}{
-		"sha": newCommitSHA,
+// readFileData returns the decoded content of a file returned by the contents API,
+// reading large files through the blobs API. ok is false for entries without content.
+func readFileData(owner string, repo string, file *common.FileContent, apiReqs *common.APIRequirements) ([]byte, bool, error) {
+	switch {
+	case file.Encoding == ENCODING_BASE64:
+		// Decode the content if it's base64 encoded
+		data, err := decodeContent(file.Content, ENCODING_BASE64)
+		if err != nil {
+			return nil, false, fmt.Errorf("error decoding base64 content: %w", err)
+		}
+		return data, true, nil
+	case file.Type == "file" && file.Content == "" && file.Size > 0:
+		// The contents API leaves content empty for files over 1 MB, read the blob instead
+		data, err := getRawBlob(owner, repo, file.SHA, apiReqs)
+		if err != nil {
+			return nil, false, fmt.Errorf("error getting content of large file: %w", err)
+		}
+		if len(data) != file.Size {
+			return nil, false, fmt.Errorf("incomplete content for %s: got %d of %d bytes", file.Path, len(data), file.Size)
+		}
+		return data, true, nil
+	case file.Type == "file":
+		return []byte{}, true, nil
+	default:
+		return nil, false, nil
 	}
+}
 
-	_, err = common.GitHubRequest(updateRefURL, "PATCH", updateRefBody, apiReqs)
+// getRawBlob downloads a blob using the raw media type, which works for blobs up to 100 MB
+func getRawBlob(owner string, repo string, sha string, apiReqs *common.APIRequirements) ([]byte, error) {
+	url := common.APIURL("/repos/%s/%s/git/blobs/%s", owner, repo, sha)
+	data, _, err := common.TypedGitHubRequest[[]byte](url, "GET", nil, apiReqs, common.WithAccept("application/vnd.github.raw"))
 	if err != nil {
-		return nil, fmt.Errorf("error updating reference: %w", err)
+		return nil, err
+	}
+	return data, nil
+}
+
+// countLines returns the number of lines in data, counting a final line without a newline
+func countLines(data []byte) int {
+	lines := bytes.Count(data, []byte("\n"))
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		lines++
+	}
+	return lines
+}
+
+// extractLines returns the requested line range of a text file, or the lines matching
+// options.Grep within that range together with their context
+func extractLines(file *common.FileContent, content string, options *GetFileContentsOptions) (*common.FileExcerpts, error) {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	result := &common.FileExcerpts{
+		Path:      file.Path,
+		SHA:       file.SHA,
+		Size:      file.Size,
+		LineCount: len(lines),
+		Excerpts:  []common.Excerpt{},
+	}
+
+	start, end := 1, len(lines)
+	if options.StartLine > 0 {
+		start = options.StartLine
+	}
+	if options.EndLine > 0 && options.EndLine < end {
+		end = options.EndLine
+	}
+	if options.StartLine > len(lines) {
+		return nil, fmt.Errorf("start_line %d is beyond the end of the file (%d lines)", start, len(lines))
+	}
+
+	if options.Grep == "" {
+		if end < start {
+			return result, nil
+		}
+		result.Excerpts = append(result.Excerpts, formatExcerpt(lines, start, end, nil))
+		return result, nil
+	}
+
+	// Validate has already checked the pattern
+	re := regexp.MustCompile(options.Grep)
+	matched := make(map[int]bool)
+	var windowStart, windowEnd int
+	for n := start; n <= end; n++ {
+		if !re.MatchString(lines[n-1]) {
+			continue
+		}
+		result.Matches++
+		if result.Matches > MAX_GREP_MATCHES {
+			result.Truncated = true
+			continue
+		}
+		matched[n] = true
+
+		from := max(n-options.ContextLines, start)
+		to := min(n+options.ContextLines, end)
+		// Overlapping or adjacent context is merged into one excerpt
+		if windowEnd > 0 && from <= windowEnd+1 {
+			windowEnd = to
+			continue
+		}
+		if windowEnd > 0 {
+			result.Excerpts = append(result.Excerpts, formatExcerpt(lines, windowStart, windowEnd, matched))
+		}
+		windowStart, windowEnd = from, to
+	}
+	if windowEnd > 0 {
+		result.Excerpts = append(result.Excerpts, formatExcerpt(lines, windowStart, windowEnd, matched))
+	}
+	return result, nil
+}
+
+// formatExcerpt numbers lines start to end (1-based, inclusive). Lines not in matched
+// are marked as context, a nil matched marks every line as selected.
+func formatExcerpt(lines []string, start int, end int, matched map[int]bool) common.Excerpt {
+	var b strings.Builder
+	for n := start; n <= end; n++ {
+		separator := ":"
+		if matched != nil && !matched[n] {
+			separator = "-"
+		}
+		fmt.Fprintf(&b, "%d%s %s\n", n, separator, lines[n-1])
+	}
+	return common.Excerpt{
+		StartLine: start,
+		EndLine:   end,
+		Content:   b.String(),
+	}
+}
+
+// sliceContent returns the requested byte range of data. Without a range, files up to
+// MAX_FILE_CHUNK_SIZE are returned whole and larger files are cut to their first chunk.
+// Text ranges are moved to character boundaries so no UTF-8 sequence is split.
+func sliceContent(data []byte, offset int, length int, text bool) ([]byte, *common.ContentRange, error) {
+	if offset == 0 && length == 0 && len(data) <= MAX_FILE_CHUNK_SIZE {
+		return data, nil, nil
+	}
+	if offset > len(data) {
+		return nil, nil, fmt.Errorf("offset %d is beyond the end of the file (%d bytes)", offset, len(data))
+	}
+	if length == 0 {
+		length = MAX_FILE_CHUNK_SIZE
+	}
+
+	start, end := offset, offset+length
+	if end > len(data) {
+		end = len(data)
+	}
+	if text {
+		for start < end && !utf8.RuneStart(data[start]) {
+			start++
+		}
+		for end > start && end < len(data) && !utf8.RuneStart(data[end]) {
+			end--
+		}
+	}
+
+	return data[start:end], &common.ContentRange{
+		Offset:  start,
+		Length:  end - start,
+		HasMore: end < len(data),
+	}, nil
+}
+
+// buildTreeItems returns the tree entries that apply one file change
+func buildTreeItems(owner string, repo string, file PushFileDefinition, lookup *treeLookup, apiReqs *common.APIRequirements) ([]map[string]interface{}, error) {
+	switch {
+	case file.Delete:
+		// To delete a file, we omit the content and set the sha to null
+		return []map[string]interface{}{deleteTreeItem(file.Path)}, nil
+	case file.blobSHA != "":
+		return []map[string]interface{}{{
+			"path": file.Path,
+			"mode": file.Mode,
+			"type": "blob",
+			"sha":  file.blobSHA,
+		}}, nil
+	case file.SubmoduleSHA != "":
+		return []map[string]interface{}{{
+			"path": file.Path,
+			"mode": FILE_MODE_SUBMODULE,
+			"type": "commit",
+			"sha":  file.SubmoduleSHA,
+		}}, nil
+	case file.SymlinkTarget != "":
+		// A symlink is a blob holding the target path
+		item := map[string]interface{}{
+			"path":    file.Path,
+			"mode":    FILE_MODE_SYMLINK,
+			"type":    "blob",
+			"content": file.SymlinkTarget,
+		}
+		if file.RenameFrom != "" {
+			return []map[string]interface{}{item, deleteTreeItem(file.RenameFrom)}, nil
+		}
+		return []map[string]interface{}{item}, nil
+	}
+
+	// The existing entry supplies the content of moves and the mode when none is given,
+	// so executable files stay executable
+	var existing *common.TreeEntry
+	if file.RenameFrom != "" || file.Mode == "" {
+		source := file.Path
+		if file.RenameFrom != "" {
+			source = file.RenameFrom
+		}
+		var err error
+		existing, err = lookup.find(source)
+		if err != nil {
+			return nil, fmt.Errorf("error looking up %s: %w", source, err)
+		}
+		if file.RenameFrom != "" && (existing == nil || existing.Type != "blob") {
+			return nil, fmt.Errorf("rename_from %s is not a file in the base commit", file.RenameFrom)
+		}
 	}
 
-	// Return the commit data
-	return commitResp, nil
+	mode := file.Mode
+	if mode == "" {
+		mode = FILE_MODE_REGULAR
+		if existing != nil && existing.Type == "blob" &&
+			(existing.Mode == FILE_MODE_EXECUTABLE || (existing.Mode == FILE_MODE_SYMLINK && file.Content == "")) {
+			mode = existing.Mode
+		}
+	}
+
+	item := map[string]interface{}{
+		"path": file.Path,
+		"mode": mode,
+		"type": "blob",
+	}
+	switch {
+	case file.Content == "" && file.RenameFrom != "":
+		// Moving a file unchanged reuses its blob
+		item["sha"] = existing.SHA
+	case file.Encoding == ENCODING_BASE64:
+		// Tree entries only accept UTF-8 content, so binary files are uploaded as blobs first
+		blob, err := createBlob(owner, repo, file.Content, apiReqs)
+		if err != nil {
+			return nil, fmt.Errorf("error creating blob for %s: %w", file.Path, err)
+		}
+		item["sha"] = blob.SHA
+	default:
+		// For new or updated files, we include the content
+		item["content"] = file.Content
+	}
+
+	if file.RenameFrom != "" {
+		return []map[string]interface{}{item, deleteTreeItem(file.RenameFrom)}, nil
+	}
+	return []map[string]interface{}{item}, nil
+}
+
+func deleteTreeItem(path string) map[string]interface{} {
+	return map[string]interface{}{
+		"path": path,
+		"mode": FILE_MODE_REGULAR,
+		"type": "blob",
+		"sha":  nil,
+	}
+}
+
+// createBlob uploads base64 encoded content as a Git blob
+func createBlob(owner string, repo string, content string, apiReqs *common.APIRequirements) (*common.GitBlob, error) {
+	url := common.APIURL("/repos/%s/%s/git/blobs", owner, repo)
+	body := map[string]interface{}{
+		"content":  strings.Join(strings.Fields(content), ""),
+		"encoding": ENCODING_BASE64,
+	}
+
+	blob, _, err := common.TypedGitHubRequest[common.GitBlob](url, "POST", body, apiReqs)
+	if err != nil {
+		return nil, err
+	}
+	if blob.SHA == "" {
+		return nil, fmt.Errorf("blob sha not found in response")
+	}
+	return &blob, nil
 }
@@ -0,0 +1,850 @@
+package operations
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/metoro-io/github-mcp-server-go/common"
+)
+
+// pngHeader is the start of a PNG image, enough for content type detection
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+
+func TestCreateOrUpdateFileOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		options       CreateOrUpdateFileOptions
+		wantErr       bool
+		errorContains string
+	}{
+		{
+			name: "valid text content",
+			options: CreateOrUpdateFileOptions{
+				Owner:   "validowner",
+				Repo:    "valid-repo",
+				Path:    "README.md",
+				Message: "Update README",
+				Content: "hello",
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid base64 content",
+			options: CreateOrUpdateFileOptions{
+				Owner:    "validowner",
+				Repo:     "valid-repo",
+				Path:     "logo.png",
+				Message:  "Add logo",
+				Content:  base64.StdEncoding.EncodeToString(pngHeader),
+				Encoding: ENCODING_BASE64,
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid base64 content",
+			options: CreateOrUpdateFileOptions{
+				Owner:    "validowner",
+				Repo:     "valid-repo",
+				Path:     "logo.png",
+				Message:  "Add logo",
+				Content:  "not base64!",
+				Encoding: ENCODING_BASE64,
+			},
+			wantErr:       true,
+			errorContains: "not valid base64",
+		},
+		{
+			name: "unknown encoding",
+			options: CreateOrUpdateFileOptions{
+				Owner:    "validowner",
+				Repo:     "valid-repo",
+				Path:     "README.md",
+				Message:  "Update README",
+				Content:  "hello",
+				Encoding: "latin1",
+			},
+			wantErr:       true,
+			errorContains: "encoding must be",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.options.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil && tt.errorContains != "" {
+				if !strings.Contains(err.Error(), tt.errorContains) {
+					t.Errorf("Validate() error = %v, should contain %v", err, tt.errorContains)
+				}
+			}
+		})
+	}
+}
+
+func TestPushFilesOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		options       PushFilesOptions
+		wantErr       bool
+		errorContains string
+	}{
+		{
+			name: "text and binary files",
+			options: PushFilesOptions{
+				Owner:   "validowner",
+				Repo:    "valid-repo",
+				Branch:  "main",
+				Message: "Add files",
+				Files: []PushFileDefinition{
+					{Path: "README.md", Content: "hello"},
+					{Path: "logo.png", Content: base64.StdEncoding.EncodeToString(pngHeader), Encoding: ENCODING_BASE64},
+					{Path: "old.txt", Delete: true},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "modes renames symlinks and submodules",
+			options: PushFilesOptions{
+				Owner:   "validowner",
+				Repo:    "valid-repo",
+				Branch:  "main",
+				Message: "Rework layout",
+				Files: []PushFileDefinition{
+					{Path: "deploy.sh", Content: "#!/bin/sh", Mode: FILE_MODE_EXECUTABLE},
+					{Path: "docs/new.md", RenameFrom: "docs/old.md"},
+					{Path: "current", SymlinkTarget: "releases/v2"},
+					{Path: "vendor/lib", SubmoduleSHA: "0123456789abcdef0123456789abcdef01234567"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "delete with content",
+			options: PushFilesOptions{
+				Owner:   "validowner",
+				Repo:    "valid-repo",
+				Branch:  "main",
+				Message: "Remove file",
+				Files:   []PushFileDefinition{{Path: "old.txt", Delete: true, Content: "x"}},
+			},
+			wantErr:       true,
+			errorContains: "delete cannot be combined",
+		},
+		{
+			name: "symlink mode without target",
+			options: PushFilesOptions{
+				Owner:   "validowner",
+				Repo:    "valid-repo",
+				Branch:  "main",
+				Message: "Add link",
+				Files:   []PushFileDefinition{{Path: "current", Content: "releases/v2", Mode: FILE_MODE_SYMLINK}},
+			},
+			wantErr:       true,
+			errorContains: "requires symlink_target",
+		},
+		{
+			name: "short submodule sha",
+			options: PushFilesOptions{
+				Owner:   "validowner",
+				Repo:    "valid-repo",
+				Branch:  "main",
+				Message: "Bump submodule",
+				Files:   []PushFileDefinition{{Path: "vendor/lib", SubmoduleSHA: "abc123"}},
+			},
+			wantErr:       true,
+			errorContains: "40 character",
+		},
+		{
+			name: "unknown mode",
+			options: PushFilesOptions{
+				Owner:   "validowner",
+				Repo:    "valid-repo",
+				Branch:  "main",
+				Message: "Add file",
+				Files:   []PushFileDefinition{{Path: "a.txt", Content: "a", Mode: "644"}},
+			},
+			wantErr:       true,
+			errorContains: "mode must be one of",
+		},
+		{
+			name: "rename source changed twice",
+			options: PushFilesOptions{
+				Owner:   "validowner",
+				Repo:    "valid-repo",
+				Branch:  "main",
+				Message: "Move file",
+				Files: []PushFileDefinition{
+					{Path: "b.txt", RenameFrom: "a.txt"},
+					{Path: "a.txt", Delete: true},
+				},
+			},
+			wantErr:       true,
+			errorContains: "more than one file entry",
+		},
+		{
+			name: "invalid base64 file",
+			options: PushFilesOptions{
+				Owner:   "validowner",
+				Repo:    "valid-repo",
+				Branch:  "main",
+				Message: "Add files",
+				Files: []PushFileDefinition{
+					{Path: "logo.png", Content: "%%%", Encoding: ENCODING_BASE64},
+				},
+			},
+			wantErr:       true,
+			errorContains: "index 0",
+		},
+		{
+			name: "short expected head sha",
+			options: PushFilesOptions{
+				Owner:           "validowner",
+				Repo:            "valid-repo",
+				Branch:          "main",
+				Message:         "Add files",
+				Files:           []PushFileDefinition{{Path: "a.txt", Content: "a"}},
+				ExpectedHeadSHA: "abc123",
+			},
+			wantErr:       true,
+			errorContains: "expected_head_sha",
+		},
+		{
+			name: "base sha differs from expected head sha",
+			options: PushFilesOptions{
+				Owner:           "validowner",
+				Repo:            "valid-repo",
+				Branch:          "main",
+				Message:         "Add files",
+				Files:           []PushFileDefinition{{Path: "a.txt", Content: "a"}},
+				BaseSHA:         strings.Repeat("a", 40),
+				ExpectedHeadSHA: strings.Repeat("b", 40),
+			},
+			wantErr:       true,
+			errorContains: "cannot differ",
+		},
+		{
+			name: "create branch with expected head sha",
+			options: PushFilesOptions{
+				Owner:            "validowner",
+				Repo:             "valid-repo",
+				Branch:           "feature",
+				Message:          "Add files",
+				Files:            []PushFileDefinition{{Path: "a.txt", Content: "a"}},
+				ExpectedHeadSHA:  strings.Repeat("b", 40),
+				CreateBranchFrom: "main",
+			},
+			wantErr:       true,
+			errorContains: "create_branch_from cannot be combined",
+		},
+		{
+			name: "author with dates and co-authors",
+			options: PushFilesOptions{
+				Owner:     "validowner",
+				Repo:      "valid-repo",
+				Branch:    "main",
+				Message:   "Add files",
+				Files:     []PushFileDefinition{{Path: "a.txt", Content: "a"}},
+				Author:    &CommitterInfo{Name: "Mona Lisa", Email: "mona@example.com", Date: "2024-05-01T10:00:00Z"},
+				Committer: &CommitterInfo{Name: "Bot", Email: "bot@example.com", Date: "2024-05-01T10:05:00+02:00"},
+				CoAuthors: []CommitterInfo{{Name: "Hubot", Email: "hubot@example.com"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid author email",
+			options: PushFilesOptions{
+				Owner:   "validowner",
+				Repo:    "valid-repo",
+				Branch:  "main",
+				Message: "Add files",
+				Files:   []PushFileDefinition{{Path: "a.txt", Content: "a"}},
+				Author:  &CommitterInfo{Name: "Mona Lisa", Email: "Mona <mona@example.com>"},
+			},
+			wantErr:       true,
+			errorContains: "invalid author",
+		},
+		{
+			name: "invalid committer date",
+			options: PushFilesOptions{
+				Owner:     "validowner",
+				Repo:      "valid-repo",
+				Branch:    "main",
+				Message:   "Add files",
+				Files:     []PushFileDefinition{{Path: "a.txt", Content: "a"}},
+				Committer: &CommitterInfo{Name: "Bot", Email: "bot@example.com", Date: "May 1 2024"},
+			},
+			wantErr:       true,
+			errorContains: "ISO 8601",
+		},
+		{
+			name: "co-author name breaks the trailer",
+			options: PushFilesOptions{
+				Owner:     "validowner",
+				Repo:      "valid-repo",
+				Branch:    "main",
+				Message:   "Add files",
+				Files:     []PushFileDefinition{{Path: "a.txt", Content: "a"}},
+				CoAuthors: []CommitterInfo{{Name: "Hubot\nSigned-off-by: x", Email: "hubot@example.com"}},
+			},
+			wantErr:       true,
+			errorContains: "co-author at index 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.options.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil && tt.errorContains != "" {
+				if !strings.Contains(err.Error(), tt.errorContains) {
+					t.Errorf("Validate() error = %v, should contain %v", err, tt.errorContains)
+				}
+			}
+		})
+	}
+}
+
+func TestAddCoAuthorTrailers(t *testing.T) {
+	coAuthors := []CommitterInfo{{Name: "Mona Lisa", Email: "mona@example.com"}}
+
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{
+			name:    "subject only",
+			message: "Fix typo",
+			want:    "Fix typo\n\nCo-authored-by: Mona Lisa <mona@example.com>",
+		},
+		{
+			name:    "body",
+			message: "Fix typo\n\nThe README misspelled the project name.\n",
+			want:    "Fix typo\n\nThe README misspelled the project name.\n\nCo-authored-by: Mona Lisa <mona@example.com>",
+		},
+		{
+			name:    "existing trailer block",
+			message: "Fix typo\n\nSigned-off-by: Hubot <hubot@example.com>",
+			want:    "Fix typo\n\nSigned-off-by: Hubot <hubot@example.com>\nCo-authored-by: Mona Lisa <mona@example.com>",
+		},
+		{
+			name:    "already credited",
+			message: "Fix typo\n\nCo-authored-by: Mona Lisa <mona@example.com>",
+			want:    "Fix typo\n\nCo-authored-by: Mona Lisa <mona@example.com>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := addCoAuthorTrailers(tt.message, coAuthors); got != tt.want {
+				t.Errorf("addCoAuthorTrailers() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetFileContentsEncoding(t *testing.T) {
+	files := map[string][]byte{
+		"README.md": []byte("# Title\n"),
+		"logo.png":  pngHeader,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/repos/octo/repo/contents/")
+		// GitHub wraps base64 content at 60 characters
+		encoded := base64.StdEncoding.EncodeToString(files[path])
+		if len(encoded) > 20 {
+			encoded = encoded[:20] + "\n" + encoded[20:]
+		}
+		fmt.Fprintf(w, `{"type":"file","encoding":"base64","path":%q,"content":%q}`, path, encoded)
+	}))
+	defer server.Close()
+
+	defer common.Configure(common.DefaultClientOptions())
+	common.Configure(common.ClientOptions{BaseURL: server.URL, Token: "test"})
+
+	tests := []struct {
+		name         string
+		path         string
+		encoding     string
+		wantEncoding string
+		wantContent  string
+		wantMime     string
+		wantErr      bool
+	}{
+		{
+			name:         "text file",
+			path:         "README.md",
+			wantEncoding: ENCODING_UTF8,
+			wantContent:  "# Title\n",
+			wantMime:     "text/plain",
+		},
+		{
+			name:         "text file as base64",
+			path:         "README.md",
+			encoding:     ENCODING_BASE64,
+			wantEncoding: ENCODING_BASE64,
+			wantContent:  base64.StdEncoding.EncodeToString(files["README.md"]),
+			wantMime:     "text/plain",
+		},
+		{
+			name:         "binary file",
+			path:         "logo.png",
+			wantEncoding: ENCODING_BASE64,
+			wantContent:  base64.StdEncoding.EncodeToString(pngHeader),
+			wantMime:     "image/png",
+		},
+		{
+			name:     "binary file as utf-8",
+			path:     "logo.png",
+			encoding: ENCODING_UTF8,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := GetFileContents(&GetFileContentsOptions{
+				Owner:    "octo",
+				Repo:     "repo",
+				Path:     tt.path,
+				Encoding: tt.encoding,
+			}, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetFileContents() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			file := result.(common.FileContent)
+			if file.Encoding != tt.wantEncoding {
+				t.Errorf("Encoding = %v, want %v", file.Encoding, tt.wantEncoding)
+			}
+			if file.Content != tt.wantContent {
+				t.Errorf("Content = %q, want %q", file.Content, tt.wantContent)
+			}
+			if !strings.HasPrefix(file.MimeType, tt.wantMime) {
+				t.Errorf("MimeType = %v, want %v", file.MimeType, tt.wantMime)
+			}
+		})
+	}
+}
+
+func TestGetFileContentsLargeFile(t *testing.T) {
+	large := []byte(strings.Repeat("line of a large lockfile\n", 50000))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octo/repo/contents/package-lock.json":
+			// Files over 1 MB come back without content
+			fmt.Fprintf(w, `{"type":"file","encoding":"none","path":"package-lock.json","sha":"abc","size":%d,"content":""}`, len(large))
+		case "/repos/octo/repo/git/blobs/abc":
+			if r.Header.Get("Accept") != "application/vnd.github.raw" {
+				t.Errorf("Accept = %v, want the raw media type", r.Header.Get("Accept"))
+			}
+			w.Write(large)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	defer common.Configure(common.DefaultClientOptions())
+	common.Configure(common.ClientOptions{BaseURL: server.URL, Token: "test"})
+
+	result, err := GetFileContents(&GetFileContentsOptions{Owner: "octo", Repo: "repo", Path: "package-lock.json", Offset: 25, Length: 25}, nil)
+	if err != nil {
+		t.Fatalf("GetFileContents() error = %v", err)
+	}
+	file := result.(common.FileContent)
+	if file.Content != "line of a large lockfile\n" {
+		t.Errorf("Content = %q, want the second line", file.Content)
+	}
+	if file.LineCount != 50000 || file.Size != len(large) {
+		t.Errorf("LineCount = %d, Size = %d, want 50000 and %d", file.LineCount, file.Size, len(large))
+	}
+	if file.Range == nil || file.Range.Offset != 25 || file.Range.Length != 25 || !file.Range.HasMore {
+		t.Errorf("Range = %+v, want offset 25 length 25 with more content", file.Range)
+	}
+}
+
+func TestSliceContent(t *testing.T) {
+	data := []byte("héllo wörld")
+
+	tests := []struct {
+		name      string
+		offset    int
+		length    int
+		text      bool
+		want      string
+		wantRange bool
+		wantErr   bool
+	}{
+		{
+			name: "whole small file",
+			text: true,
+			want: "héllo wörld",
+		},
+		{
+			name:      "range",
+			offset:    7,
+			length:    6,
+			text:      true,
+			want:      "wörld",
+			wantRange: true,
+		},
+		{
+			name:      "range inside a character",
+			offset:    2,
+			length:    3,
+			text:      true,
+			want:      "ll",
+			wantRange: true,
+		},
+		{
+			name:    "offset past the end",
+			offset:  100,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotRange, err := sliceContent(data, tt.offset, tt.length, tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sliceContent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("sliceContent() = %q, want %q", got, tt.want)
+			}
+			if (gotRange != nil) != tt.wantRange {
+				t.Errorf("sliceContent() range = %+v, wantRange %v", gotRange, tt.wantRange)
+			}
+		})
+	}
+}
+
+func TestExtractLines(t *testing.T) {
+	file := &common.FileContent{Path: "main.go", SHA: "abc"}
+	content := "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n\nfunc helper() {\n\tfmt.Println(\"help\")\n}\n"
+
+	tests := []struct {
+		name         string
+		options      GetFileContentsOptions
+		wantExcerpts []string
+		wantMatches  int
+		wantErr      bool
+	}{
+		{
+			name:         "line range",
+			options:      GetFileContentsOptions{StartLine: 5, EndLine: 7},
+			wantExcerpts: []string{"5: func main() {\n6: \tfmt.Println(\"hello\")\n7: }\n"},
+		},
+		{
+			name:         "end line past the end",
+			options:      GetFileContentsOptions{StartLine: 11, EndLine: 50},
+			wantExcerpts: []string{"11: }\n"},
+		},
+		{
+			name:         "grep with context",
+			options:      GetFileContentsOptions{Grep: `^func`, ContextLines: 1},
+			wantExcerpts: []string{"4- \n5: func main() {\n6- \tfmt.Println(\"hello\")\n", "8- \n9: func helper() {\n10- \tfmt.Println(\"help\")\n"},
+			wantMatches:  2,
+		},
+		{
+			name:         "grep with merged context",
+			options:      GetFileContentsOptions{Grep: `Println`, ContextLines: 2},
+			wantExcerpts: []string{"4- \n5- func main() {\n6: \tfmt.Println(\"hello\")\n7- }\n8- \n9- func helper() {\n10: \tfmt.Println(\"help\")\n11- }\n"},
+			wantMatches:  2,
+		},
+		{
+			name:         "grep within line range",
+			options:      GetFileContentsOptions{Grep: `Println`, StartLine: 8},
+			wantExcerpts: []string{"10: \tfmt.Println(\"help\")\n"},
+			wantMatches:  1,
+		},
+		{
+			name:    "start line past the end",
+			options: GetFileContentsOptions{StartLine: 12},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := extractLines(file, content, &tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("extractLines() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if result.SHA != "abc" || result.LineCount != 11 {
+				t.Errorf("SHA = %v, LineCount = %v, want abc and 11", result.SHA, result.LineCount)
+			}
+			if result.Matches != tt.wantMatches {
+				t.Errorf("Matches = %v, want %v", result.Matches, tt.wantMatches)
+			}
+			if len(result.Excerpts) != len(tt.wantExcerpts) {
+				t.Fatalf("extractLines() returned %d excerpts %+v, want %d", len(result.Excerpts), result.Excerpts, len(tt.wantExcerpts))
+			}
+			for i, excerpt := range result.Excerpts {
+				if excerpt.Content != tt.wantExcerpts[i] {
+					t.Errorf("excerpt %d = %q, want %q", i, excerpt.Content, tt.wantExcerpts[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBuildTreeItems(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octo/repo/git/trees/root":
+			fmt.Fprint(w, `{"sha":"root","tree":[{"path":"scripts","mode":"040000","type":"tree","sha":"scripts"},{"path":"README.md","mode":"100644","type":"blob","sha":"readme"}]}`)
+		case "/repos/octo/repo/git/trees/scripts":
+			fmt.Fprint(w, `{"sha":"scripts","tree":[{"path":"deploy.sh","mode":"100755","type":"blob","sha":"deploy"}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	defer common.Configure(common.DefaultClientOptions())
+	common.Configure(common.ClientOptions{BaseURL: server.URL, Token: "test"})
+
+	tests := []struct {
+		name    string
+		file    PushFileDefinition
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "executable bit is preserved",
+			file: PushFileDefinition{Path: "scripts/deploy.sh", Content: "#!/bin/sh"},
+			want: []string{"scripts/deploy.sh 100755 blob content"},
+		},
+		{
+			name: "new files are regular",
+			file: PushFileDefinition{Path: "scripts/new.sh", Content: "#!/bin/sh"},
+			want: []string{"scripts/new.sh 100644 blob content"},
+		},
+		{
+			name: "rename reuses the blob",
+			file: PushFileDefinition{Path: "bin/deploy.sh", RenameFrom: "scripts/deploy.sh"},
+			want: []string{"bin/deploy.sh 100755 blob deploy", "scripts/deploy.sh 100644 blob <nil>"},
+		},
+		{
+			name: "symlink",
+			file: PushFileDefinition{Path: "deploy", SymlinkTarget: "scripts/deploy.sh"},
+			want: []string{"deploy 120000 blob content"},
+		},
+		{
+			name: "submodule",
+			file: PushFileDefinition{Path: "vendor/lib", SubmoduleSHA: "0123456789abcdef0123456789abcdef01234567"},
+			want: []string{"vendor/lib 160000 commit 0123456789abcdef0123456789abcdef01234567"},
+		},
+		{
+			name:    "rename of a missing file",
+			file:    PushFileDefinition{Path: "b.txt", RenameFrom: "missing.txt"},
+			wantErr: true,
+		},
+	}
+
+	lookup := newTreeLookup("octo", "repo", "root", nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := buildTreeItems("octo", "repo", tt.file, lookup, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildTreeItems() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var got []string
+			for _, item := range items {
+				sha := fmt.Sprint(item["sha"])
+				if _, ok := item["content"]; ok {
+					sha = "content"
+				}
+				got = append(got, fmt.Sprintf("%s %s %s %s", item["path"], item["mode"], item["type"], sha))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("buildTreeItems() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommitFilesConflicts(t *testing.T) {
+	oldHead := strings.Repeat("a", 40)
+	newHead := strings.Repeat("b", 40)
+
+	tests := []struct {
+		name          string
+		options       PushFilesOptions
+		compare       string
+		wantParent    string
+		wantConflicts []string
+		wantReason    string
+	}{
+		{
+			name:       "moved branch without rebase",
+			options:    PushFilesOptions{ExpectedHeadSHA: oldHead},
+			wantReason: "set rebase",
+		},
+		{
+			name:       "stale base rebased onto unrelated changes",
+			options:    PushFilesOptions{BaseSHA: oldHead, Rebase: true},
+			compare:    `{"status":"ahead","files":[{"filename":"src/main.go","status":"modified"}]}`,
+			wantParent: newHead,
+		},
+		{
+			name:          "rebase onto changes to the same file",
+			options:       PushFilesOptions{ExpectedHeadSHA: oldHead, Rebase: true},
+			compare:       `{"status":"ahead","files":[{"filename":"README.md","status":"renamed","previous_filename":"docs/guide.md"}]}`,
+			wantConflicts: []string{"docs/guide.md"},
+			wantReason:    "same files",
+		},
+		{
+			name:       "rebase onto a rewritten branch",
+			options:    PushFilesOptions{ExpectedHeadSHA: oldHead, Rebase: true},
+			compare:    `{"status":"diverged","files":[]}`,
+			wantReason: "rewritten",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			head := newHead
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/repos/octo/repo/git/refs/heads/main" && r.Method == "GET":
+					fmt.Fprintf(w, `{"ref":"refs/heads/main","object":{"sha":%q}}`, head)
+				case r.URL.Path == "/repos/octo/repo/git/refs/heads/main" && r.Method == "PATCH":
+					var body struct {
+						SHA string `json:"sha"`
+					}
+					json.NewDecoder(r.Body).Decode(&body)
+					if body.SHA != "commit-on-"+head {
+						w.WriteHeader(http.StatusUnprocessableEntity)
+						fmt.Fprint(w, `{"message":"Update is not a fast forward"}`)
+						return
+					}
+					head = body.SHA
+					fmt.Fprintf(w, `{"ref":"refs/heads/main","object":{"sha":%q}}`, head)
+				case strings.HasPrefix(r.URL.Path, "/repos/octo/repo/git/commits/"):
+					sha := strings.TrimPrefix(r.URL.Path, "/repos/octo/repo/git/commits/")
+					fmt.Fprintf(w, `{"sha":%q,"tree":{"sha":"tree"}}`, sha)
+				case r.URL.Path == "/repos/octo/repo/git/commits":
+					var body struct {
+						Parents []string `json:"parents"`
+					}
+					json.NewDecoder(r.Body).Decode(&body)
+					fmt.Fprintf(w, `{"sha":"commit-on-%s","parents":[{"sha":%q}]}`, body.Parents[0], body.Parents[0])
+				case r.URL.Path == "/repos/octo/repo/git/trees/tree":
+					fmt.Fprint(w, `{"sha":"tree","tree":[{"path":"README.md","mode":"100644","type":"blob","sha":"readme"}]}`)
+				case r.URL.Path == "/repos/octo/repo/git/trees":
+					fmt.Fprint(w, `{"sha":"newtree"}`)
+				case r.URL.Path == "/repos/octo/repo/compare/"+oldHead+"..."+newHead:
+					fmt.Fprint(w, tt.compare)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			defer common.Configure(common.DefaultClientOptions())
+			common.Configure(common.ClientOptions{BaseURL: server.URL, Token: "test"})
+
+			options := tt.options
+			options.Owner, options.Repo, options.Branch, options.Message = "octo", "repo", "main", "Update docs"
+			options.Files = []PushFileDefinition{{Path: "docs/guide.md", Content: "# Guide"}}
+
+			commit, err := commitFiles(&options, nil)
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Fatalf("commitFiles() error = %v", err)
+				}
+				if len(commit.Parents) != 1 || commit.Parents[0].SHA != tt.wantParent {
+					t.Errorf("commit parents = %+v, want %s", commit.Parents, tt.wantParent)
+				}
+				return
+			}
+
+			var conflict *common.ConflictError
+			if !errors.As(err, &conflict) {
+				t.Fatalf("commitFiles() error = %v, want a ConflictError", err)
+			}
+			if conflict.ExpectedSHA != oldHead || conflict.ActualSHA != newHead {
+				t.Errorf("conflict = %s..%s, want %s..%s", conflict.ExpectedSHA, conflict.ActualSHA, oldHead, newHead)
+			}
+			if !strings.Contains(conflict.Reason, tt.wantReason) {
+				t.Errorf("Reason = %q, should contain %q", conflict.Reason, tt.wantReason)
+			}
+			if strings.Join(conflict.ConflictingPaths, ",") != strings.Join(tt.wantConflicts, ",") {
+				t.Errorf("ConflictingPaths = %v, want %v", conflict.ConflictingPaths, tt.wantConflicts)
+			}
+		})
+	}
+}
+
+func TestCommitFilesCreatesBranch(t *testing.T) {
+	tagSHA := strings.Repeat("c", 40)
+	var createdRef map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/octo/repo/git/refs/heads/feature":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+		case r.URL.Path == "/repos/octo/repo/commits/v1.0":
+			fmt.Fprintf(w, `{"sha":%q}`, tagSHA)
+		case r.URL.Path == "/repos/octo/repo/git/commits/"+tagSHA:
+			fmt.Fprintf(w, `{"sha":%q,"tree":{"sha":"tree"}}`, tagSHA)
+		case r.URL.Path == "/repos/octo/repo/git/trees/tree":
+			fmt.Fprint(w, `{"sha":"tree","tree":[]}`)
+		case r.URL.Path == "/repos/octo/repo/git/trees":
+			fmt.Fprint(w, `{"sha":"newtree"}`)
+		case r.URL.Path == "/repos/octo/repo/git/commits":
+			fmt.Fprint(w, `{"sha":"newcommit"}`)
+		case r.URL.Path == "/repos/octo/repo/git/refs" && r.Method == "POST":
+			json.NewDecoder(r.Body).Decode(&createdRef)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"ref":%q,"object":{"sha":%q}}`, createdRef["ref"], createdRef["sha"])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	defer common.Configure(common.DefaultClientOptions())
+	common.Configure(common.ClientOptions{BaseURL: server.URL, Token: "test"})
+
+	commit, err := commitFiles(&PushFilesOptions{
+		Owner:            "octo",
+		Repo:             "repo",
+		Branch:           "feature",
+		Message:          "Add notes",
+		Files:            []PushFileDefinition{{Path: "NOTES.md", Content: "notes"}},
+		CreateBranchFrom: "v1.0",
+	}, nil)
+	if err != nil {
+		t.Fatalf("commitFiles() error = %v", err)
+	}
+	if commit.SHA != "newcommit" {
+		t.Errorf("commit SHA = %s, want newcommit", commit.SHA)
+	}
+	if createdRef["ref"] != "refs/heads/feature" || createdRef["sha"] != "newcommit" {
+		t.Errorf("created ref = %v, want refs/heads/feature at newcommit", createdRef)
+	}
+}
@@ -1,7 +1,6 @@
 package operations
 
 import (
-	"encoding/json"
 	"fmt"
 	"strconv"
 
@@ -141,10 +140,10 @@ func (o *IssueCommentOptions) Validate() error {
 // CreateIssue creates a new issue in a GitHub repository
 func CreateIssue(options *CreateIssueOptions, apiReqs *common.APIRequirements) (*common.GitHubIssue, error) {
 	if err := options.Validate(); err != nil {
-		return nil, err
+		return nil, common.InvalidInput(err)
 	}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues", options.Owner, options.Repo)
+	url := common.APIURL("/repos/%s/%s/issues", options.Owner, options.Repo)
 
 	requestBody := map[string]interface{}{
 		"title": options.Title,
@@ -159,58 +158,38 @@ func CreateIssue(options *CreateIssueOptions, apiReqs *common.APIRequirements) (
 		requestBody["labels"] = options.Labels
 	}
 
-	resp, err := common.GitHubRequest(url, "POST", requestBody, apiReqs)
-	if err != nil {
-		return nil, err
-	}
-
-	var issue common.GitHubIssue
-	jsonData, err := json.Marshal(resp)
+	issue, _, err := common.TypedGitHubRequest[common.GitHubIssue](url, "POST", requestBody, apiReqs)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := json.Unmarshal(jsonData, &issue); err != nil {
-		return nil, err
-	}
-
 	return &issue, nil
 }
 
 // GetIssue gets details of a specific issue in a GitHub repository
 func GetIssue(options *GetIssueOptions, apiReqs *common.APIRequirements) (*common.GitHubIssue, error) {
 	if err := options.Validate(); err != nil {
-		return nil, err
+		return nil, common.InvalidInput(err)
 	}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues/%d",
+	url := common.APIURL("/repos/%s/%s/issues/%d",
 		options.Owner, options.Repo, options.Number)
 
-	resp, err := common.GitHubRequest(url, "GET", nil, apiReqs)
+	issue, _, err := common.TypedGitHubRequest[common.GitHubIssue](url, "GET", nil, apiReqs)
 	if err != nil {
 		return nil, err
 	}
 
-	var issue common.GitHubIssue
-	jsonData, err := json.Marshal(resp)
-	if err != nil {
-		return nil, err
-	}
-
-	if err := json.Unmarshal(jsonData, &issue); err != nil {
-		return nil, err
-	}
-
 	return &issue, nil
 }
 
 // ListIssues lists issues in a GitHub repository
 func ListIssues(options *ListIssuesOptions, apiReqs *common.APIRequirements) ([]common.GitHubIssue, error) {
 	if err := options.Validate(); err != nil {
-		return nil, err
+		return nil, common.InvalidInput(err)
 	}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues", options.Owner, options.Repo)
+	url := common.APIURL("/repos/%s/%s/issues", options.Owner, options.Repo)
 
 	params := make(map[string]string)
 	if options.State != "" {
@@ -237,31 +216,21 @@ func ListIssues(options *ListIssuesOptions, apiReqs *common.APIRequirements) ([]
 		}
 	}
 
-	resp, err := common.GitHubRequest(url, "GET", nil, apiReqs)
+	issues, _, err := common.TypedGitHubRequest[[]common.GitHubIssue](url, "GET", nil, apiReqs)
 	if err != nil {
 		return nil, err
 	}
 
-	var issues []common.GitHubIssue
-	jsonData, err := json.Marshal(resp)
-	if err != nil {
-		return nil, err
-	}
-
-	if err := json.Unmarshal(jsonData, &issues); err != nil {
-		return nil, err
-	}
-
 	return issues, nil
 }
 
 // UpdateIssue updates an existing issue in a GitHub repository
 func UpdateIssue(options *UpdateIssueOptions, apiReqs *common.APIRequirements) (*common.GitHubIssue, error) {
 	if err := options.Validate(); err != nil {
-		return nil, err
+		return nil, common.InvalidInput(err)
 	}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues/%d",
+	url := common.APIURL("/repos/%s/%s/issues/%d",
 		options.Owner, options.Repo, options.Number)
 
 	requestBody := make(map[string]interface{})
@@ -281,41 +250,31 @@ func UpdateIssue(options *UpdateIssueOptions, apiReqs *common.APIRequirements) (
 		requestBody["labels"] = options.Labels
 	}
 
-	resp, err := common.GitHubRequest(url, "PATCH", requestBody, apiReqs)
+	issue, _, err := common.TypedGitHubRequest[common.GitHubIssue](url, "PATCH", requestBody, apiReqs)
 	if err != nil {
 		return nil, err
 	}
 
-	var issue common.GitHubIssue
-	jsonData, err := json.Marshal(resp)
-	if err != nil {
-		return nil, err
-	}
-
-	if err := json.Unmarshal(jsonData, &issue); err != nil {
-		return nil, err
-	}
-
 	return &issue, nil
 }
 
 // AddIssueComment adds a comment to an existing issue
-func AddIssueComment(options *IssueCommentOptions, apiReqs *common.APIRequirements) (interface{}, error) {
+func AddIssueComment(options *IssueCommentOptions, apiReqs *common.APIRequirements) (*common.IssueComment, error) {
 	if err := options.Validate(); err != nil {
-		return nil, err
+		return nil, common.InvalidInput(err)
 	}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues/%d/comments",
+	url := common.APIURL("/repos/%s/%s/issues/%d/comments",
 		options.Owner, options.Repo, options.Number)
 
 	requestBody := map[string]string{
 		"body": options.Body,
 	}
 
-	resp, err := common.GitHubRequest(url, "POST", requestBody, apiReqs)
+	comment, _, err := common.TypedGitHubRequest[common.IssueComment](url, "POST", requestBody, apiReqs)
 	if err != nil {
 		return nil, err
 	}
 
-	return resp, nil
+	return &comment, nil
 }
@@ -0,0 +1,375 @@
+package operations
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/metoro-io/github-mcp-server-go/common"
+)
+
+const (
+	// DEFAULT_LOG_CONTEXT_LINES is the number of lines shown around each error line
+	DEFAULT_LOG_CONTEXT_LINES = 20
+	// MAX_LOG_CONTEXT_LINES bounds the context_lines a caller can ask for
+	MAX_LOG_CONTEXT_LINES = 200
+	// MAX_JOB_LOG_SIZE bounds the log text returned in excerpts or as the whole log
+	MAX_JOB_LOG_SIZE = 64 * 1024
+	// MAX_LOG_LINE_LENGTH is the length at which a single log line is cut off
+	MAX_LOG_LINE_LENGTH = 1000
+	// MAX_LOG_ERRORS bounds the error lines listed for the whole job
+	MAX_LOG_ERRORS = 30
+)
+
+var (
+	ansiPattern         = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+	logTimestampPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2})(\.\d+)?Z `)
+	// logErrorPattern matches the error output of common tools besides the ##[error] command
+	logErrorPattern = regexp.MustCompile(`(?i)^\s*(error|fatal|panic)\b[:\[ ]|^\s*(--- FAIL|FAIL\b|FAILED\b)|npm ERR!|Traceback \(most recent call last\)|^\s*[\w.]*(Error|Exception): |\berror( \w+)?:\s|: (fatal )?error\b`)
+)
+
+// GetJobLogsOptions defines options for reading the log of a workflow job
+type GetJobLogsOptions struct {
+	Owner        string `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo         string `json:"repo" jsonschema:"description=The name of the repository"`
+	JobID        int64  `json:"job_id" jsonschema:"description=The ID of the job as listed by get_workflow_run"`
+	Step         int    `json:"step,omitempty" jsonschema:"description=The number of the step to return. Default: the failing step"`
+	ContextLines int    `json:"context_lines,omitempty" jsonschema:"description=Lines to return around each error line and at the end of the step. Default: 20. Maximum: 200"`
+	WholeLog     bool   `json:"whole_log,omitempty" jsonschema:"description=Return the whole cleaned log instead of excerpts. Long logs are cut to their last 64 KB. Default: false"`
+}
+
+// Validate validates the GetJobLogsOptions
+func (o *GetJobLogsOptions) Validate() error {
+	if _, err := common.ValidateOwnerName(o.Owner); err != nil {
+		return err
+	}
+	if _, err := common.ValidateRepositoryName(o.Repo); err != nil {
+		return err
+	}
+	if o.JobID <= 0 {
+		return fmt.Errorf("job_id is required")
+	}
+	if o.Step < 0 {
+		return fmt.Errorf("step must be a positive step number")
+	}
+	if o.ContextLines < 0 || o.ContextLines > MAX_LOG_CONTEXT_LINES {
+		return fmt.Errorf("context_lines must be between 0 and %d", MAX_LOG_CONTEXT_LINES)
+	}
+	if o.WholeLog && o.Step > 0 {
+		return fmt.Errorf("step cannot be combined with whole_log")
+	}
+	return nil
+}
+
+// logLine is a cleaned line of a job log
+type logLine struct {
+	text string
+	// time is the timestamp the runner wrote the line at, zero for continuation lines
+	time time.Time
+	// runGroup is true for the ##[group]Run line that starts the log of an action or script step
+	runGroup bool
+	error    bool
+}
+
+// GetJobLogs downloads the log of a workflow job and returns the failing step with
+// context around the error lines, or the whole cleaned log when requested
+func GetJobLogs(options *GetJobLogsOptions, apiReqs *common.APIRequirements) (*common.JobLog, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	// The job and its log are read past the response cache: the log of a running job grows
+	// from one call to the next, and a log can run to megabytes that are not worth keeping
+	jobURL := common.APIURL("/repos/%s/%s/actions/jobs/%d", options.Owner, options.Repo, options.JobID)
+	job, _, err := common.TypedGitHubRequest[common.WorkflowJob](jobURL, "GET", nil, apiReqs, common.WithNoCache())
+	if err != nil {
+		return nil, fmt.Errorf("error getting job: %w", err)
+	}
+
+	// The API redirects to a short-lived URL of the log archive, which the client follows.
+	// The Authorization header is not sent on to the other host.
+	logsURL := common.APIURL("/repos/%s/%s/actions/jobs/%d/logs", options.Owner, options.Repo, options.JobID)
+	raw, _, err := common.TypedGitHubRequest[[]byte](logsURL, "GET", nil, apiReqs, common.WithNoCache())
+	if err != nil {
+		return nil, fmt.Errorf("error downloading job log: %w", err)
+	}
+
+	lines := parseJobLog(string(raw))
+	steps := assignLogSteps(lines, job.Steps)
+
+	result := &common.JobLog{
+		JobID:      job.ID,
+		Name:       job.Name,
+		Status:     job.Status,
+		Conclusion: job.Conclusion,
+		HTMLURL:    job.HTMLURL,
+		LineCount:  len(lines),
+		Steps:      steps,
+		Errors:     []common.JobLogError{},
+	}
+	for n, line := range lines {
+		if !line.error {
+			continue
+		}
+		if len(result.Errors) == MAX_LOG_ERRORS {
+			result.ErrorsTruncated = true
+			break
+		}
+		result.Errors = append(result.Errors, common.JobLogError{
+			Line: n + 1,
+			Step: stepAtLine(steps, n+1),
+			Text: line.text,
+		})
+	}
+
+	if options.WholeLog {
+		result.Log, result.LogTruncated = formatWholeLog(lines, steps)
+		return result, nil
+	}
+
+	step, err := selectLogStep(steps, lines, options.Step)
+	if err != nil {
+		return nil, err
+	}
+	if step == nil {
+		return result, nil
+	}
+	result.Step = step
+
+	contextLines := DEFAULT_LOG_CONTEXT_LINES
+	if options.ContextLines > 0 {
+		contextLines = options.ContextLines
+	}
+	result.Excerpts, result.LogTruncated = stepExcerpts(lines, step, contextLines)
+	return result, nil
+}
+
+// parseJobLog splits a job log into lines without timestamps, ANSI escape codes and
+// the markers of workflow commands
+func parseJobLog(log string) []logLine {
+	log = strings.TrimPrefix(log, "\ufeff")
+	log = strings.ReplaceAll(log, "\r\n", "\n")
+	raw := strings.Split(strings.TrimSuffix(log, "\n"), "\n")
+	if log == "" {
+		raw = nil
+	}
+
+	lines := make([]logLine, 0, len(raw))
+	for _, text := range raw {
+		var line logLine
+		if match := logTimestampPattern.FindStringSubmatch(text); match != nil {
+			line.time, _ = time.Parse("2006-01-02T15:04:05", match[1])
+			text = text[len(match[0]):]
+		}
+		text = ansiPattern.ReplaceAllString(text, "")
+
+		switch {
+		case text == "##[endgroup]":
+			continue
+		case strings.HasPrefix(text, "##[group]"):
+			text = strings.TrimPrefix(text, "##[group]")
+			line.runGroup = strings.HasPrefix(text, "Run ")
+		case strings.HasPrefix(text, "##[error]"):
+			text = "Error: " + strings.TrimPrefix(text, "##[error]")
+			line.error = true
+		case strings.HasPrefix(text, "##[warning]"):
+			text = "Warning: " + strings.TrimPrefix(text, "##[warning]")
+		case strings.HasPrefix(text, "##[notice]"):
+			text = "Notice: " + strings.TrimPrefix(text, "##[notice]")
+		case strings.HasPrefix(text, "##[debug]"):
+			text = "Debug: " + strings.TrimPrefix(text, "##[debug]")
+		case strings.HasPrefix(text, "##[command]"):
+			text = strings.TrimPrefix(text, "##[command]")
+		default:
+			line.error = logErrorPattern.MatchString(text)
+		}
+
+		if len(text) > MAX_LOG_LINE_LENGTH {
+			text = strings.ToValidUTF8(text[:MAX_LOG_LINE_LENGTH], "") + " …"
+		}
+		line.text = text
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// assignLogSteps locates the lines each step wrote. The log has no step markers, so the
+// steps are followed through the timestamps of the lines, which have the same one second
+// precision as the start and completion times of the steps. Within a second, the
+// ##[group]Run line that starts the log of an action or script marks the next step.
+func assignLogSteps(lines []logLine, jobSteps []common.WorkflowStep) []common.JobLogStep {
+	steps := make([]common.JobLogStep, len(jobSteps))
+	for i, step := range jobSteps {
+		steps[i] = common.JobLogStep{Number: step.Number, Name: step.Name, Conclusion: step.Conclusion}
+	}
+	if len(steps) == 0 {
+		return steps
+	}
+
+	current := 0
+	sawRunGroup := false
+	for n, line := range lines {
+		if !line.time.IsZero() {
+			for current+1 < len(jobSteps) {
+				next := jobSteps[current+1]
+				if next.StartedAt == nil || line.time.Before(next.StartedAt.Truncate(time.Second)) {
+					break
+				}
+				completed := jobSteps[current].CompletedAt
+				ended := completed != nil && line.time.After(completed.Truncate(time.Second))
+				startsNext := line.runGroup && (sawRunGroup || current == 0)
+				if !ended && !startsNext {
+					break
+				}
+				current++
+				sawRunGroup = false
+				if startsNext {
+					break
+				}
+			}
+		}
+		if line.runGroup {
+			sawRunGroup = true
+		}
+
+		if steps[current].StartLine == 0 {
+			steps[current].StartLine = n + 1
+		}
+		steps[current].EndLine = n + 1
+	}
+	return steps
+}
+
+func stepAtLine(steps []common.JobLogStep, line int) int {
+	for _, step := range steps {
+		if step.StartLine > 0 && line >= step.StartLine && line <= step.EndLine {
+			return step.Number
+		}
+	}
+	return 0
+}
+
+// selectLogStep returns the requested step or, by default, the first failed step. When
+// no step failed, the step of the first error line or else the last step with output is used.
+func selectLogStep(steps []common.JobLogStep, lines []logLine, number int) (*common.JobLogStep, error) {
+	if number > 0 {
+		for i := range steps {
+			if steps[i].Number == number {
+				return &steps[i], nil
+			}
+		}
+		return nil, fmt.Errorf("the job has no step %d", number)
+	}
+
+	for i := range steps {
+		if steps[i].Conclusion == "failure" && steps[i].StartLine > 0 {
+			return &steps[i], nil
+		}
+	}
+	for n, line := range lines {
+		if !line.error {
+			continue
+		}
+		number := stepAtLine(steps, n+1)
+		for i := range steps {
+			if steps[i].Number == number {
+				return &steps[i], nil
+			}
+		}
+	}
+	for i := len(steps) - 1; i >= 0; i-- {
+		if steps[i].StartLine > 0 {
+			return &steps[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// stepExcerpts returns the error lines of a step with context around them and the last
+// lines of the step. The earliest excerpts are dropped when they exceed MAX_JOB_LOG_SIZE.
+func stepExcerpts(lines []logLine, step *common.JobLogStep, contextLines int) ([]common.Excerpt, bool) {
+	if step.StartLine == 0 {
+		return []common.Excerpt{}, false
+	}
+
+	text := make([]string, len(lines))
+	for i, line := range lines {
+		text[i] = line.text
+	}
+
+	matched := make(map[int]bool)
+	var windows [][2]int
+	addWindow := func(from int, to int) {
+		from, to = max(from, step.StartLine), min(to, step.EndLine)
+		// Overlapping or adjacent context is merged into one excerpt
+		if last := len(windows) - 1; last >= 0 && from <= windows[last][1]+1 {
+			windows[last][1] = max(windows[last][1], to)
+			return
+		}
+		windows = append(windows, [2]int{from, to})
+	}
+	for n := step.StartLine; n <= step.EndLine; n++ {
+		if lines[n-1].error {
+			matched[n] = true
+			addWindow(n-contextLines, n+contextLines)
+		}
+	}
+	addWindow(step.EndLine-contextLines+1, step.EndLine)
+
+	excerpts := make([]common.Excerpt, 0, len(windows))
+	size := 0
+	truncated := false
+	for i := len(windows) - 1; i >= 0; i-- {
+		excerpt := formatExcerpt(text, windows[i][0], windows[i][1], matched)
+		if size+len(excerpt.Content) > MAX_JOB_LOG_SIZE {
+			truncated = true
+			if size > 0 {
+				break
+			}
+			excerpt.Content = outputTail(excerpt.Content, MAX_JOB_LOG_SIZE)
+		}
+		size += len(excerpt.Content)
+		excerpts = append([]common.Excerpt{excerpt}, excerpts...)
+	}
+	return excerpts, truncated
+}
+
+// formatWholeLog joins the cleaned log with a header before the output of each step,
+// keeping the last MAX_JOB_LOG_SIZE bytes. It reports whether the log was cut.
+func formatWholeLog(lines []logLine, steps []common.JobLogStep) (string, bool) {
+	headers := make(map[int]string)
+	for _, step := range steps {
+		if step.StartLine > 0 {
+			headers[step.StartLine] = fmt.Sprintf("=== Step %d: %s ===", step.Number, step.Name)
+		}
+	}
+
+	var b strings.Builder
+	for n, line := range lines {
+		if header, ok := headers[n+1]; ok {
+			b.WriteString(header)
+			b.WriteByte('\n')
+		}
+		b.WriteString(line.text)
+		b.WriteByte('\n')
+	}
+
+	log := b.String()
+	if len(log) <= MAX_JOB_LOG_SIZE {
+		return log, false
+	}
+	return outputTail(log, MAX_JOB_LOG_SIZE), true
+}
+
+// outputTail returns the last size bytes of text, starting at a full line
+func outputTail(text string, size int) string {
+	if len(text) <= size {
+		return text
+	}
+	text = text[len(text)-size:]
+	if i := strings.IndexByte(text, '\n'); i >= 0 && i < len(text)-1 {
+		return text[i+1:]
+	}
+	return strings.ToValidUTF8(text, "")
+}
@@ -0,0 +1,253 @@
+package operations
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/metoro-io/github-mcp-server-go/common"
+)
+
+func testJobLog() string {
+	var b strings.Builder
+	b.WriteString("\ufeff")
+	for _, line := range []string{
+		"2024-01-02T10:00:00.1000000Z Current runner version: '2.311.0'",
+		"2024-01-02T10:00:00.2000000Z ##[group]Operating System",
+		"2024-01-02T10:00:00.3000000Z Ubuntu",
+		"2024-01-02T10:00:00.4000000Z ##[endgroup]",
+		"2024-01-02T10:00:01.1000000Z ##[group]Run actions/checkout@v4",
+		"2024-01-02T10:00:01.2000000Z with:",
+		"2024-01-02T10:00:01.3000000Z ##[endgroup]",
+		"2024-01-02T10:00:02.0000000Z Syncing repository",
+		"2024-01-02T10:00:02.5000000Z ##[group]Run go test ./...",
+		"2024-01-02T10:00:02.6000000Z \x1b[36;1mgo test ./...\x1b[0m",
+		"2024-01-02T10:00:02.7000000Z ##[endgroup]",
+	} {
+		b.WriteString(line + "\r\n")
+	}
+	for i := 1; i <= 30; i++ {
+		fmt.Fprintf(&b, "2024-01-02T10:00:03.0000000Z ok  \tpkg%d\t0.01s\r\n", i)
+	}
+	for _, line := range []string{
+		"2024-01-02T10:00:04.0000000Z --- FAIL: TestX (0.00s)",
+		"2024-01-02T10:00:04.0000000Z     x_test.go:12: got 1, want 2",
+		"2024-01-02T10:00:04.0000000Z FAIL",
+		"2024-01-02T10:00:05.0000000Z ##[error]Process completed with exit code 1.",
+		"2024-01-02T10:00:06.0000000Z Cleaning up orphan processes",
+	} {
+		b.WriteString(line + "\r\n")
+	}
+	return b.String()
+}
+
+func TestGetJobLogs(t *testing.T) {
+	tests := []struct {
+		name         string
+		options      GetJobLogsOptions
+		wantStep     int
+		wantExcerpts []string
+		wantLog      []string
+		wantErr      string
+	}{
+		{
+			name:         "failing step with context",
+			options:      GetJobLogsOptions{ContextLines: 2},
+			wantStep:     3,
+			wantExcerpts: []string{"37-42"},
+		},
+		{
+			name:         "failing step with the default context",
+			wantStep:     3,
+			wantExcerpts: []string{"19-42"},
+		},
+		{
+			name:         "requested step",
+			options:      GetJobLogsOptions{Step: 2},
+			wantStep:     2,
+			wantExcerpts: []string{"4-6"},
+		},
+		{
+			name:    "whole log",
+			options: GetJobLogsOptions{WholeLog: true},
+			wantLog: []string{"=== Step 1: Set up job ===\nCurrent runner version", "=== Step 3: Run tests ===\nRun go test ./...\ngo test ./...\n", "=== Step 4: Complete job ===\nCleaning up"},
+		},
+		{name: "unknown step", options: GetJobLogsOptions{Step: 9}, wantErr: "no step 9"},
+		{name: "step and whole log", options: GetJobLogsOptions{Step: 2, WholeLog: true}, wantErr: "cannot be combined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/repos/octo/repo/actions/jobs/5":
+					fmt.Fprint(w, `{"id":5,"name":"test","status":"completed","conclusion":"failure","steps":[
+						{"number":1,"name":"Set up job","conclusion":"success","started_at":"2024-01-02T10:00:00Z","completed_at":"2024-01-02T10:00:01Z"},
+						{"number":2,"name":"Checkout","conclusion":"success","started_at":"2024-01-02T10:00:01Z","completed_at":"2024-01-02T10:00:02Z"},
+						{"number":3,"name":"Run tests","conclusion":"failure","started_at":"2024-01-02T10:00:02Z","completed_at":"2024-01-02T10:00:05Z"},
+						{"number":4,"name":"Complete job","conclusion":"success","started_at":"2024-01-02T10:00:05Z","completed_at":"2024-01-02T10:00:06Z"}]}`)
+				case "/repos/octo/repo/actions/jobs/5/logs":
+					http.Redirect(w, r, "/archive/job-5.txt", http.StatusFound)
+				case "/archive/job-5.txt":
+					fmt.Fprint(w, testJobLog())
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			defer common.Configure(common.DefaultClientOptions())
+			common.Configure(common.ClientOptions{BaseURL: server.URL, Token: "test"})
+
+			options := tt.options
+			options.Owner, options.Repo, options.JobID = "octo", "repo", 5
+			result, err := GetJobLogs(&options, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("GetJobLogs() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetJobLogs() error = %v", err)
+			}
+
+			var ranges []string
+			for _, step := range result.Steps {
+				ranges = append(ranges, fmt.Sprintf("%d-%d", step.StartLine, step.EndLine))
+			}
+			if got := strings.Join(ranges, ","); got != "1-3,4-6,7-42,43-43" {
+				t.Errorf("step lines = %s, want 1-3,4-6,7-42,43-43", got)
+			}
+
+			var errors []string
+			for _, e := range result.Errors {
+				errors = append(errors, fmt.Sprintf("%d/%d %s", e.Line, e.Step, e.Text))
+			}
+			wantErrors := "39/3 --- FAIL: TestX (0.00s),41/3 FAIL,42/3 Error: Process completed with exit code 1."
+			if got := strings.Join(errors, ","); got != wantErrors {
+				t.Errorf("Errors = %s, want %s", got, wantErrors)
+			}
+
+			if tt.wantLog != nil {
+				for _, want := range tt.wantLog {
+					if !strings.Contains(result.Log, want) {
+						t.Errorf("Log should contain %q, got:\n%s", want, result.Log)
+					}
+				}
+				if strings.Contains(result.Log, "\x1b") || strings.Contains(result.Log, "2024-01-02T") || strings.Contains(result.Log, "\r") {
+					t.Errorf("Log was not cleaned:\n%s", result.Log)
+				}
+				return
+			}
+
+			if result.Step == nil || result.Step.Number != tt.wantStep {
+				t.Fatalf("Step = %+v, want step %d", result.Step, tt.wantStep)
+			}
+			var excerpts []string
+			for _, excerpt := range result.Excerpts {
+				excerpts = append(excerpts, fmt.Sprintf("%d-%d", excerpt.StartLine, excerpt.EndLine))
+			}
+			if strings.Join(excerpts, ",") != strings.Join(tt.wantExcerpts, ",") {
+				t.Errorf("excerpts = %v, want %v", excerpts, tt.wantExcerpts)
+			}
+			if tt.wantStep == 3 && !strings.Contains(result.Excerpts[0].Content, "39: --- FAIL: TestX (0.00s)\n40-     x_test.go:12") {
+				t.Errorf("excerpt should mark the error lines, got:\n%s", result.Excerpts[0].Content)
+			}
+		})
+	}
+}
+
+func TestStepExcerptsSizeLimit(t *testing.T) {
+	var lines []logLine
+	for i := 0; i < 2000; i++ {
+		lines = append(lines, logLine{text: strings.Repeat("x", 100), error: i%100 == 0})
+	}
+	step := &common.JobLogStep{Number: 1, StartLine: 1, EndLine: len(lines)}
+
+	excerpts, truncated := stepExcerpts(lines, step, 30)
+	if !truncated {
+		t.Errorf("truncated = false, want true")
+	}
+	size := 0
+	for _, excerpt := range excerpts {
+		size += len(excerpt.Content)
+	}
+	if size > MAX_JOB_LOG_SIZE {
+		t.Errorf("excerpts hold %d bytes, want at most %d", size, MAX_JOB_LOG_SIZE)
+	}
+	if last := excerpts[len(excerpts)-1]; last.EndLine != len(lines) {
+		t.Errorf("last excerpt ends at line %d, want the end of the step", last.EndLine)
+	}
+}
+
+func TestGetJobLogsOfRunningJob(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octo/repo/actions/jobs/5":
+			fmt.Fprint(w, `{"id":5,"name":"test","status":"in_progress","steps":[{"number":1,"name":"Run tests","status":"in_progress","started_at":"2024-01-02T10:00:00Z"}]}`)
+		case "/repos/octo/repo/actions/jobs/5/logs":
+			requests++
+			for i := 1; i <= requests; i++ {
+				fmt.Fprintf(w, "2024-01-02T10:00:0%d.0000000Z line %d\n", i, i)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	defer common.Configure(common.DefaultClientOptions())
+	common.Configure(common.ClientOptions{
+		BaseURL: server.URL,
+		Token:   "test",
+		Cache:   common.CacheOptions{Enabled: true, TTL: time.Hour},
+	})
+
+	for want := 1; want <= 2; want++ {
+		result, err := GetJobLogs(&GetJobLogsOptions{Owner: "octo", Repo: "repo", JobID: 5, WholeLog: true}, nil)
+		if err != nil {
+			t.Fatalf("GetJobLogs() error = %v", err)
+		}
+		if result.LineCount != want {
+			t.Errorf("LineCount = %d, want %d as the log grows", result.LineCount, want)
+		}
+	}
+}
+
+func TestGetJobLogsTruncation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octo/repo/actions/jobs/5":
+			fmt.Fprint(w, `{"id":5,"name":"test","status":"completed","conclusion":"failure","steps":[{"number":1,"name":"Run tests","conclusion":"failure","started_at":"2024-01-02T10:00:00Z","completed_at":"2024-01-02T10:00:09Z"}]}`)
+		case "/repos/octo/repo/actions/jobs/5/logs":
+			for i := 1; i <= MAX_LOG_ERRORS+10; i++ {
+				fmt.Fprintf(w, "2024-01-02T10:00:01.0000000Z --- FAIL: Test%d (0.00s)\n", i)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	defer common.Configure(common.DefaultClientOptions())
+	common.Configure(common.ClientOptions{BaseURL: server.URL, Token: "test"})
+
+	for _, options := range []GetJobLogsOptions{{}, {WholeLog: true}} {
+		options.Owner, options.Repo, options.JobID = "octo", "repo", 5
+		result, err := GetJobLogs(&options, nil)
+		if err != nil {
+			t.Fatalf("GetJobLogs() error = %v", err)
+		}
+		if len(result.Errors) != MAX_LOG_ERRORS || !result.ErrorsTruncated {
+			t.Errorf("Errors has %d lines, errors_truncated = %v, want %d and true", len(result.Errors), result.ErrorsTruncated, MAX_LOG_ERRORS)
+		}
+		if result.LogTruncated {
+			t.Errorf("log_truncated = true, want false as the whole log fits")
+		}
+	}
+}
@@ -0,0 +1,572 @@
+package operations
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/metoro-io/github-mcp-server-go/common"
+)
+
+// DEFAULT_PATCH_FUZZ is the number of leading and trailing context lines a hunk may ignore by default
+const DEFAULT_PATCH_FUZZ = 2
+
+// ApplyPatchOptions defines options for applying a unified diff as a commit
+type ApplyPatchOptions struct {
+	Owner   string `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo    string `json:"repo" jsonschema:"description=The name of the repository to patch"`
+	Branch  string `json:"branch" jsonschema:"description=The branch to apply the patch to"`
+	Message string `json:"message" jsonschema:"description=The commit message for the patch"`
+	Patch   string `json:"patch" jsonschema:"description=A unified diff such as the output of git diff. May create modify delete and rename text files"`
+	Fuzz    *int   `json:"fuzz,omitempty" jsonschema:"description=Maximum number of leading and trailing context lines a hunk may ignore when its context does not match exactly. Default: 2"`
+}
+
+// Validate validates the ApplyPatchOptions
+func (o *ApplyPatchOptions) Validate() error {
+	if _, err := common.ValidateOwnerName(o.Owner); err != nil {
+		return err
+	}
+	if _, err := common.ValidateRepositoryName(o.Repo); err != nil {
+		return err
+	}
+	if _, err := common.ValidateBranchName(o.Branch); err != nil {
+		return err
+	}
+	if o.Message == "" {
+		return fmt.Errorf("commit message is required")
+	}
+	if strings.TrimSpace(o.Patch) == "" {
+		return fmt.Errorf("patch is required")
+	}
+	if o.Fuzz != nil && (*o.Fuzz < 0 || *o.Fuzz > 3) {
+		return fmt.Errorf("fuzz must be between 0 and 3")
+	}
+	return nil
+}
+
+// PatchRejectedError is returned when hunks of a patch do not apply. Nothing is committed.
+type PatchRejectedError struct {
+	Rejected []common.RejectedHunk
+}
+
+func (e *PatchRejectedError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "patch does not apply, %d hunk(s) rejected:", len(e.Rejected))
+	for _, r := range e.Rejected {
+		if r.Hunk == 0 {
+			fmt.Fprintf(&b, "\n- %s: %s", r.Path, r.Reason)
+		} else {
+			fmt.Fprintf(&b, "\n- %s hunk %d (%s): %s", r.Path, r.Hunk, r.Header, r.Reason)
+		}
+	}
+	return b.String()
+}
+
+// ApplyPatch applies a unified diff to a branch and commits the result in one commit
+func ApplyPatch(options *ApplyPatchOptions, apiReqs *common.APIRequirements) (*common.ApplyPatchResult, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	filePatches, err := parsePatch(options.Patch)
+	if err != nil {
+		return nil, err
+	}
+
+	fuzz := DEFAULT_PATCH_FUZZ
+	if options.Fuzz != nil {
+		fuzz = *options.Fuzz
+	}
+
+	// Hunks are checked against the commit the new commit will be based on
+	headSHA, err := getBranchSHA(options.Owner, options.Repo, options.Branch, apiReqs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &common.ApplyPatchResult{}
+	var rejected []common.RejectedHunk
+	var pushFiles []PushFileDefinition
+	for _, fp := range filePatches {
+		patched := common.PatchedFile{
+			Path:   fp.newPath,
+			Status: fp.status(),
+		}
+
+		if fp.newMode != "" && fp.newMode != FILE_MODE_REGULAR && fp.newMode != FILE_MODE_EXECUTABLE {
+			rejected = append(rejected, common.RejectedHunk{Path: fp.path(), Reason: fmt.Sprintf("mode %s is not supported, only regular and executable files can be patched", fp.newMode)})
+			continue
+		}
+
+		var content string
+		switch patched.Status {
+		case common.PATCH_STATUS_ADDED:
+			_, _, err := getContents(options.Owner, options.Repo, fp.newPath, headSHA, apiReqs)
+			if err == nil {
+				rejected = append(rejected, common.RejectedHunk{Path: fp.newPath, Reason: "file already exists"})
+				continue
+			} else if !common.IsNotFound(err) {
+				return nil, fmt.Errorf("error checking for existing file %s: %w", fp.newPath, err)
+			}
+		default:
+			_, content, err = readTextFile(options.Owner, options.Repo, fp.oldPath, headSHA, apiReqs)
+			if common.IsNotFound(err) {
+				rejected = append(rejected, common.RejectedHunk{Path: fp.oldPath, Reason: "file does not exist"})
+				continue
+			} else if err != nil {
+				return nil, fmt.Errorf("error reading %s: %w", fp.oldPath, err)
+			}
+		}
+
+		newContent, notes, rejects := applyFilePatch(content, fp, fuzz)
+		if len(rejects) > 0 {
+			rejected = append(rejected, rejects...)
+			continue
+		}
+		if patched.Status == common.PATCH_STATUS_MODIFIED && newContent == content && fp.newMode == "" {
+			continue
+		}
+		patched.Hunks = len(fp.hunks)
+		patched.Notes = notes
+
+		switch patched.Status {
+		case common.PATCH_STATUS_DELETED:
+			if newContent != "" {
+				rejected = append(rejected, common.RejectedHunk{Path: fp.oldPath, Reason: "file is not empty after removing the lines in the patch"})
+				continue
+			}
+			patched.Path = fp.oldPath
+			pushFiles = append(pushFiles, PushFileDefinition{Path: fp.oldPath, Delete: true})
+		case common.PATCH_STATUS_RENAMED:
+			patched.PreviousPath = fp.oldPath
+			if newContent == "" && content != "" {
+				// An empty content would move the file unchanged
+				pushFiles = append(pushFiles,
+					PushFileDefinition{Path: fp.newPath, Mode: fp.newMode},
+					PushFileDefinition{Path: fp.oldPath, Delete: true})
+			} else if newContent == content {
+				pushFiles = append(pushFiles, PushFileDefinition{Path: fp.newPath, RenameFrom: fp.oldPath, Mode: fp.newMode})
+			} else {
+				pushFiles = append(pushFiles, PushFileDefinition{Path: fp.newPath, RenameFrom: fp.oldPath, Content: newContent, Mode: fp.newMode})
+			}
+		default:
+			pushFiles = append(pushFiles, PushFileDefinition{Path: fp.newPath, Content: newContent, Mode: fp.newMode})
+		}
+		result.Files = append(result.Files, patched)
+	}
+
+	if len(rejected) > 0 {
+		return nil, &PatchRejectedError{Rejected: rejected}
+	}
+	if len(pushFiles) == 0 {
+		return nil, fmt.Errorf("patch does not change any file")
+	}
+
+	commit, err := commitFiles(&PushFilesOptions{
+		Owner:   options.Owner,
+		Repo:    options.Repo,
+		Branch:  options.Branch,
+		Message: options.Message,
+		Files:   pushFiles,
+		BaseSHA: headSHA,
+	}, apiReqs)
+	if err != nil {
+		return nil, fmt.Errorf("error committing patch: %w", err)
+	}
+
+	result.Commit = *commit
+	return result, nil
+}
+
+// filePatch is the part of a unified diff touching one file. An empty oldPath
+// means the file is created, an empty newPath that it is deleted.
+type filePatch struct {
+	oldPath string
+	newPath string
+	hunks   []*hunk
+	// newMode is the mode set by "new file mode" or "new mode" headers
+	newMode string
+	// hasFileHeader is set once the ---/+++ lines were read
+	hasFileHeader bool
+}
+
+func (fp *filePatch) status() string {
+	switch {
+	case fp.oldPath == "":
+		return common.PATCH_STATUS_ADDED
+	case fp.newPath == "":
+		return common.PATCH_STATUS_DELETED
+	case fp.oldPath != fp.newPath:
+		return common.PATCH_STATUS_RENAMED
+	default:
+		return common.PATCH_STATUS_MODIFIED
+	}
+}
+
+func (fp *filePatch) path() string {
+	if fp.newPath != "" {
+		return fp.newPath
+	}
+	return fp.oldPath
+}
+
+// hunk is a single @@ section of a unified diff
+type hunk struct {
+	header   string
+	oldStart int
+	oldLines int
+	newStart int
+	newLines int
+	lines    []hunkLine
+	// oldNoEOL and newNoEOL record "\ No newline at end of file" markers
+	oldNoEOL bool
+	newNoEOL bool
+}
+
+type hunkLine struct {
+	op   byte
+	text string
+}
+
+// sides returns the lines the hunk expects to find and the lines it replaces them with
+func (h *hunk) sides() ([]string, []string) {
+	var before, after []string
+	for _, l := range h.lines {
+		if l.op != '+' {
+			before = append(before, l.text)
+		}
+		if l.op != '-' {
+			after = append(after, l.text)
+		}
+	}
+	return before, after
+}
+
+// contextBounds returns the number of context lines at the start and end of the hunk
+func (h *hunk) contextBounds() (int, int) {
+	leading, trailing := 0, 0
+	for _, l := range h.lines {
+		if l.op != ' ' {
+			break
+		}
+		leading++
+	}
+	for i := len(h.lines) - 1; i >= 0 && h.lines[i].op == ' '; i-- {
+		trailing++
+	}
+	if leading == len(h.lines) {
+		trailing = 0
+	}
+	return leading, trailing
+}
+
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// parsePatch parses a unified diff, with or without git extended headers
+func parsePatch(patch string) ([]*filePatch, error) {
+	lines := strings.Split(strings.ReplaceAll(patch, "\r\n", "\n"), "\n")
+
+	var files []*filePatch
+	var current *filePatch
+	startFile := func() {
+		current = &filePatch{}
+		files = append(files, current)
+	}
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			startFile()
+			rest := strings.TrimPrefix(line, "diff --git ")
+			if oldPath, newPath, ok := strings.Cut(rest, " b/"); ok {
+				current.oldPath = strings.TrimPrefix(oldPath, "a/")
+				current.newPath = newPath
+			}
+		case current != nil && strings.HasPrefix(line, "rename from "):
+			current.oldPath = strings.TrimPrefix(line, "rename from ")
+		case current != nil && strings.HasPrefix(line, "rename to "):
+			current.newPath = strings.TrimPrefix(line, "rename to ")
+		case current != nil && strings.HasPrefix(line, "new file mode "):
+			current.oldPath = ""
+			current.newMode = strings.TrimPrefix(line, "new file mode ")
+		case current != nil && strings.HasPrefix(line, "new mode "):
+			current.newMode = strings.TrimPrefix(line, "new mode ")
+		case current != nil && strings.HasPrefix(line, "deleted file mode "):
+			current.newPath = ""
+		case strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch":
+			path := "unknown file"
+			if current != nil {
+				path = current.path()
+			}
+			return nil, fmt.Errorf("binary patches are not supported (%s), use push_files with base64 content instead", path)
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			if current == nil || current.hasFileHeader || len(current.hunks) > 0 {
+				startFile()
+			}
+			current.oldPath = parsePatchPath(strings.TrimPrefix(line, "--- "), "a/")
+			current.newPath = parsePatchPath(strings.TrimPrefix(lines[i+1], "+++ "), "b/")
+			current.hasFileHeader = true
+			i += 2
+			continue
+		case strings.HasPrefix(line, "@@ "):
+			if current == nil {
+				return nil, fmt.Errorf("hunk at line %d of the patch has no file header", i+1)
+			}
+			h, next, err := parseHunk(lines, i)
+			if err != nil {
+				return nil, fmt.Errorf("invalid hunk in %s: %w", current.path(), err)
+			}
+			current.hunks = append(current.hunks, h)
+			i = next
+			continue
+		}
+		i++
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("patch contains no file changes")
+	}
+	for _, fp := range files {
+		if fp.oldPath == "" && fp.newPath == "" {
+			return nil, fmt.Errorf("patch contains a file without a path")
+		}
+	}
+	return files, nil
+}
+
+// parsePatchPath parses the path of a ---/+++ line, returning "" for /dev/null
+func parsePatchPath(value string, prefix string) string {
+	// Some tools append a tab and a timestamp
+	if before, _, ok := strings.Cut(value, "\t"); ok {
+		value = before
+	}
+	value = strings.TrimSpace(value)
+	if value == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(value, prefix)
+}
+
+// parseHunk parses the hunk starting at lines[start] and returns the index of the line after it
+func parseHunk(lines []string, start int) (*hunk, int, error) {
+	match := hunkHeaderPattern.FindStringSubmatch(lines[start])
+	if match == nil {
+		return nil, 0, fmt.Errorf("malformed hunk header at line %d: %q", start+1, lines[start])
+	}
+
+	count := func(s string) int {
+		if s == "" {
+			return 1
+		}
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	h := &hunk{header: lines[start]}
+	h.oldStart, _ = strconv.Atoi(match[1])
+	h.oldLines = count(match[2])
+	h.newStart, _ = strconv.Atoi(match[3])
+	h.newLines = count(match[4])
+	if end := strings.Index(h.header[2:], "@@"); end >= 0 {
+		h.header = h.header[:end+4]
+	}
+
+	oldSeen, newSeen := 0, 0
+	i := start + 1
+	for ; i < len(lines) && (oldSeen < h.oldLines || newSeen < h.newLines); i++ {
+		line := lines[i]
+		if line == "" {
+			// Editors often strip the single space of empty context lines
+			line = " "
+		}
+		switch line[0] {
+		case ' ':
+			oldSeen++
+			newSeen++
+		case '-':
+			oldSeen++
+		case '+':
+			newSeen++
+		case '\\':
+			h.markNoEOL()
+			continue
+		default:
+			return nil, 0, fmt.Errorf("hunk %q ends at line %d after %d of %d old and %d of %d new lines",
+				h.header, i+1, oldSeen, h.oldLines, newSeen, h.newLines)
+		}
+		h.lines = append(h.lines, hunkLine{op: line[0], text: line[1:]})
+	}
+	if oldSeen < h.oldLines || newSeen < h.newLines {
+		return nil, 0, fmt.Errorf("hunk %q is truncated", h.header)
+	}
+	if oldSeen > h.oldLines || newSeen > h.newLines {
+		return nil, 0, fmt.Errorf("hunk %q has more lines than its header declares", h.header)
+	}
+	if i < len(lines) && strings.HasPrefix(lines[i], "\\") {
+		h.markNoEOL()
+		i++
+	}
+	return h, i, nil
+}
+
+// markNoEOL applies a "\ No newline at end of file" marker to the line before it
+func (h *hunk) markNoEOL() {
+	if len(h.lines) == 0 {
+		return
+	}
+	switch h.lines[len(h.lines)-1].op {
+	case '-':
+		h.oldNoEOL = true
+	case '+':
+		h.newNoEOL = true
+	default:
+		h.oldNoEOL = true
+		h.newNoEOL = true
+	}
+}
+
+// applyFilePatch applies the hunks of fp to content. Hunks are searched for near their
+// declared position, then with up to fuzz context lines ignored at either end.
+func applyFilePatch(content string, fp *filePatch, fuzz int) (string, []string, []common.RejectedHunk) {
+	lines, eol := splitLines(content)
+
+	var out []string
+	var notes []string
+	var rejected []common.RejectedHunk
+	cursor, offset := 0, 0
+	for n, h := range fp.hunks {
+		before, after := h.sides()
+
+		expected := h.oldStart - 1
+		if h.oldLines == 0 {
+			// Pure insertions name the line they follow
+			expected = h.oldStart
+		}
+
+		pos, lead, trail, ok := locateHunk(lines, before, h, expected+offset, cursor, fuzz)
+		if !ok {
+			rejected = append(rejected, common.RejectedHunk{
+				Path:   fp.path(),
+				Hunk:   n + 1,
+				Header: h.header,
+				Reason: describeMismatch(lines, before, expected+offset, cursor, fuzz),
+			})
+			continue
+		}
+
+		if pos-lead != expected || lead > 0 || trail > 0 {
+			note := fmt.Sprintf("hunk %d applied at line %d", n+1, pos-lead+1)
+			if pos-lead != expected {
+				note += fmt.Sprintf(" (offset %d)", pos-lead-expected)
+			}
+			if lead > 0 || trail > 0 {
+				note += fmt.Sprintf(" with fuzz %d", max(lead, trail))
+			}
+			notes = append(notes, note)
+		}
+		offset = pos - lead - expected
+
+		matched := before[lead : len(before)-trail]
+		out = append(out, lines[cursor:pos]...)
+		out = append(out, after[lead:len(after)-trail]...)
+		cursor = pos + len(matched)
+
+		if cursor == len(lines) {
+			if h.newNoEOL {
+				eol = false
+			} else if h.oldNoEOL {
+				eol = true
+			}
+		}
+	}
+	out = append(out, lines[cursor:]...)
+
+	return joinLines(out, eol), notes, rejected
+}
+
+// locateHunk finds where before occurs in lines, at or after minPos, searching outwards
+// from expected. It returns the position of the first matched line and how many leading
+// and trailing context lines had to be ignored.
+func locateHunk(lines []string, before []string, h *hunk, expected int, minPos int, fuzz int) (int, int, int, bool) {
+	leading, trailing := h.contextBounds()
+	for f := 0; f <= fuzz; f++ {
+		lead, trail := min(f, leading), min(f, trailing)
+		if f > 0 && lead == min(f-1, leading) && trail == min(f-1, trailing) {
+			// The hunk has no more context to ignore
+			break
+		}
+		candidate := before[lead : len(before)-trail]
+		if len(candidate) == 0 && len(before) > 0 {
+			break
+		}
+
+		start := expected + lead
+		for d := 0; ; d++ {
+			below, above := start+d, start-d
+			if below > len(lines)-len(candidate) && above < minPos {
+				break
+			}
+			if below >= minPos && below <= len(lines)-len(candidate) && linesMatch(lines[below:], candidate) {
+				return below, lead, trail, true
+			}
+			if d > 0 && above >= minPos && above <= len(lines)-len(candidate) && linesMatch(lines[above:], candidate) {
+				return above, lead, trail, true
+			}
+			if len(candidate) == 0 {
+				// Insertions without context only apply at their declared position
+				break
+			}
+		}
+	}
+	return 0, 0, 0, false
+}
+
+func linesMatch(lines []string, expected []string) bool {
+	if len(lines) < len(expected) {
+		return false
+	}
+	for i, line := range expected {
+		if lines[i] != line {
+			return false
+		}
+	}
+	return true
+}
+
+// describeMismatch explains why a hunk did not apply at its expected position
+func describeMismatch(lines []string, before []string, expected int, minPos int, fuzz int) string {
+	suffix := fmt.Sprintf(" and it was not found elsewhere in the file with fuzz %d", fuzz)
+	if expected < minPos {
+		return fmt.Sprintf("hunk overlaps the previous hunk at line %d%s", expected+1, suffix)
+	}
+	for i, want := range before {
+		n := expected + i
+		if n >= len(lines) {
+			return fmt.Sprintf("expected line %d to be %q but the file has only %d lines%s", n+1, want, len(lines), suffix)
+		}
+		if lines[n] != want {
+			return fmt.Sprintf("expected line %d to be %q but found %q%s", n+1, want, lines[n], suffix)
+		}
+	}
+	return "hunk does not apply" + suffix
+}
+
+// splitLines splits content into lines and reports whether it ends with a newline
+func splitLines(content string) ([]string, bool) {
+	if content == "" {
+		return nil, true
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n"), strings.HasSuffix(content, "\n")
+}
+
+func joinLines(lines []string, eol bool) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	content := strings.Join(lines, "\n")
+	if eol {
+		content += "\n"
+	}
+	return content
+}
@@ -0,0 +1,170 @@
+package operations
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/metoro-io/github-mcp-server-go/common"
+)
+
+func TestParsePatch(t *testing.T) {
+	patch := `diff --git a/main.go b/main.go
+index 3b18e51..a1b2c3d 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+-var x = 1
++var x = 2
+
+diff --git a/docs/old.md b/docs/new.md
+similarity index 100%
+rename from docs/old.md
+rename to docs/new.md
+diff --git a/added.txt b/added.txt
+new file mode 100644
+index 0000000..e69de29
+--- /dev/null
++++ b/added.txt
+@@ -0,0 +1,2 @@
++one
++two
+\ No newline at end of file
+diff --git a/removed.txt b/removed.txt
+deleted file mode 100644
+--- a/removed.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+`
+
+	files, err := parsePatch(patch)
+	if err != nil {
+		t.Fatalf("parsePatch() error = %v", err)
+	}
+
+	want := []struct {
+		oldPath string
+		newPath string
+		status  string
+		hunks   int
+	}{
+		{"main.go", "main.go", common.PATCH_STATUS_MODIFIED, 1},
+		{"docs/old.md", "docs/new.md", common.PATCH_STATUS_RENAMED, 0},
+		{"", "added.txt", common.PATCH_STATUS_ADDED, 1},
+		{"removed.txt", "", common.PATCH_STATUS_DELETED, 1},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("parsePatch() returned %d files, want %d", len(files), len(want))
+	}
+	for i, w := range want {
+		fp := files[i]
+		if fp.oldPath != w.oldPath || fp.newPath != w.newPath || fp.status() != w.status || len(fp.hunks) != w.hunks {
+			t.Errorf("file %d = %s -> %s (%s, %d hunks), want %s -> %s (%s, %d hunks)",
+				i, fp.oldPath, fp.newPath, fp.status(), len(fp.hunks), w.oldPath, w.newPath, w.status, w.hunks)
+		}
+	}
+	if !files[2].hunks[0].newNoEOL {
+		t.Errorf("the no newline marker of added.txt was not recorded")
+	}
+
+	if _, err := parsePatch("--- a/x\n+++ b/x\n@@ -1,3 +1,3 @@\n a\n-b\n"); err == nil || !strings.Contains(err.Error(), "truncated") {
+		t.Errorf("parsePatch() error = %v, want a truncated hunk error", err)
+	}
+	if _, err := parsePatch("just some text"); err == nil {
+		t.Errorf("parsePatch() should fail for text without file changes")
+	}
+}
+
+func TestApplyFilePatch(t *testing.T) {
+	content := "one\ntwo\nthree\nfour\nfive\nsix\nseven\n"
+
+	tests := []struct {
+		name        string
+		content     string
+		patch       string
+		fuzz        int
+		want        string
+		wantNotes   int
+		wantRejects string
+	}{
+		{
+			name:    "exact match",
+			content: content,
+			patch:   "--- a/f\n+++ b/f\n@@ -2,3 +2,3 @@\n two\n-three\n+THREE\n four\n",
+			want:    "one\ntwo\nTHREE\nfour\nfive\nsix\nseven\n",
+		},
+		{
+			name:      "offset",
+			content:   "zero\n" + content,
+			patch:     "--- a/f\n+++ b/f\n@@ -2,3 +2,3 @@\n two\n-three\n+THREE\n four\n",
+			want:      "zero\none\ntwo\nTHREE\nfour\nfive\nsix\nseven\n",
+			wantNotes: 1,
+		},
+		{
+			name:      "fuzz ignores changed context",
+			content:   content,
+			patch:     "--- a/f\n+++ b/f\n@@ -1,5 +1,5 @@\n ONE\n two\n-three\n+THREE\n four\n five\n",
+			fuzz:      1,
+			want:      "one\ntwo\nTHREE\nfour\nfive\nsix\nseven\n",
+			wantNotes: 1,
+		},
+		{
+			name:        "changed context without fuzz",
+			content:     content,
+			patch:       "--- a/f\n+++ b/f\n@@ -1,5 +1,5 @@\n ONE\n two\n-three\n+THREE\n four\n five\n",
+			wantRejects: `expected line 1 to be "ONE" but found "one"`,
+		},
+		{
+			name:        "removed line does not match",
+			content:     content,
+			patch:       "--- a/f\n+++ b/f\n@@ -2,3 +2,3 @@\n two\n-drei\n+THREE\n four\n",
+			fuzz:        2,
+			wantRejects: `expected line 3 to be "drei" but found "three"`,
+		},
+		{
+			name:    "two hunks",
+			content: content,
+			patch:   "--- a/f\n+++ b/f\n@@ -1,2 +1,3 @@\n one\n+one and a half\n two\n@@ -6,2 +7,2 @@\n six\n-seven\n+SEVEN\n",
+			want:    "one\none and a half\ntwo\nthree\nfour\nfive\nsix\nSEVEN\n",
+		},
+		{
+			name:    "remove trailing newline",
+			content: "a\nb\n",
+			patch:   "--- a/f\n+++ b/f\n@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n",
+			want:    "a\nb",
+		},
+		{
+			name:    "create file",
+			content: "",
+			patch:   "--- /dev/null\n+++ b/f\n@@ -0,0 +1,2 @@\n+new\n+file\n",
+			want:    "new\nfile\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := parsePatch(tt.patch)
+			if err != nil {
+				t.Fatalf("parsePatch() error = %v", err)
+			}
+
+			got, notes, rejected := applyFilePatch(tt.content, files[0], tt.fuzz)
+			if tt.wantRejects != "" {
+				if len(rejected) != 1 || !strings.Contains(rejected[0].Reason, tt.wantRejects) {
+					t.Errorf("applyFilePatch() rejected = %+v, want a reason containing %q", rejected, tt.wantRejects)
+				}
+				return
+			}
+			if len(rejected) > 0 {
+				t.Fatalf("applyFilePatch() rejected = %+v", rejected)
+			}
+			if got != tt.want {
+				t.Errorf("applyFilePatch() = %q, want %q", got, tt.want)
+			}
+			if len(notes) != tt.wantNotes {
+				t.Errorf("notes = %v, want %d notes", notes, tt.wantNotes)
+			}
+		})
+	}
+}
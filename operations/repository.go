@@ -1,7 +1,6 @@
 package operations
 
 import (
-	"encoding/json"
 	"fmt"
 	"strconv"
 
@@ -86,31 +85,21 @@ func (o *ForkRepositoryOptions) Validate() error {
 // CreateRepository creates a new GitHub repository
 func CreateRepository(options *CreateRepositoryOptions, apiReqs *common.APIRequirements) (*common.GitHubRepository, error) {
 	if err := options.Validate(); err != nil {
-		return nil, err
-	}
-
-	resp, err := common.GitHubRequest("https://api.github.com/user/repos", "POST", options, apiReqs)
-	if err != nil {
-		return nil, err
+		return nil, common.InvalidInput(err)
 	}
 
-	var repo common.GitHubRepository
-	jsonData, err := json.Marshal(resp)
+	repo, _, err := common.TypedGitHubRequest[common.GitHubRepository](common.APIURL("/user/repos"), "POST", options, apiReqs)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := json.Unmarshal(jsonData, &repo); err != nil {
-		return nil, err
-	}
-
 	return &repo, nil
 }
 
 // SearchRepositories searches for GitHub repositories
 func SearchRepositories(options *SearchRepositoriesOptions, apiReqs *common.APIRequirements) (*common.GitHubSearchResponse, error) {
 	if err := options.Validate(); err != nil {
-		return nil, err
+		return nil, common.InvalidInput(err)
 	}
 
 	if options.Page <= 0 {
@@ -126,36 +115,26 @@ func SearchRepositories(options *SearchRepositoriesOptions, apiReqs *common.APIR
 		"per_page": strconv.Itoa(options.PerPage),
 	}
 
-	url, err := common.BuildURL("https://api.github.com/search/repositories", params)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := common.GitHubRequest(url, "GET", nil, apiReqs)
+	url, err := common.BuildURL(common.APIURL("/search/repositories"), params)
 	if err != nil {
 		return nil, err
 	}
 
-	var searchResp common.GitHubSearchResponse
-	jsonData, err := json.Marshal(resp)
+	searchResp, _, err := common.TypedGitHubRequest[common.GitHubSearchResponse](url, "GET", nil, apiReqs)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := json.Unmarshal(jsonData, &searchResp); err != nil {
-		return nil, err
-	}
-
 	return &searchResp, nil
 }
 
 // ForkRepository forks a GitHub repository
 func ForkRepository(options *ForkRepositoryOptions, apiReqs *common.APIRequirements) (*common.GitHubRepository, error) {
 	if err := options.Validate(); err != nil {
-		return nil, err
+		return nil, common.InvalidInput(err)
 	}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/forks", options.Owner, options.Repo)
+	url := common.APIURL("/repos/%s/%s/forks", options.Owner, options.Repo)
 	if options.Organization != "" {
 		params := map[string]string{
 			"organization": options.Organization,
@@ -167,20 +146,10 @@ func ForkRepository(options *ForkRepositoryOptions, apiReqs *common.APIRequireme
 		}
 	}
 
-	resp, err := common.GitHubRequest(url, "POST", nil, apiReqs)
-	if err != nil {
-		return nil, err
-	}
-
-	var repo common.GitHubRepository
-	jsonData, err := json.Marshal(resp)
+	repo, _, err := common.TypedGitHubRequest[common.GitHubRepository](url, "POST", nil, apiReqs)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := json.Unmarshal(jsonData, &repo); err != nil {
-		return nil, err
-	}
-
 	return &repo, nil
 }
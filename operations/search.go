@@ -1,7 +1,6 @@
 package operations
 
 import (
-	"encoding/json"
 	"fmt"
 	"strconv"
 
@@ -56,10 +55,10 @@ func (o *SearchUsersOptions) Validate() error {
 // SearchCode searches for code across GitHub repositories
 func SearchCode(options *SearchCodeOptions, apiReqs *common.APIRequirements) (*common.GitHubSearchCodeResponse, error) {
 	if err := options.Validate(); err != nil {
-		return nil, err
+		return nil, common.InvalidInput(err)
 	}
 
-	url := "https://api.github.com/search/code"
+	url := common.APIURL("/search/code")
 
 	params := map[string]string{
 		"q": options.Query,
@@ -78,31 +77,21 @@ func SearchCode(options *SearchCodeOptions, apiReqs *common.APIRequirements) (*c
 		return nil, err
 	}
 
-	resp, err := common.GitHubRequest(fullURL, "GET", nil, apiReqs)
-	if err != nil {
-		return nil, err
-	}
-
-	var searchResp common.GitHubSearchCodeResponse
-	jsonData, err := json.Marshal(resp)
+	searchResp, _, err := common.TypedGitHubRequest[common.GitHubSearchCodeResponse](fullURL, "GET", nil, apiReqs)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := json.Unmarshal(jsonData, &searchResp); err != nil {
-		return nil, err
-	}
-
 	return &searchResp, nil
 }
 
 // SearchIssues searches for issues and pull requests across GitHub repositories
 func SearchIssues(options *SearchIssuesOptions, apiReqs *common.APIRequirements) (*common.GitHubSearchIssuesResponse, error) {
 	if err := options.Validate(); err != nil {
-		return nil, err
+		return nil, common.InvalidInput(err)
 	}
 
-	url := "https://api.github.com/search/issues"
+	url := common.APIURL("/search/issues")
 
 	params := map[string]string{
 		"q": options.Query,
@@ -121,31 +110,21 @@ func SearchIssues(options *SearchIssuesOptions, apiReqs *common.APIRequirements)
 		return nil, err
 	}
 
-	resp, err := common.GitHubRequest(fullURL, "GET", nil, apiReqs)
-	if err != nil {
-		return nil, err
-	}
-
-	var searchResp common.GitHubSearchIssuesResponse
-	jsonData, err := json.Marshal(resp)
+	searchResp, _, err := common.TypedGitHubRequest[common.GitHubSearchIssuesResponse](fullURL, "GET", nil, apiReqs)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := json.Unmarshal(jsonData, &searchResp); err != nil {
-		return nil, err
-	}
-
 	return &searchResp, nil
 }
 
 // SearchUsers searches for users on GitHub
 func SearchUsers(options *SearchUsersOptions, apiReqs *common.APIRequirements) (*common.GitHubSearchUsersResponse, error) {
 	if err := options.Validate(); err != nil {
-		return nil, err
+		return nil, common.InvalidInput(err)
 	}
 
-	url := "https://api.github.com/search/users"
+	url := common.APIURL("/search/users")
 
 	params := map[string]string{
 		"q": options.Query,
@@ -164,20 +143,10 @@ func SearchUsers(options *SearchUsersOptions, apiReqs *common.APIRequirements) (
 		return nil, err
 	}
 
-	resp, err := common.GitHubRequest(fullURL, "GET", nil, apiReqs)
-	if err != nil {
-		return nil, err
-	}
-
-	var searchResp common.GitHubSearchUsersResponse
-	jsonData, err := json.Marshal(resp)
+	searchResp, _, err := common.TypedGitHubRequest[common.GitHubSearchUsersResponse](fullURL, "GET", nil, apiReqs)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := json.Unmarshal(jsonData, &searchResp); err != nil {
-		return nil, err
-	}
-
 	return &searchResp, nil
 }
@@ -0,0 +1,77 @@
+package operations
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/metoro-io/github-mcp-server-go/common"
+)
+
+// signCommit adds the author, committer and signature of a signed commit to the body of a
+// create commit request. The signature covers the commit object GitHub builds from the body,
+// so every field of that object, including both dates, is set explicitly.
+func signCommit(body map[string]interface{}, signer *common.CommitSigner, author *CommitterInfo, committer *CommitterInfo, now time.Time) error {
+	signedCommitter := CommitterInfo{Name: signer.Name, Email: signer.Email}
+	if committer != nil {
+		signedCommitter = *committer
+	}
+	if signedCommitter.Date == "" {
+		signedCommitter.Date = now.UTC().Format(time.RFC3339)
+	}
+	signedAuthor := signedCommitter
+	if author != nil {
+		signedAuthor = *author
+		if signedAuthor.Date == "" {
+			signedAuthor.Date = signedCommitter.Date
+		}
+	}
+
+	authorLine, err := identityLine(signedAuthor)
+	if err != nil {
+		return fmt.Errorf("invalid author: %w", err)
+	}
+	committerLine, err := identityLine(signedCommitter)
+	if err != nil {
+		return fmt.Errorf("invalid committer: %w", err)
+	}
+
+	tree, _ := body["tree"].(string)
+	parents, _ := body["parents"].([]string)
+	message, _ := body["message"].(string)
+	signature, err := signer.Sign(commitPayload(tree, parents, authorLine, committerLine, message))
+	if err != nil {
+		return err
+	}
+
+	body["author"] = &signedAuthor
+	body["committer"] = &signedCommitter
+	body["signature"] = signature
+	return nil
+}
+
+// commitPayload builds the canonical commit object that git signs, without its gpgsig header
+func commitPayload(tree string, parents []string, author string, committer string, message string) []byte {
+	var payload strings.Builder
+	fmt.Fprintf(&payload, "tree %s\n", tree)
+	for _, parent := range parents {
+		fmt.Fprintf(&payload, "parent %s\n", parent)
+	}
+	fmt.Fprintf(&payload, "author %s\n", author)
+	fmt.Fprintf(&payload, "committer %s\n", committer)
+	payload.WriteString("\n")
+	payload.WriteString(message)
+	return []byte(payload.String())
+}
+
+// identityLine formats a person as in a commit header: "Name <email> unix-time +hhmm"
+func identityLine(person CommitterInfo) (string, error) {
+	if person.Name == "" || person.Email == "" {
+		return "", fmt.Errorf("a name and email are required for signed commits")
+	}
+	date, err := time.Parse(time.RFC3339, person.Date)
+	if err != nil {
+		return "", fmt.Errorf("date %q must be in ISO 8601 format: YYYY-MM-DDTHH:MM:SSZ", person.Date)
+	}
+	return fmt.Sprintf("%s <%s> %d %s", person.Name, person.Email, date.Unix(), date.Format("-0700")), nil
+}
@@ -0,0 +1,77 @@
+package operations
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/metoro-io/github-mcp-server-go/common"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestCommitPayload(t *testing.T) {
+	author, err := identityLine(CommitterInfo{Name: "Mona Lisa", Email: "mona@example.com", Date: "2024-05-01T12:00:00+02:00"})
+	if err != nil {
+		t.Fatalf("identityLine() error = %v", err)
+	}
+	if author != "Mona Lisa <mona@example.com> 1714557600 +0200" {
+		t.Errorf("identityLine() = %q", author)
+	}
+
+	got := string(commitPayload("tree1", []string{"parent1", "parent2"}, author, author, "Fix typo\n\nDetails"))
+	want := "tree tree1\nparent parent1\nparent parent2\n" +
+		"author Mona Lisa <mona@example.com> 1714557600 +0200\n" +
+		"committer Mona Lisa <mona@example.com> 1714557600 +0200\n" +
+		"\nFix typo\n\nDetails"
+	if got != want {
+		t.Errorf("commitPayload() = %q, want %q", got, want)
+	}
+}
+
+func TestSignCommit(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(privateKey, "")
+	if err != nil {
+		t.Fatalf("MarshalPrivateKey() error = %v", err)
+	}
+	signer, err := common.NewCommitSigner(common.SigningOptions{
+		Format: common.SIGNING_FORMAT_SSH,
+		Key:    pem.EncodeToMemory(block),
+		Name:   "Signing Bot",
+		Email:  "bot@example.com",
+	})
+	if err != nil {
+		t.Fatalf("NewCommitSigner() error = %v", err)
+	}
+
+	body := map[string]interface{}{
+		"message": "Add files",
+		"tree":    "tree1",
+		"parents": []string{"parent1"},
+	}
+	author := &CommitterInfo{Name: "Mona Lisa", Email: "mona@example.com"}
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	if err := signCommit(body, signer, author, nil, now); err != nil {
+		t.Fatalf("signCommit() error = %v", err)
+	}
+
+	committer := body["committer"].(*CommitterInfo)
+	if committer.Name != "Signing Bot" || committer.Date != "2024-05-01T10:00:00Z" {
+		t.Errorf("committer = %+v, want the signer identity dated now", committer)
+	}
+	if signedAuthor := body["author"].(*CommitterInfo); signedAuthor.Name != "Mona Lisa" || signedAuthor.Date != committer.Date {
+		t.Errorf("author = %+v, want Mona Lisa with the committer date", signedAuthor)
+	}
+	if author.Date != "" {
+		t.Errorf("signCommit() must not modify the caller's author")
+	}
+	if signature, _ := body["signature"].(string); !strings.HasPrefix(signature, "-----BEGIN SSH SIGNATURE-----") {
+		t.Errorf("signature = %q, want an SSH signature", signature)
+	}
+}
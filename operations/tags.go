@@ -1,7 +1,6 @@
 package operations
 
 import (
-	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -31,10 +30,10 @@ func (o *GetTagsOptions) Validate() error {
 // GetTags fetches all tags for a GitHub repository
 func GetTags(options *GetTagsOptions, apiReqs *common.APIRequirements) ([]string, error) {
 	if err := options.Validate(); err != nil {
-		return nil, err
+		return nil, common.InvalidInput(err)
 	}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/refs/tags", options.Owner, options.Repo)
+	url := common.APIURL("/repos/%s/%s/git/refs/tags", options.Owner, options.Repo)
 
 	params := make(map[string]string)
 	if options.Page > 0 {
@@ -49,21 +48,11 @@ func GetTags(options *GetTagsOptions, apiReqs *common.APIRequirements) ([]string
 		return nil, err
 	}
 
-	resp, err := common.GitHubRequest(fullURL, "GET", nil, apiReqs)
-	if err != nil {
-		return nil, err
-	}
-
-	var refs []common.GitHubRef
-	jsonData, err := json.Marshal(resp)
+	refs, _, err := common.TypedGitHubRequest[[]common.GitHubRef](fullURL, "GET", nil, apiReqs)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := json.Unmarshal(jsonData, &refs); err != nil {
-		return nil, err
-	}
-
 	refToRet := make([]string, 0, len(refs))
 	for _, ref := range refs {
 		// Extract tag name from ref (e.g., "refs/tags/v1.0.0" -> "v1.0.0")
@@ -0,0 +1,336 @@
+package operations
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/metoro-io/github-mcp-server-go/common"
+)
+
+// Tree output formats
+const (
+	TREE_FORMAT_JSON = "json"
+	TREE_FORMAT_TEXT = "text"
+)
+
+// GetRepositoryTreeOptions defines options for listing the tree of a repository
+type GetRepositoryTreeOptions struct {
+	Owner    string   `json:"owner" jsonschema:"description=The username or organization name that owns the repository"`
+	Repo     string   `json:"repo" jsonschema:"description=The name of the repository"`
+	Ref      string   `json:"ref,omitempty" jsonschema:"description=The branch/tag/commit SHA to list. Default: the repository's default branch"`
+	Path     string   `json:"path,omitempty" jsonschema:"description=Only list entries below this directory. Default: the repository root"`
+	Include  []string `json:"include,omitempty" jsonschema:"description=Glob patterns of paths to include such as src/**/*.go. Patterns without a slash match file names. Default: everything"`
+	Exclude  []string `json:"exclude,omitempty" jsonschema:"description=Glob patterns of paths to exclude such as **/testdata/** or *.lock"`
+	MaxDepth int      `json:"max_depth,omitempty" jsonschema:"description=Maximum depth below path to list. 1 lists only direct children. Default: no limit"`
+	Type     string   `json:"type,omitempty" jsonschema:"description=Only list entries of this type. Can be one of: blob tree commit. Default: all types"`
+	MinSize  int      `json:"min_size,omitempty" jsonschema:"description=Only list files of at least this many bytes"`
+	MaxSize  int      `json:"max_size,omitempty" jsonschema:"description=Only list files of at most this many bytes"`
+	Format   string   `json:"format,omitempty" jsonschema:"description=Output format. Can be one of: json text. text renders a compact indented tree. Default: json"`
+}
+
+// Validate validates the GetRepositoryTreeOptions
+func (o *GetRepositoryTreeOptions) Validate() error {
+	if _, err := common.ValidateOwnerName(o.Owner); err != nil {
+		return err
+	}
+	if _, err := common.ValidateRepositoryName(o.Repo); err != nil {
+		return err
+	}
+	if o.Type != "" && o.Type != "blob" && o.Type != "tree" && o.Type != "commit" {
+		return fmt.Errorf("type must be one of: blob, tree, commit")
+	}
+	if o.Format != "" && o.Format != TREE_FORMAT_JSON && o.Format != TREE_FORMAT_TEXT {
+		return fmt.Errorf("format must be one of: json, text")
+	}
+	if o.MaxDepth < 0 {
+		return fmt.Errorf("max_depth must not be negative")
+	}
+	if o.MinSize < 0 || o.MaxSize < 0 {
+		return fmt.Errorf("min_size and max_size must not be negative")
+	}
+	if o.MaxSize > 0 && o.MinSize > o.MaxSize {
+		return fmt.Errorf("min_size must not be greater than max_size")
+	}
+	for _, pattern := range append(append([]string{}, o.Include...), o.Exclude...) {
+		if _, err := compileGlob(pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetRepositoryTree lists the files of a repository recursively using the Git trees API
+func GetRepositoryTree(options *GetRepositoryTreeOptions, apiReqs *common.APIRequirements) (*common.RepositoryTree, error) {
+	if err := options.Validate(); err != nil {
+		return nil, common.InvalidInput(err)
+	}
+
+	ref := options.Ref
+	if ref == "" {
+		// The trees API resolves HEAD to the default branch
+		ref = "HEAD"
+	}
+
+	url, err := common.BuildURL(common.APIURL("/repos/%s/%s/git/trees/%s", options.Owner, options.Repo, ref),
+		map[string]string{"recursive": "1"})
+	if err != nil {
+		return nil, err
+	}
+
+	tree, _, err := common.TypedGitHubRequest[common.GitHubTree](url, "GET", nil, apiReqs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting tree: %w", err)
+	}
+
+	entries, err := filterTreeEntries(tree.Tree, options)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &common.RepositoryTree{
+		SHA:          tree.SHA,
+		Truncated:    tree.Truncated,
+		TotalEntries: len(tree.Tree),
+		Entries:      entries,
+	}
+	if tree.Truncated {
+		result.Warning = "GitHub truncated this tree because the repository is too large, some entries are missing. Use path to list a smaller directory."
+	}
+	if options.Format == TREE_FORMAT_TEXT {
+		result.Text = renderTree(entries, strings.Trim(options.Path, "/"))
+		result.Entries = nil
+	}
+	return result, nil
+}
+
+// filterTreeEntries applies the path, depth, type, size and glob filters of options
+func filterTreeEntries(entries []common.TreeEntry, options *GetRepositoryTreeOptions) ([]common.TreeEntry, error) {
+	include, err := compileGlobs(options.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compileGlobs(options.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := strings.Trim(options.Path, "/")
+	filtered := []common.TreeEntry{}
+	for _, entry := range entries {
+		relative := entry.Path
+		if prefix != "" {
+			if !strings.HasPrefix(entry.Path, prefix+"/") {
+				continue
+			}
+			relative = strings.TrimPrefix(entry.Path, prefix+"/")
+		}
+		if options.MaxDepth > 0 && strings.Count(relative, "/")+1 > options.MaxDepth {
+			continue
+		}
+		if options.Type != "" && entry.Type != options.Type {
+			continue
+		}
+		if entry.Type == "blob" {
+			if entry.Size < options.MinSize || (options.MaxSize > 0 && entry.Size > options.MaxSize) {
+				continue
+			}
+		} else if options.MinSize > 0 || options.MaxSize > 0 {
+			// Size filters only make sense for files
+			continue
+		}
+		if len(include) > 0 && !matchAnyGlob(include, entry.Path) {
+			continue
+		}
+		// Excluding dir/** also excludes the directory entry itself
+		if matchAnyGlob(exclude, entry.Path) || (entry.Type == "tree" && matchAnyGlob(exclude, entry.Path+"/")) {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+	return filtered, nil
+}
+
+// renderTree renders entries as an indented tree relative to root. Directories of
+// matching entries are shown even when they were filtered out themselves.
+func renderTree(entries []common.TreeEntry, root string) string {
+	sorted := append([]common.TreeEntry{}, entries...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return comparePaths(sorted[i].Path, sorted[j].Path) < 0
+	})
+
+	var b strings.Builder
+	printed := make(map[string]bool)
+	for _, entry := range sorted {
+		relative := entry.Path
+		if root != "" {
+			relative = strings.TrimPrefix(entry.Path, root+"/")
+		}
+		parts := strings.Split(relative, "/")
+
+		for depth := 0; depth < len(parts)-1; depth++ {
+			dir := strings.Join(parts[:depth+1], "/")
+			if !printed[dir] {
+				printed[dir] = true
+				fmt.Fprintf(&b, "%s%s/\n", strings.Repeat("  ", depth), parts[depth])
+			}
+		}
+
+		depth := len(parts) - 1
+		switch entry.Type {
+		case "tree":
+			if !printed[relative] {
+				printed[relative] = true
+				fmt.Fprintf(&b, "%s%s/\n", strings.Repeat("  ", depth), parts[depth])
+			}
+		case "commit":
+			fmt.Fprintf(&b, "%s%s @ %s\n", strings.Repeat("  ", depth), parts[depth], entry.SHA)
+		default:
+			fmt.Fprintf(&b, "%s%s (%d)\n", strings.Repeat("  ", depth), parts[depth], entry.Size)
+		}
+	}
+	return b.String()
+}
+
+// comparePaths orders paths component by component, so a directory's children
+// directly follow the directory
+func comparePaths(a string, b string) int {
+	partsA := strings.Split(a, "/")
+	partsB := strings.Split(b, "/")
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		if partsA[i] != partsB[i] {
+			return strings.Compare(partsA[i], partsB[i])
+		}
+	}
+	return len(partsA) - len(partsB)
+}
+
+// compileGlob converts a glob pattern to a regular expression. * and ? do not match
+// a slash, ** matches any number of directories. Patterns without a slash are
+// matched against the file name only.
+func compileGlob(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("glob pattern must not be empty")
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(pattern, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// **/ matches zero or more directories
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+	}
+	return re, nil
+}
+
+func compileGlobs(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := compileGlob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+func matchAnyGlob(patterns []*regexp.Regexp, name string) bool {
+	for _, re := range patterns {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// treeLookup finds entries of a tree by path, listing each directory on the way once
+type treeLookup struct {
+	owner   string
+	repo    string
+	rootSHA string
+	apiReqs *common.APIRequirements
+	trees   map[string][]common.TreeEntry
+}
+
+func newTreeLookup(owner string, repo string, rootSHA string, apiReqs *common.APIRequirements) *treeLookup {
+	return &treeLookup{
+		owner:   owner,
+		repo:    repo,
+		rootSHA: rootSHA,
+		apiReqs: apiReqs,
+		trees:   make(map[string][]common.TreeEntry),
+	}
+}
+
+// find returns the entry at path with its full path, or nil if there is none
+func (l *treeLookup) find(path string) (*common.TreeEntry, error) {
+	treeSHA := l.rootSHA
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range parts {
+		entries, err := l.list(treeSHA)
+		if err != nil {
+			return nil, err
+		}
+
+		var found *common.TreeEntry
+		for j := range entries {
+			if entries[j].Path == part {
+				entry := entries[j]
+				found = &entry
+				break
+			}
+		}
+		if found == nil {
+			return nil, nil
+		}
+		if i == len(parts)-1 {
+			found.Path = path
+			return found, nil
+		}
+		if found.Type != "tree" {
+			return nil, nil
+		}
+		treeSHA = found.SHA
+	}
+	return nil, nil
+}
+
+func (l *treeLookup) list(sha string) ([]common.TreeEntry, error) {
+	if entries, ok := l.trees[sha]; ok {
+		return entries, nil
+	}
+	url := common.APIURL("/repos/%s/%s/git/trees/%s", l.owner, l.repo, sha)
+	tree, _, err := common.TypedGitHubRequest[common.GitHubTree](url, "GET", nil, l.apiReqs)
+	if err != nil {
+		return nil, err
+	}
+	l.trees[sha] = tree.Tree
+	return tree.Tree, nil
+}
@@ -0,0 +1,147 @@
+package operations
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/metoro-io/github-mcp-server-go/common"
+)
+
+var testTreeEntries = []common.TreeEntry{
+	{Path: "README.md", Type: "blob", Size: 120},
+	{Path: "go.sum", Type: "blob", Size: 90000},
+	{Path: "cmd", Type: "tree"},
+	{Path: "cmd/server", Type: "tree"},
+	{Path: "cmd/server/main.go", Type: "blob", Size: 800},
+	{Path: "pkg", Type: "tree"},
+	{Path: "pkg/api.go", Type: "blob", Size: 3000},
+	{Path: "pkg/testdata", Type: "tree"},
+	{Path: "pkg/testdata/fixture.json", Type: "blob", Size: 50},
+	{Path: "vendor-lib", Type: "commit", SHA: "abc123"},
+}
+
+func TestGetRepositoryTreeOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		options       GetRepositoryTreeOptions
+		wantErr       bool
+		errorContains string
+	}{
+		{
+			name:    "valid options",
+			options: GetRepositoryTreeOptions{Owner: "validowner", Repo: "valid-repo", Include: []string{"**/*.go"}, Format: TREE_FORMAT_TEXT},
+			wantErr: false,
+		},
+		{
+			name:          "invalid type",
+			options:       GetRepositoryTreeOptions{Owner: "validowner", Repo: "valid-repo", Type: "file"},
+			wantErr:       true,
+			errorContains: "type must be",
+		},
+		{
+			name:          "invalid format",
+			options:       GetRepositoryTreeOptions{Owner: "validowner", Repo: "valid-repo", Format: "yaml"},
+			wantErr:       true,
+			errorContains: "format must be",
+		},
+		{
+			name:          "min size above max size",
+			options:       GetRepositoryTreeOptions{Owner: "validowner", Repo: "valid-repo", MinSize: 10, MaxSize: 5},
+			wantErr:       true,
+			errorContains: "min_size",
+		},
+		{
+			name:          "empty glob",
+			options:       GetRepositoryTreeOptions{Owner: "validowner", Repo: "valid-repo", Exclude: []string{""}},
+			wantErr:       true,
+			errorContains: "glob pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.options.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil && tt.errorContains != "" {
+				if !strings.Contains(err.Error(), tt.errorContains) {
+					t.Errorf("Validate() error = %v, should contain %v", err, tt.errorContains)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterTreeEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		options GetRepositoryTreeOptions
+		want    []string
+	}{
+		{
+			name:    "include go files",
+			options: GetRepositoryTreeOptions{Include: []string{"*.go"}},
+			want:    []string{"cmd/server/main.go", "pkg/api.go"},
+		},
+		{
+			name:    "include with directory glob",
+			options: GetRepositoryTreeOptions{Include: []string{"cmd/**"}},
+			want:    []string{"cmd/server", "cmd/server/main.go"},
+		},
+		{
+			name:    "exclude testdata",
+			options: GetRepositoryTreeOptions{Path: "pkg", Exclude: []string{"**/testdata/**"}},
+			want:    []string{"pkg/api.go"},
+		},
+		{
+			name:    "max depth",
+			options: GetRepositoryTreeOptions{MaxDepth: 1, Type: "tree"},
+			want:    []string{"cmd", "pkg"},
+		},
+		{
+			name:    "size filter",
+			options: GetRepositoryTreeOptions{MinSize: 1000},
+			want:    []string{"go.sum", "pkg/api.go"},
+		},
+		{
+			name:    "submodules",
+			options: GetRepositoryTreeOptions{Type: "commit"},
+			want:    []string{"vendor-lib"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := filterTreeEntries(testTreeEntries, &tt.options)
+			if err != nil {
+				t.Fatalf("filterTreeEntries() error = %v", err)
+			}
+			var got []string
+			for _, entry := range entries {
+				got = append(got, entry.Path)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("filterTreeEntries() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTree(t *testing.T) {
+	entries, err := filterTreeEntries(testTreeEntries, &GetRepositoryTreeOptions{Include: []string{"*.go", "*.md"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "README.md (120)\n" +
+		"cmd/\n" +
+		"  server/\n" +
+		"    main.go (800)\n" +
+		"pkg/\n" +
+		"  api.go (3000)\n"
+	if got := renderTree(entries, ""); got != want {
+		t.Errorf("renderTree() =\n%s\nwant\n%s", got, want)
+	}
+}
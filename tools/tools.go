@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/metoro-io/github-mcp-server-go/common"
 	"github.com/metoro-io/github-mcp-server-go/operations"
@@ -15,94 +18,269 @@ type GitHubTool struct {
 	Name        string
 	Description string
 	Handler     interface{}
+	// Toolset is the group the tool belongs to, used to enable tools selectively
+	Toolset string
+	// ReadOnly is true if the tool never modifies anything on GitHub
+	ReadOnly bool
 }
 
+const (
+	// TOOLSET_ALL enables every toolset
+	TOOLSET_ALL = "all"
+)
+
+// Toolsets is the list of toolset names tools can be grouped under
+var Toolsets = []string{"repos", "refs", "files", "issues", "commits", "checks", "actions", "search"}
+
 // GitHubToolsList is the list of tools available for GitHub operations
 var GitHubToolsList = []GitHubTool{
 	{
 		Name:        "search_repositories",
 		Description: "Search for GitHub repositories",
 		Handler:     SearchRepositoriesHandler,
+		Toolset:     "repos",
+		ReadOnly:    true,
 	},
 	{
 		Name:        "create_repository",
 		Description: "Create a new GitHub repository in your account",
 		Handler:     CreateRepositoryHandler,
+		Toolset:     "repos",
 	},
 	{
 		Name:        "fork_repository",
 		Description: "Fork a GitHub repository to your account or specified organization",
 		Handler:     ForkRepositoryHandler,
+		Toolset:     "repos",
 	},
 	{
 		Name:        "create_branch",
-		Description: "Create a new branch in a GitHub repository",
+		Description: "Create a new branch in a GitHub repository from a branch or tag or commit SHA",
 		Handler:     CreateBranchHandler,
+		Toolset:     "refs",
+	},
+	{
+		Name:        "squash_branch",
+		Description: "Squash all commits of a branch since its merge base with a base branch into one commit and force-update the branch",
+		Handler:     SquashBranchHandler,
+		Toolset:     "refs",
 	},
 	{
 		Name:        "create_or_update_file",
 		Description: "Create or update a single file in a GitHub repository",
 		Handler:     CreateOrUpdateFileHandler,
+		Toolset:     "files",
 	},
 	{
 		Name:        "get_file_contents",
 		Description: "Get the contents of a file or directory from a GitHub repository",
 		Handler:     GetFileContentsHandler,
+		Toolset:     "files",
+		ReadOnly:    true,
+	},
+	{
+		Name:        "get_repository_tree",
+		Description: "List the files of a GitHub repository recursively with glob depth type and size filters",
+		Handler:     GetRepositoryTreeHandler,
+		Toolset:     "files",
+		ReadOnly:    true,
+	},
+	{
+		Name:        "edit_file",
+		Description: "Edit files on a branch by replacing exact strings and commit the result in a single commit",
+		Handler:     EditFileHandler,
+		Toolset:     "files",
+	},
+	{
+		Name:        "apply_patch",
+		Description: "Apply a unified diff to a branch and commit the result in a single commit",
+		Handler:     ApplyPatchHandler,
+		Toolset:     "files",
 	},
 	{
 		Name:        "push_files",
 		Description: "Push multiple files to a GitHub repository in a single commit",
 		Handler:     PushFilesHandler,
+		Toolset:     "files",
 	},
 	{
 		Name:        "create_issue",
 		Description: "Create a new issue in a GitHub repository",
 		Handler:     CreateIssueHandler,
+		Toolset:     "issues",
 	},
 	{
 		Name:        "get_issue",
 		Description: "Get details of a specific issue in a GitHub repository",
 		Handler:     GetIssueHandler,
+		Toolset:     "issues",
+		ReadOnly:    true,
 	},
 	{
 		Name:        "list_issues",
 		Description: "List issues in a GitHub repository with filtering options",
 		Handler:     ListIssuesHandler,
+		Toolset:     "issues",
+		ReadOnly:    true,
 	},
 	{
 		Name:        "update_issue",
 		Description: "Update an existing issue in a GitHub repository",
 		Handler:     UpdateIssueHandler,
+		Toolset:     "issues",
 	},
 	{
 		Name:        "add_issue_comment",
 		Description: "Add a comment to an existing issue",
 		Handler:     AddIssueCommentHandler,
+		Toolset:     "issues",
 	},
 	{
 		Name:        "list_commits",
 		Description: "Get list of commits of a branch in a GitHub repository",
 		Handler:     ListCommitsHandler,
+		Toolset:     "commits",
+		ReadOnly:    true,
+	},
+	{
+		Name:        "get_commit",
+		Description: "Get a commit by SHA branch or tag with its stats changed files verification and associated pull requests",
+		Handler:     GetCommitHandler,
+		Toolset:     "commits",
+		ReadOnly:    true,
+	},
+	{
+		Name:        "cherry_pick_commit",
+		Description: "Apply the changes of a commit onto the head of a branch in a new commit",
+		Handler:     CherryPickCommitHandler,
+		Toolset:     "commits",
+	},
+	{
+		Name:        "revert_commit",
+		Description: "Undo the changes of a commit on a branch in a new commit",
+		Handler:     RevertCommitHandler,
+		Toolset:     "commits",
+	},
+	{
+		Name:        "compare_refs",
+		Description: "Compare two branches tags or commits (base...head) and get the commits and changed files between them",
+		Handler:     CompareRefsHandler,
+		Toolset:     "commits",
+		ReadOnly:    true,
+	},
+	{
+		Name:        "get_ref_status",
+		Description: "Get the combined commit status check runs and check suites of a commit SHA or branch",
+		Handler:     GetRefStatusHandler,
+		Toolset:     "checks",
+		ReadOnly:    true,
+	},
+	{
+		Name:        "create_check_run",
+		Description: "Create a check run on a commit with an optional summary and file annotations",
+		Handler:     CreateCheckRunHandler,
+		Toolset:     "checks",
+	},
+	{
+		Name:        "update_check_run",
+		Description: "Update the status conclusion or output of a check run and add annotations",
+		Handler:     UpdateCheckRunHandler,
+		Toolset:     "checks",
+	},
+	{
+		Name:        "wait_for_checks",
+		Description: "Wait until the checks of a pull request or commit complete or a timeout passes and summarize each check with failure excerpts",
+		Handler:     WaitForChecksHandler,
+		Toolset:     "checks",
+		ReadOnly:    true,
+	},
+	{
+		Name:        "list_workflows",
+		Description: "List the GitHub Actions workflows of a repository",
+		Handler:     ListWorkflowsHandler,
+		Toolset:     "actions",
+		ReadOnly:    true,
+	},
+	{
+		Name:        "list_workflow_runs",
+		Description: "List workflow runs of a repository or of one workflow filtered by branch event status or actor",
+		Handler:     ListWorkflowRunsHandler,
+		Toolset:     "actions",
+		ReadOnly:    true,
+	},
+	{
+		Name:        "get_workflow_run",
+		Description: "Get a workflow run with its jobs and their steps",
+		Handler:     GetWorkflowRunHandler,
+		Toolset:     "actions",
+		ReadOnly:    true,
+	},
+	{
+		Name:        "get_job_logs",
+		Description: "Get the log of a workflow job distilled to the failing step with context around the error lines",
+		Handler:     GetJobLogsHandler,
+		Toolset:     "actions",
+		ReadOnly:    true,
+	},
+	{
+		Name:        "list_artifacts",
+		Description: "List the artifacts uploaded by a workflow run",
+		Handler:     ListArtifactsHandler,
+		Toolset:     "actions",
+		ReadOnly:    true,
+	},
+	{
+		Name:        "download_artifact",
+		Description: "Download an artifact and return its file list and text files with JUnit test reports summarized as pass/fail results",
+		Handler:     DownloadArtifactHandler,
+		Toolset:     "actions",
+		ReadOnly:    true,
+	},
+	{
+		Name:        "rerun_failed_jobs",
+		Description: "Re-run the failed jobs of a workflow run and the jobs that depend on them",
+		Handler:     RerunFailedJobsHandler,
+		Toolset:     "actions",
+	},
+	{
+		Name:        "cancel_workflow_run",
+		Description: "Cancel a workflow run",
+		Handler:     CancelWorkflowRunHandler,
+		Toolset:     "actions",
+	},
+	{
+		Name:        "dispatch_workflow",
+		Description: "Trigger a workflow with a workflow_dispatch event after checking the inputs against the ones the workflow declares",
+		Handler:     DispatchWorkflowHandler,
+		Toolset:     "actions",
 	},
 	{
 		Name:        "search_code",
 		Description: "Search for code across GitHub repositories",
 		Handler:     SearchCodeHandler,
+		Toolset:     "search",
+		ReadOnly:    true,
 	},
 	{
 		Name:        "search_issues",
 		Description: "Search for issues and pull requests across GitHub repositories",
 		Handler:     SearchIssuesHandler,
+		Toolset:     "search",
+		ReadOnly:    true,
 	},
 	{
 		Name:        "search_users",
 		Description: "Search for users on GitHub",
 		Handler:     SearchUsersHandler,
+		Toolset:     "search",
+		ReadOnly:    true,
 	},
 	{
 		Name:        "get_tags",
 		Description: "Get all tags for a GitHub repository",
 		Handler:     GetTagsHandler,
+		Toolset:     "refs",
+		ReadOnly:    true,
 	},
 }
 
@@ -174,6 +352,23 @@ func CreateBranchHandler(ctx context.Context, args operations.CreateBranchOption
 	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
 }
 
+// SquashBranchHandler handles squash_branch requests
+func SquashBranchHandler(ctx context.Context, args operations.SquashBranchOptions) (*mcpgolang.ToolResponse, error) {
+	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
+
+	result, err := operations.SquashBranch(&args, apiReqs)
+	if err != nil {
+		return nil, formatError(err)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
+}
+
 // GetFileContentsHandler handles get_file_contents requests
 func GetFileContentsHandler(ctx context.Context, args operations.GetFileContentsOptions) (*mcpgolang.ToolResponse, error) {
 	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
@@ -183,6 +378,43 @@ func GetFileContentsHandler(ctx context.Context, args operations.GetFileContents
 		return nil, formatError(err)
 	}
 
+	// Whole images are returned as image content so clients can display them, with the metadata alongside
+	if file, ok := result.(common.FileContent); ok && file.Encoding == operations.ENCODING_BASE64 && strings.HasPrefix(file.MimeType, "image/") && file.Range == nil {
+		image := mcpgolang.NewImageContent(file.Content, file.MimeType)
+		file.Content = ""
+		jsonData, err := json.MarshalIndent(file, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return mcpgolang.NewToolResponse(image, mcpgolang.NewTextContent(string(jsonData))), nil
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
+}
+
+// GetRepositoryTreeHandler handles get_repository_tree requests
+func GetRepositoryTreeHandler(ctx context.Context, args operations.GetRepositoryTreeOptions) (*mcpgolang.ToolResponse, error) {
+	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
+
+	result, err := operations.GetRepositoryTree(&args, apiReqs)
+	if err != nil {
+		return nil, formatError(err)
+	}
+
+	// The text format is returned as is, escaping it as JSON would defeat its compactness
+	if args.Format == operations.TREE_FORMAT_TEXT {
+		header := fmt.Sprintf("tree %s (%d entries)\n", result.SHA, result.TotalEntries)
+		if result.Warning != "" {
+			header += "warning: " + result.Warning + "\n"
+		}
+		return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(header + result.Text)), nil
+	}
+
 	jsonData, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
 		return nil, err
@@ -225,6 +457,40 @@ func PushFilesHandler(ctx context.Context, args operations.PushFilesOptions) (*m
 	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
 }
 
+// EditFileHandler handles edit_file requests
+func EditFileHandler(ctx context.Context, args operations.EditFileOptions) (*mcpgolang.ToolResponse, error) {
+	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
+
+	result, err := operations.EditFile(&args, apiReqs)
+	if err != nil {
+		return nil, formatError(err)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
+}
+
+// ApplyPatchHandler handles apply_patch requests
+func ApplyPatchHandler(ctx context.Context, args operations.ApplyPatchOptions) (*mcpgolang.ToolResponse, error) {
+	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
+
+	result, err := operations.ApplyPatch(&args, apiReqs)
+	if err != nil {
+		return nil, formatError(err)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
+}
+
 // CreateIssueHandler handles create_issue requests
 func CreateIssueHandler(ctx context.Context, args operations.CreateIssueOptions) (*mcpgolang.ToolResponse, error) {
 	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
@@ -327,6 +593,312 @@ func ListCommitsHandler(ctx context.Context, args operations.ListCommitsOptions)
 	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
 }
 
+// GetCommitHandler handles get_commit requests
+func GetCommitHandler(ctx context.Context, args operations.GetCommitOptions) (*mcpgolang.ToolResponse, error) {
+	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
+
+	result, err := operations.GetCommit(&args, apiReqs)
+	if err != nil {
+		return nil, formatError(err)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
+}
+
+// CherryPickCommitHandler handles cherry_pick_commit requests
+func CherryPickCommitHandler(ctx context.Context, args operations.CherryPickCommitOptions) (*mcpgolang.ToolResponse, error) {
+	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
+
+	result, err := operations.CherryPickCommit(&args, apiReqs)
+	if err != nil {
+		return nil, formatError(err)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
+}
+
+// RevertCommitHandler handles revert_commit requests
+func RevertCommitHandler(ctx context.Context, args operations.RevertCommitOptions) (*mcpgolang.ToolResponse, error) {
+	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
+
+	result, err := operations.RevertCommit(&args, apiReqs)
+	if err != nil {
+		return nil, formatError(err)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
+}
+
+// CompareRefsHandler handles compare_refs requests
+func CompareRefsHandler(ctx context.Context, args operations.CompareRefsOptions) (*mcpgolang.ToolResponse, error) {
+	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
+
+	result, err := operations.CompareRefs(&args, apiReqs)
+	if err != nil {
+		return nil, formatError(err)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
+}
+
+// GetRefStatusHandler handles get_ref_status requests
+func GetRefStatusHandler(ctx context.Context, args operations.GetRefStatusOptions) (*mcpgolang.ToolResponse, error) {
+	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
+
+	result, err := operations.GetRefStatus(&args, apiReqs)
+	if err != nil {
+		return nil, formatError(err)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
+}
+
+// CreateCheckRunHandler handles create_check_run requests
+func CreateCheckRunHandler(ctx context.Context, args operations.CreateCheckRunOptions) (*mcpgolang.ToolResponse, error) {
+	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
+
+	result, err := operations.CreateCheckRun(&args, apiReqs)
+	if err != nil {
+		return nil, formatError(err)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
+}
+
+// UpdateCheckRunHandler handles update_check_run requests
+func UpdateCheckRunHandler(ctx context.Context, args operations.UpdateCheckRunOptions) (*mcpgolang.ToolResponse, error) {
+	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
+
+	result, err := operations.UpdateCheckRun(&args, apiReqs)
+	if err != nil {
+		return nil, formatError(err)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
+}
+
+// WaitForChecksHandler handles wait_for_checks requests. After each poll it sends a progress
+// notification counting the seconds waited out of the timeout, when the client passed a progress token.
+func WaitForChecksHandler(ctx context.Context, args operations.WaitForChecksOptions) (*mcpgolang.ToolResponse, error) {
+	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
+
+	timeout := args.TimeoutSeconds
+	if timeout == 0 {
+		timeout = operations.DEFAULT_WAIT_TIMEOUT_SECONDS
+	}
+	args.Progress = func(progress common.CheckWaitProgress) {
+		slog.Debug("waiting for checks",
+			"repo", args.Owner+"/"+args.Repo,
+			"sha", progress.SHA,
+			"poll", progress.Poll,
+			"elapsed", progress.Elapsed.Round(time.Second).String(),
+			"state", progress.State,
+			"completed", progress.Completed,
+			"total", progress.Total)
+		message := fmt.Sprintf("%d of %d checks completed, state %s", progress.Completed, progress.Total, progress.State)
+		common.SendProgress(ctx, progress.Elapsed.Seconds(), float64(timeout), message)
+	}
+	result, err := operations.WaitForChecks(ctx, &args, apiReqs)
+	if err != nil {
+		return nil, formatError(err)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
+}
+
+// ListWorkflowsHandler handles list_workflows requests
+func ListWorkflowsHandler(ctx context.Context, args operations.ListWorkflowsOptions) (*mcpgolang.ToolResponse, error) {
+	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
+
+	result, err := operations.ListWorkflows(&args, apiReqs)
+	if err != nil {
+		return nil, formatError(err)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
+}
+
+// ListWorkflowRunsHandler handles list_workflow_runs requests
+func ListWorkflowRunsHandler(ctx context.Context, args operations.ListWorkflowRunsOptions) (*mcpgolang.ToolResponse, error) {
+	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
+
+	result, err := operations.ListWorkflowRuns(&args, apiReqs)
+	if err != nil {
+		return nil, formatError(err)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
+}
+
+// GetWorkflowRunHandler handles get_workflow_run requests
+func GetWorkflowRunHandler(ctx context.Context, args operations.WorkflowRunOptions) (*mcpgolang.ToolResponse, error) {
+	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
+
+	result, err := operations.GetWorkflowRun(&args, apiReqs)
+	if err != nil {
+		return nil, formatError(err)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
+}
+
+// GetJobLogsHandler handles get_job_logs requests
+func GetJobLogsHandler(ctx context.Context, args operations.GetJobLogsOptions) (*mcpgolang.ToolResponse, error) {
+	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
+
+	result, err := operations.GetJobLogs(&args, apiReqs)
+	if err != nil {
+		return nil, formatError(err)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
+}
+
+// ListArtifactsHandler handles list_artifacts requests
+func ListArtifactsHandler(ctx context.Context, args operations.ListArtifactsOptions) (*mcpgolang.ToolResponse, error) {
+	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
+
+	result, err := operations.ListArtifacts(&args, apiReqs)
+	if err != nil {
+		return nil, formatError(err)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
+}
+
+// DownloadArtifactHandler handles download_artifact requests
+func DownloadArtifactHandler(ctx context.Context, args operations.DownloadArtifactOptions) (*mcpgolang.ToolResponse, error) {
+	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
+
+	result, err := operations.DownloadArtifact(&args, apiReqs)
+	if err != nil {
+		return nil, formatError(err)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
+}
+
+// RerunFailedJobsHandler handles rerun_failed_jobs requests
+func RerunFailedJobsHandler(ctx context.Context, args operations.RerunFailedJobsOptions) (*mcpgolang.ToolResponse, error) {
+	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
+
+	result, err := operations.RerunFailedJobs(&args, apiReqs)
+	if err != nil {
+		return nil, formatError(err)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
+}
+
+// CancelWorkflowRunHandler handles cancel_workflow_run requests
+func CancelWorkflowRunHandler(ctx context.Context, args operations.CancelWorkflowRunOptions) (*mcpgolang.ToolResponse, error) {
+	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
+
+	result, err := operations.CancelWorkflowRun(&args, apiReqs)
+	if err != nil {
+		return nil, formatError(err)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
+}
+
+// DispatchWorkflowHandler handles dispatch_workflow requests
+func DispatchWorkflowHandler(ctx context.Context, args operations.DispatchWorkflowOptions) (*mcpgolang.ToolResponse, error) {
+	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
+
+	result, err := operations.DispatchWorkflow(&args, apiReqs)
+	if err != nil {
+		return nil, formatError(err)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
+}
+
 // SearchCodeHandler handles search_code requests
 func SearchCodeHandler(ctx context.Context, args operations.SearchCodeOptions) (*mcpgolang.ToolResponse, error) {
 	apiReqs := common.GetGitHubAPIRequirementsFromContext(ctx)
@@ -395,10 +967,60 @@ func GetTagsHandler(ctx context.Context, args operations.GetTagsOptions) (*mcpgo
 	return mcpgolang.NewToolResponse(mcpgolang.NewTextContent(string(jsonData))), nil
 }
 
+// FilterTools returns the tools belonging to the given toolsets, dropping write tools in read-only mode
+func FilterTools(toolsets []string, readOnly bool) ([]GitHubTool, error) {
+	enabled := make(map[string]bool)
+	for _, name := range toolsets {
+		if name == TOOLSET_ALL {
+			for _, ts := range Toolsets {
+				enabled[ts] = true
+			}
+			continue
+		}
+		if !isKnownToolset(name) {
+			return nil, fmt.Errorf("unknown toolset %q, must be one of: %s, %s", name, TOOLSET_ALL, strings.Join(Toolsets, ", "))
+		}
+		enabled[name] = true
+	}
+
+	var filtered []GitHubTool
+	for _, tool := range GitHubToolsList {
+		if !enabled[tool.Toolset] {
+			continue
+		}
+		if readOnly && !tool.ReadOnly {
+			continue
+		}
+		filtered = append(filtered, tool)
+	}
+	return filtered, nil
+}
+
+func isKnownToolset(name string) bool {
+	for _, ts := range Toolsets {
+		if ts == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ToolError is returned by handlers so that the tool result is flagged as an error
+// and its text is a stable JSON payload agents can parse
+type ToolError struct {
+	Details common.ErrorDetails
+}
+
+// Error renders the error payload as JSON
+func (e *ToolError) Error() string {
+	payload, err := json.MarshalIndent(map[string]interface{}{"error": e.Details}, "", "  ")
+	if err != nil {
+		return e.Details.Message
+	}
+	return string(payload)
+}
+
 // formatError formats errors for response
 func formatError(err error) error {
-	if common.IsGitHubError(err) {
-		return fmt.Errorf(common.FormatGitHubError(err))
-	}
-	return err
+	return &ToolError{Details: common.GetErrorDetails(err)}
 }